@@ -1,51 +1,248 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"inventory.com/catalog/internal/controller"
 	"inventory.com/catalog/internal/handler/ginhandler"
+	"inventory.com/catalog/internal/repository/instrumented"
 	"inventory.com/catalog/internal/repository/memory"
+	"inventory.com/catalog/internal/search"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/discovery"
+	"inventory.com/pkg/discovery/registry"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/health"
+	"inventory.com/pkg/lifecycle"
+	"inventory.com/pkg/logging"
+	"inventory.com/pkg/metrics"
+	"inventory.com/pkg/requestid"
+	"inventory.com/pkg/tenant"
+	"inventory.com/pkg/tracing"
+	"inventory.com/pkg/webhook"
 )
 
-var (
-	categoryRepo    *memory.Category
-	subCategoryRepo *memory.SubCategory
-	productRepo     *memory.Product
+const (
+	// priceSchedulerInterval is how often scheduled price changes are checked.
+	priceSchedulerInterval = time.Minute
+	// deletedRetention is how long soft deleted entities can be restored before they are purged.
+	deletedRetention = 30 * 24 * time.Hour
+	// purgeInterval is how often expired soft deleted entities are purged.
+	purgeInterval = time.Hour
+	// eventRelayInterval is how often pending domain events are delivered from the outbox.
+	eventRelayInterval = time.Second
+	// heartbeatInterval is how often a ready instance reports to the service registry.
+	heartbeatInterval = 2 * time.Second
+	// healthCheckTimeout bounds each readiness check.
+	healthCheckTimeout = time.Second
+	// exportWriteTimeout bounds a response, long enough to stream a catalog export.
+	exportWriteTimeout = 5 * time.Minute
 )
 
-var (
-	categoryCtrl    *controller.CategoryController
-	subCategoryCtrl *controller.SubCategoryController
-	productCtrl     *controller.ProductController
-)
+// eventWebhookURL optionally receives every catalog domain event as a JSON POST.
+var eventWebhookURL = os.Getenv("CATALOG_EVENT_WEBHOOK_URL")
+
+// eventWebhookSecret signs the requests of the webhook sink, like the
+// deliveries to webhook subscriptions, when set.
+var eventWebhookSecret = os.Getenv("CATALOG_EVENT_WEBHOOK_SECRET")
+
+// advertiseAddr is the address the catalog registers under in the service registry.
+var advertiseAddr = envOr("CATALOG_ADVERTISE_ADDR", "localhost:8081")
+
+type repositories struct {
+	categories    *instrumented.Category
+	subCategories *instrumented.SubCategory
+	products      *instrumented.Product
+	prices        *instrumented.PriceHistory
+	audit         *audit.MemoryStore
+	outbox        *events.MemoryOutbox
+}
 
-func init() {
-	initRepos()
-	initControllers()
+type controllers struct {
+	categories    *controller.CategoryController
+	subCategories *controller.SubCategoryController
+	products      *controller.ProductController
+	prices        *controller.PriceController
+	transfers     *controller.TransferController
+	retention     *controller.RetentionController
 }
 
 func main() {
+	if err := logging.Init(logging.ConfigFromEnv("catalog")); err != nil {
+		logging.Fatal("failed to set up logging", "error", err)
+	}
+	authenticator, err := auth.Load(auth.ConfigFromEnv())
+	if err != nil {
+		logging.Fatal("failed to set up authentication", "error", err)
+	}
+	tenants, err := tenant.FromEnv()
+	if err != nil {
+		logging.Fatal("failed to set up tenants", "error", err)
+	}
+	shutdownTracing, err := tracing.Init(tracing.ConfigFromEnv("catalog"))
+	if err != nil {
+		logging.Fatal("failed to set up tracing", "error", err)
+	}
+	serviceRegistry, err := registry.FromEnv()
+	if err != nil {
+		logging.Fatal("failed to set up the service registry", "error", err)
+	}
+
+	repos := newRepositories()
+	ctrls := newControllers(repos)
+	eventRelay := newEventRelay(repos.outbox, ctrls.products)
+	registerMetrics(repos, tenants)
+	readiness := newReadiness(repos)
+
 	gin.SetMode(gin.DebugMode)
-	engine := gin.New()
+	engine := newEngine(authenticator, tenants, readiness)
+
+	ginhandler.InitCategoryHandler(engine, ctrls.categories)
+	ginhandler.InitSubCategoryHandler(engine, ctrls.subCategories)
+	ginhandler.InitProductHandler(engine, ctrls.products)
+	ginhandler.InitPriceHandler(engine, ctrls.prices)
+	ginhandler.InitTransferHandler(engine, ctrls.transfers)
+	admin := engine.Group("", auth.Require(auth.PermAdmin))
+	audit.InitHandler(admin, repos.audit)
+	logging.InitHandler(admin)
 
-	ginhandler.InitCategoryHandler(engine, categoryCtrl)
-	ginhandler.InitSubCategoryHandler(engine, subCategoryCtrl)
-	ginhandler.InitProductHandler(engine, productCtrl)
-	if err := engine.Run(":8081"); err != nil {
-		log.Fatalf("[server] Failed to start server: %v", err)
+	cfg := lifecycle.DefaultConfig(":8081")
+	cfg.WriteTimeout = exportWriteTimeout
+	service := lifecycle.New(cfg, engine)
+	readiness.Add("lifecycle", service)
+
+	for _, id := range tenants {
+		service.Go(func(ctx context.Context) {
+			ctrls.products.RunPriceScheduler(tenant.WithID(ctx, id), priceSchedulerInterval)
+		})
+		service.Go(func(ctx context.Context) {
+			ctrls.retention.Run(tenant.WithID(ctx, id), purgeInterval)
+		})
+	}
+	service.Go(func(ctx context.Context) { eventRelay.Run(ctx, eventRelayInterval) })
+	if serviceRegistry != nil {
+		instance := health.Instance{ID: discovery.GenerateInstanceID("catalog"), Service: "catalog", HostPort: advertiseAddr}
+		service.Go(func(ctx context.Context) { readiness.Heartbeat(ctx, serviceRegistry, instance, heartbeatInterval) })
 	}
+	// The events of the last requests are relayed before exiting.
+	service.OnShutdown("events", func(ctx context.Context) error {
+		_, err := eventRelay.Flush(ctx, time.Now())
+		return err
+	})
+	service.OnShutdown("tracing", shutdownTracing)
 
+	if err := service.Run(context.Background()); err != nil {
+		logging.Fatal("server stopped", "error", err)
+	}
 }
 
-func initRepos() {
-	categoryRepo = memory.NewCategory()
-	subCategoryRepo = memory.NewSubCategory()
-	productRepo = memory.NewProduct()
+// newRepositories returns the repositories, traced and timed.
+func newRepositories() *repositories {
+	return &repositories{
+		categories:    instrumented.NewCategory(memory.NewCategory()),
+		subCategories: instrumented.NewSubCategory(memory.NewSubCategory()),
+		products:      instrumented.NewProduct(memory.NewProduct()),
+		prices:        instrumented.NewPriceHistory(memory.NewPriceHistory()),
+		audit:         audit.NewMemoryStore(),
+		outbox:        events.NewMemoryOutbox(),
+	}
 }
-func initControllers() {
-	categoryCtrl = controller.NewCategoryController(categoryRepo)
-	subCategoryCtrl = controller.NewSubCategoryController(subCategoryRepo, categoryCtrl)
-	productCtrl = controller.NewProductController(productRepo, subCategoryCtrl)
+
+func newControllers(repos *repositories) *controllers {
+	auditRecorder := audit.NewRecorder(repos.audit)
+	categories := controller.NewCategoryController(repos.categories, auditRecorder, repos.outbox)
+	subCategories := controller.NewSubCategoryController(repos.subCategories, categories, auditRecorder, repos.outbox)
+	prices := controller.NewPriceController(repos.prices, repos.products)
+	products := controller.NewProductController(repos.products, subCategories, prices, search.NewTenantIndex(), auditRecorder, repos.outbox)
+	categories.CascadeTo(subCategories)
+	subCategories.CascadeTo(products)
+	return &controllers{
+		categories:    categories,
+		subCategories: subCategories,
+		products:      products,
+		prices:        prices,
+		transfers:     controller.NewTransferController(categories, subCategories, products),
+		retention:     controller.NewRetentionController(deletedRetention, repos.products, repos.subCategories, repos.categories, repos.prices, auditRecorder),
+	}
+}
+
+// newEventRelay wires the outbox relay to the in-process bus and, when
+// configured, the webhook sink.
+func newEventRelay(outbox *events.MemoryOutbox, products *controller.ProductController) *events.Relay {
+	eventBus := events.NewInProcessBus()
+	// Product search entries include category and sub-category names.
+	reindex := func(ctx context.Context, _ *events.Event) error { return products.Reindex(ctx) }
+	for _, eventType := range []string{
+		model.EventCategoryUpdated, model.EventCategoryRestored,
+		model.EventSubCategoryUpdated, model.EventSubCategoryRestored,
+	} {
+		eventBus.Subscribe(eventType, reindex)
+	}
+
+	bus := events.MultiBus{eventBus}
+	if eventWebhookURL != "" {
+		var sign events.Signer
+		if eventWebhookSecret != "" {
+			sign = webhook.Signer(eventWebhookSecret)
+		}
+		bus = append(bus, events.NewWebhookSink(eventWebhookURL, 5*time.Second, sign))
+	}
+	return events.NewRelay(outbox, bus, 100, time.Second, 5*time.Minute)
+}
+
+// registerMetrics registers the gauges of the catalog contents of every tenant.
+func registerMetrics(repos *repositories, tenants []tenant.ID) {
+	metrics.RegisterTenantGauge("products", "Products in the catalog, soft deleted ones excluded.", nil, tenants,
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			products, err := repos.products.GetAll(ctx)
+			if errors.Is(err, model.ErrProductNotFound) {
+				return metrics.Count(0), nil
+			}
+			return metrics.Count(len(products)), err
+		})
+	metrics.RegisterTenantGauge("categories", "Categories in the catalog, soft deleted ones excluded.", nil, tenants,
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			categories, err := repos.categories.GetAll(ctx)
+			if errors.Is(err, model.ErrCategoryNotFound) {
+				return metrics.Count(0), nil
+			}
+			return metrics.Count(len(categories)), err
+		})
+}
+
+// newReadiness checks that the repositories answer; a deadlocked repository
+// fails its check by timing out.
+func newReadiness(repos *repositories) *health.Health {
+	readiness := health.New(healthCheckTimeout)
+	readiness.Add("categories", health.CheckerFunc(repos.categories.Ping))
+	readiness.Add("subCategories", health.CheckerFunc(repos.subCategories.Ping))
+	readiness.Add("products", health.CheckerFunc(repos.products.Ping))
+	readiness.Add("prices", health.CheckerFunc(repos.prices.Ping))
+	return readiness
+}
+
+// newEngine returns the engine of the service. The probes and the metrics are
+// served before the authentication and tenant middleware, so that they answer
+// whatever the configured tenants; the routes added afterwards require a known
+// tenant.
+func newEngine(authenticator *auth.Authenticator, tenants []tenant.ID, readiness *health.Health) *gin.Engine {
+	engine := gin.New()
+	engine.Use(requestid.Middleware(), tracing.Middleware(), logging.Middleware(), metrics.Middleware())
+	health.InitHandler(engine, readiness)
+	metrics.InitHandler(engine)
+	engine.Use(auth.Middleware(authenticator), tenant.Middleware(tenants, auth.MayChooseTenant))
+	return engine
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
 }
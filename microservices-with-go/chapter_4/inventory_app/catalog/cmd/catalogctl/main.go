@@ -0,0 +1,116 @@
+// Command catalogctl performs bulk operations against a running catalog service.
+//
+// Usage:
+//
+//	catalogctl import [-addr url] [-format csv|ndjson] [-dry-run] <file>
+//	catalogctl export [-addr url] [-format csv|ndjson] [-o file]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const defaultAddr = "http://0.0.0.0:8081"
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("[catalogctl] %v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: catalogctl import [-addr url] [-format csv|ndjson] [-dry-run] <file>")
+	fmt.Fprintln(os.Stderr, "       catalogctl export [-addr url] [-format csv|ndjson] [-o file]")
+	os.Exit(2)
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	addr := fs.String("addr", defaultAddr, "catalog service address")
+	format := fs.String("format", "", "file format, csv or ndjson (default: from file extension)")
+	dryRun := fs.Bool("dry-run", false, "validate rows without creating anything")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import expects exactly one file argument")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	query := url.Values{"format": {*format}, "dryRun": {strconv.FormatBool(*dryRun)}}
+	resp, err := http.Post(*addr+"/import?"+query.Encode(), "application/octet-stream", file)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The import report is printed as returned by the service.
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		return err
+	}
+	fmt.Println()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("import failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	addr := fs.String("addr", defaultAddr, "catalog service address")
+	format := fs.String("format", "ndjson", "output format, csv or ndjson")
+	output := fs.String("o", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	resp, err := http.Get(*addr + "/export?" + url.Values{"format": {*format}}.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("export failed: %s: %s", resp.Status, body)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"inventory.com/pkg/audit"
+)
+
+// Entity types under which catalog mutations are audited and published as events.
+const (
+	entityCategory    = "category"
+	entitySubCategory = "subcategory"
+	entityProduct     = "product"
+)
+
+// IAuditRecorder records mutations of catalog entities in the audit log.
+type IAuditRecorder interface {
+	Record(ctx context.Context, entityType string, entityID any, op audit.Operation, before, after any) error
+}
+
+// recordAudit records a mutation, wrapping failures so callers can tell them
+// apart from failures of the mutation itself.
+func recordAudit(ctx context.Context, recorder IAuditRecorder, entityType string, entityID any, op audit.Operation, before, after any) error {
+	if err := recorder.Record(ctx, entityType, entityID, op, before, after); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
@@ -2,42 +2,155 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/tracing"
 )
 
 type ICategoryRepository interface {
-	Create(ctx context.Context, data *model.Category) (*model.Category, error)
-	Update(ctx context.Context, id model.CategoryID, data *model.Category) error
+	Create(ctx context.Context, data *model.Category, commit func(before, after *model.Category) error) (*model.Category, error)
+	Update(ctx context.Context, id model.CategoryID, data *model.Category, commit func(before, after *model.Category) error) error
 	Get(ctx context.Context, id model.CategoryID) (*model.Category, error)
 	GetAll(ctx context.Context) ([]*model.Category, error)
-	Delete(ctx context.Context, id model.CategoryID) (*model.Category, error)
+	Delete(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error)
+	GetDeleted(ctx context.Context) ([]*model.Category, error)
+	Restore(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error)
+}
+
+// ISubCategoryCascade soft deletes the sub-categories of a deleted category.
+type ISubCategoryCascade interface {
+	DeleteByCategory(ctx context.Context, id model.CategoryID) error
 }
 
 type CategoryController struct {
-	repo ICategoryRepository
+	repo          ICategoryRepository
+	audit         IAuditRecorder
+	outbox        IEventOutbox
+	subCategories ISubCategoryCascade
 }
 
-func NewCategoryController(repo ICategoryRepository) *CategoryController {
-	return &CategoryController{repo: repo}
+func NewCategoryController(repo ICategoryRepository, audit IAuditRecorder, outbox IEventOutbox) *CategoryController {
+	return &CategoryController{repo: repo, audit: audit, outbox: outbox}
+}
+
+// CascadeTo makes Delete soft delete the sub-categories of a deleted category
+// as well. It is set after construction because the sub-category controller
+// depends on this one.
+func (c *CategoryController) CascadeTo(subCategories ISubCategoryCascade) {
+	c.subCategories = subCategories
 }
 
 func (c *CategoryController) Create(ctx context.Context, data *model.Category) (*model.Category, error) {
-	return c.repo.Create(ctx, data)
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Create")
+	defer span.End()
+
+	if err := model.ValidateSchema(data.Attributes); err != nil {
+		return nil, err
+	}
+	return c.repo.Create(ctx, data, c.commit(ctx, model.EventCategoryCreated, audit.OperationCreate))
 }
 
 func (c *CategoryController) Update(ctx context.Context, id model.CategoryID, data *model.Category) error {
-	return c.repo.Update(ctx, id, data)
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Update")
+	defer span.End()
+
+	if err := model.ValidateSchema(data.Attributes); err != nil {
+		return err
+	}
+	return c.repo.Update(ctx, id, data, c.commit(ctx, model.EventCategoryUpdated, audit.OperationUpdate))
+}
+
+// Patch applies a merge patch or JSON patch to the category and stores the
+// result through Update, so the patched category is validated like a full update.
+func (c *CategoryController) Patch(ctx context.Context, id model.CategoryID, version int, patch jsonpatch.Func) (*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Patch")
+	defer span.End()
+
+	current, err := c.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patched, version, err := applyPatch(current, current.Version, version, patch)
+	if err != nil {
+		return nil, err
+	}
+	patched.ID = id
+	patched.Version = version
+	if err := c.Update(ctx, id, patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
 }
 
 func (c *CategoryController) Get(ctx context.Context, id model.CategoryID) (*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Get")
+	defer span.End()
+
 	return c.repo.Get(ctx, id)
 }
 
 func (c *CategoryController) GetAll(ctx context.Context) ([]*model.Category, error) {
-	return c.repo.GetAll(ctx)
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.GetAll")
+	defer span.End()
+
+	all, err := c.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		return []*model.Category{}, nil
+	}
+	return all, err
+}
+
+func (c *CategoryController) Delete(ctx context.Context, id model.CategoryID, version int) (*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Delete")
+	defer span.End()
+
+	deleted, err := c.repo.Delete(ctx, id, version, c.commit(ctx, model.EventCategoryDeleted, audit.OperationDelete))
+	if err != nil {
+		return nil, err
+	}
+	// Children are deleted after the category, outside of its lock: they
+	// resolve their category on every read and restore, so from here on they
+	// are no longer visible and cannot be restored without it.
+	if c.subCategories != nil {
+		if err := c.subCategories.DeleteByCategory(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to delete the sub-categories of category %d: %w", id, err)
+		}
+	}
+	return deleted, nil
+}
+
+// GetDeleted returns the soft deleted categories that can still be restored.
+func (c *CategoryController) GetDeleted(ctx context.Context) ([]*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.GetDeleted")
+	defer span.End()
+
+	return c.repo.GetDeleted(ctx)
+}
+
+// Restore undoes the soft deletion of a category.
+func (c *CategoryController) Restore(ctx context.Context, id model.CategoryID, version int) (*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Restore")
+	defer span.End()
+
+	return c.repo.Restore(ctx, id, version, c.commit(ctx, model.EventCategoryRestored, audit.OperationRestore))
 }
 
-func (c *CategoryController) Delete(ctx context.Context, id model.CategoryID) (*model.Category, error) {
-	return c.repo.Delete(ctx, id)
+// commit returns the repository hook that publishes a category change as an
+// event of eventType and records it in the audit log as op. The repository
+// runs it while locked, so the change, its event and its audit entry are
+// stored together or not at all.
+func (c *CategoryController) commit(ctx context.Context, eventType string, op audit.Operation) func(before, after *model.Category) error {
+	return func(before, after *model.Category) error {
+		return c.outbox.Transact(ctx, func(tx *events.Tx) error {
+			if err := tx.Emit(eventType, entityCategory, after.ID, after); err != nil {
+				return err
+			}
+			return recordAudit(ctx, c.audit, entityCategory, after.ID, op, before, after)
+		})
+	}
 }
@@ -4,27 +4,49 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"inventory.com/catalog/internal/controller"
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/tenant"
 )
 
+// defaultTenant returns a context bound to tenant.Default, like the ones
+// the tenant middleware hands to the handlers.
+func defaultTenant() context.Context {
+	return tenant.WithID(context.Background(), tenant.Default)
+}
+
 // --- Mocks ---
 type MockCategoryRepo struct {
 	mock.Mock
 }
 
-func (m *MockCategoryRepo) Create(ctx context.Context, data *model.Category) (*model.Category, error) {
+func (m *MockCategoryRepo) Create(ctx context.Context, data *model.Category, commit func(before, after *model.Category) error) (*model.Category, error) {
 	args := m.Called(ctx, data)
-	return args.Get(0).(*model.Category), args.Error(1)
+	created, err := args.Get(0).(*model.Category), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return created, commit(nil, created)
 }
 
-func (m *MockCategoryRepo) Update(ctx context.Context, id model.CategoryID, data *model.Category) error {
+func (m *MockCategoryRepo) Update(ctx context.Context, id model.CategoryID, data *model.Category, commit func(before, after *model.Category) error) error {
 	args := m.Called(ctx, id, data)
-	return args.Error(0)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	before, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return commit(before, data)
 }
 
 func (m *MockCategoryRepo) Get(ctx context.Context, id model.CategoryID) (*model.Category, error) {
@@ -37,20 +59,38 @@ func (m *MockCategoryRepo) GetAll(ctx context.Context) ([]*model.Category, error
 	return args.Get(0).([]*model.Category), args.Error(1)
 }
 
-func (m *MockCategoryRepo) Delete(ctx context.Context, id model.CategoryID) (*model.Category, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*model.Category), args.Error(1)
+func (m *MockCategoryRepo) Delete(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error) {
+	args := m.Called(ctx, id, version)
+	deleted, err := args.Get(0).(*model.Category), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, commit(&model.Category{ID: id}, deleted)
+}
+
+func (m *MockCategoryRepo) GetDeleted(ctx context.Context) ([]*model.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) Restore(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error) {
+	args := m.Called(ctx, id, version)
+	restored, err := args.Get(0).(*model.Category), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return restored, commit(&model.Category{ID: id}, restored)
 }
 
 // --- Tests ---
 func TestCategoryController_Create(t *testing.T) {
 	mockRepo := new(MockCategoryRepo)
-	ctrl := controller.NewCategoryController(mockRepo)
+	ctrl := controller.NewCategoryController(mockRepo, audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
 
 	expected := &model.Category{ID: 1, Name: "Electronics"}
 	mockRepo.On("Create", mock.Anything, expected).Return(expected, nil)
 
-	result, err := ctrl.Create(context.Background(), expected)
+	result, err := ctrl.Create(defaultTenant(), expected)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
@@ -59,12 +99,60 @@ func TestCategoryController_Create(t *testing.T) {
 
 func TestCategoryController_Get_NotFound(t *testing.T) {
 	mockRepo := new(MockCategoryRepo)
-	ctrl := controller.NewCategoryController(mockRepo)
+	ctrl := controller.NewCategoryController(mockRepo, audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
 
 	mockRepo.On("Get", mock.Anything, model.CategoryID(99)).Return(&model.Category{}, errors.New("not found"))
 
-	_, err := ctrl.Get(context.Background(), 99)
+	_, err := ctrl.Get(defaultTenant(), 99)
 
 	assert.Error(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCategoryController_Patch(t *testing.T) {
+	mockRepo := new(MockCategoryRepo)
+	ctrl := controller.NewCategoryController(mockRepo, audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+
+	current := &model.Category{ID: 1, Name: "Electronics", Version: 2}
+	mockRepo.On("Get", mock.Anything, model.CategoryID(1)).Return(current, nil)
+	mockRepo.On("Update", mock.Anything, model.CategoryID(1), &model.Category{ID: 1, Name: "Consumer Electronics", Version: 2}).Return(nil)
+
+	patch, err := jsonpatch.Parse(jsonpatch.MediaTypeMergePatch, []byte(`{"name":"Consumer Electronics","id":7}`))
+	assert.NoError(t, err)
+	result, err := ctrl.Patch(defaultTenant(), 1, model.AnyVersion, patch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Consumer Electronics", result.Name)
+	assert.Equal(t, model.CategoryID(1), result.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCategoryController_Create_EmitsEvent(t *testing.T) {
+	mockRepo := new(MockCategoryRepo)
+	outbox := events.NewMemoryOutbox()
+	ctrl := controller.NewCategoryController(mockRepo, audit.NewRecorder(audit.NewMemoryStore()), outbox)
+
+	created := &model.Category{ID: 3, Name: "Garden"}
+	mockRepo.On("Create", mock.Anything, created).Return(created, nil)
+
+	_, err := ctrl.Create(defaultTenant(), created)
+	assert.NoError(t, err)
+
+	pending, err := outbox.Pending(defaultTenant(), time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, model.EventCategoryCreated, pending[0].Event.Type)
+	assert.Equal(t, "3", pending[0].Event.AggregateID)
+}
+
+func TestCategoryController_GetAll_Empty(t *testing.T) {
+	mockRepo := new(MockCategoryRepo)
+	ctrl := controller.NewCategoryController(mockRepo, audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.Category(nil), model.ErrCategoryNotFound)
+
+	result, err := ctrl.GetAll(defaultTenant())
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+}
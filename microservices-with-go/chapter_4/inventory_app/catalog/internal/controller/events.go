@@ -0,0 +1,12 @@
+package controller
+
+import (
+	"context"
+
+	"inventory.com/pkg/events"
+)
+
+// IEventOutbox stores domain events together with the changes that cause them.
+type IEventOutbox interface {
+	Transact(ctx context.Context, fn func(tx *events.Tx) error) error
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/jsonpatch"
+)
+
+// applyPatch applies a patch to the current state of an entity and returns the
+// patched copy together with the version the update must be conditioned on.
+// Without an explicit version the current one is used, so a concurrent write
+// between reading and updating the entity is still detected.
+func applyPatch[T any](current *T, currentVersion, version int, patch jsonpatch.Func) (*T, int, error) {
+	patched, err := jsonpatch.ApplyTo(current, patch)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version == model.AnyVersion {
+		version = currentVersion
+	}
+	return patched, version, nil
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/identity"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tracing"
+)
+
+type IPriceHistoryRepository interface {
+	Add(ctx context.Context, change *model.PriceChange) error
+	GetByProductID(ctx context.Context, id model.ProductID) ([]*model.PriceChange, error)
+	At(ctx context.Context, id model.ProductID, at time.Time) (*model.PriceChange, error)
+}
+
+// PriceController maintains the price history of products. Scheduled price
+// changes are applied to the products by ProductController.ApplyScheduledPrices.
+type PriceController struct {
+	repo     IPriceHistoryRepository
+	products IProductRepository
+}
+
+func NewPriceController(repo IPriceHistoryRepository, products IProductRepository) *PriceController {
+	return &PriceController{
+		repo:     repo,
+		products: products,
+	}
+}
+
+// Record adds a price change effective immediately, attributed to the caller in ctx.
+func (c *PriceController) Record(ctx context.Context, id model.ProductID, price money.Money) error {
+	ctx, span := tracing.Start(ctx, "controller.PriceController.Record")
+	defer span.End()
+
+	now := time.Now()
+	return c.repo.Add(ctx, &model.PriceChange{
+		ProductID:     id,
+		Price:         price,
+		EffectiveFrom: now,
+		ChangedBy:     identity.Actor(ctx),
+		ChangedAt:     now,
+	})
+}
+
+// Schedule registers a future price change for an existing product.
+func (c *PriceController) Schedule(ctx context.Context, id model.ProductID, price money.Money, effectiveFrom time.Time) (*model.PriceChange, error) {
+	ctx, span := tracing.Start(ctx, "controller.PriceController.Schedule")
+	defer span.End()
+
+	if err := validateListCost(price); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !effectiveFrom.After(now) {
+		return nil, fmt.Errorf("%w: effectiveFrom must be in the future", model.ErrInvalidPriceChange)
+	}
+	if _, err := c.products.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	change := &model.PriceChange{
+		ProductID:     id,
+		Price:         price,
+		EffectiveFrom: effectiveFrom,
+		ChangedBy:     identity.Actor(ctx),
+		ChangedAt:     now,
+	}
+	if err := c.repo.Add(ctx, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// History returns every price change of a product, including scheduled ones.
+func (c *PriceController) History(ctx context.Context, id model.ProductID) ([]*model.PriceChange, error) {
+	ctx, span := tracing.Start(ctx, "controller.PriceController.History")
+	defer span.End()
+
+	return c.repo.GetByProductID(ctx, id)
+}
+
+// PriceAt returns the price of a product that was in effect at the given instant.
+func (c *PriceController) PriceAt(ctx context.Context, id model.ProductID, at time.Time) (*model.PriceChange, error) {
+	ctx, span := tracing.Start(ctx, "controller.PriceController.PriceAt")
+	defer span.End()
+
+	return c.repo.At(ctx, id, at)
+}
@@ -2,44 +2,233 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"inventory.com/catalog/internal/search"
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tracing"
 )
 
 type IProductRepository interface {
-	Create(ctx context.Context, data *model.ProductBasic) (*model.ProductBasic, error)
-	Update(ctx context.Context, id model.ProductID, data *model.ProductBasic) error
+	Create(ctx context.Context, data *model.ProductBasic, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error)
+	Update(ctx context.Context, id model.ProductID, data *model.ProductBasic, commit func(before, after *model.ProductBasic) error) error
 	Get(ctx context.Context, id model.ProductID) (*model.ProductBasic, error)
 	GetAll(ctx context.Context) ([]*model.ProductBasic, error)
-	Delete(ctx context.Context, id model.ProductID) (*model.ProductBasic, error)
+	Delete(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error)
+	GetDeleted(ctx context.Context) ([]*model.ProductBasic, error)
+	Restore(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error)
 }
 
 type ISubCategoryGetController interface {
 	Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryDetails, error)
 }
 
+// IPriceRecorder records list cost changes in the product price history and
+// looks up the price in effect at a point in time.
+type IPriceRecorder interface {
+	Record(ctx context.Context, id model.ProductID, price money.Money) error
+	PriceAt(ctx context.Context, id model.ProductID, at time.Time) (*model.PriceChange, error)
+}
+
+// IProductIndex is the full-text index kept up to date with product changes,
+// holding the products of the tenant of ctx.
+type IProductIndex interface {
+	Put(ctx context.Context, id model.ProductID, fields ...search.Field)
+	Remove(ctx context.Context, id model.ProductID)
+	Search(ctx context.Context, query string, limit int) []search.Hit
+}
+
 type ProductController struct {
 	repo                  IProductRepository
 	subCategoryController ISubCategoryGetController
+	prices                IPriceRecorder
+	index                 IProductIndex
+	audit                 IAuditRecorder
+	outbox                IEventOutbox
 }
 
-func NewProductController(repo IProductRepository, subCategoryController ISubCategoryGetController) *ProductController {
+func NewProductController(repo IProductRepository, subCategoryController ISubCategoryGetController, prices IPriceRecorder, index IProductIndex, audit IAuditRecorder, outbox IEventOutbox) *ProductController {
 	return &ProductController{
 		repo:                  repo,
 		subCategoryController: subCategoryController,
+		prices:                prices,
+		index:                 index,
+		audit:                 audit,
+		outbox:                outbox,
 	}
 }
 
 func (p *ProductController) Create(ctx context.Context, data *model.ProductBasic) (*model.ProductBasic, error) {
-	return p.repo.Create(ctx, data)
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Create")
+	defer span.End()
+
+	if err := validateListCost(data.ListCost); err != nil {
+		return nil, err
+	}
+	subCat, err := p.validateAttributes(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return p.repo.Create(ctx, data, p.commit(ctx, model.EventProductCreated, audit.OperationCreate, func(_, after *model.ProductBasic) error {
+		if err := p.prices.Record(ctx, after.ID, after.ListCost); err != nil {
+			return fmt.Errorf("failed to record initial price: %w", err)
+		}
+		p.index.Put(ctx, after.ID, productFields(after, subCat)...)
+		return nil
+	}))
 }
 
 func (p *ProductController) Update(ctx context.Context, id model.ProductID, data *model.ProductBasic) error {
-	return p.repo.Update(ctx, id, data)
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Update")
+	defer span.End()
+
+	if err := validateListCost(data.ListCost); err != nil {
+		return err
+	}
+	subCat, err := p.validateAttributes(ctx, data)
+	if err != nil {
+		return err
+	}
+	return p.repo.Update(ctx, id, data, p.commit(ctx, model.EventProductUpdated, audit.OperationUpdate, func(before, after *model.ProductBasic) error {
+		if after.ListCost != before.ListCost {
+			if err := p.recordPrice(ctx, id, after.ListCost); err != nil {
+				return fmt.Errorf("failed to record price change: %w", err)
+			}
+		}
+		p.index.Put(ctx, id, productFields(after, subCat)...)
+		return nil
+	}))
+}
+
+// recordPrice records a new list cost in the price history, unless it is
+// already the price in effect, as when a scheduled price change became due.
+func (p *ProductController) recordPrice(ctx context.Context, id model.ProductID, cost money.Money) error {
+	current, err := p.prices.PriceAt(ctx, id, time.Now())
+	if err != nil && !errors.Is(err, model.ErrPriceNotFound) {
+		return err
+	}
+	if err == nil && current.Price == cost {
+		return nil
+	}
+	return p.prices.Record(ctx, id, cost)
+}
+
+// ApplyScheduledPrices updates the list cost of every product whose price in
+// effect at now differs from it, which activates scheduled price changes.
+// Changes go through Update, so they are indexed, audited and published like
+// any other product update.
+func (p *ProductController) ApplyScheduledPrices(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.ApplyScheduledPrices")
+	defer span.End()
+
+	products, err := p.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrProductNotFound) {
+		return nil // an empty catalog has nothing to apply
+	}
+	if err != nil {
+		return err
+	}
+	for _, pb := range products {
+		current, err := p.prices.PriceAt(ctx, pb.ID, now)
+		if errors.Is(err, model.ErrPriceNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up the price of product %d: %w", pb.ID, err)
+		}
+		if current.Price == pb.ListCost {
+			continue
+		}
+		updated := *pb
+		updated.ListCost = current.Price
+		if err := p.Update(ctx, pb.ID, &updated); err != nil {
+			return fmt.Errorf("failed to apply scheduled price for product %d: %w", pb.ID, err)
+		}
+	}
+	return nil
+}
+
+// RunPriceScheduler applies due price changes every interval until ctx is cancelled.
+func (p *ProductController) RunPriceScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := p.ApplyScheduledPrices(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "failed to apply scheduled prices", "error", err)
+			}
+		}
+	}
+}
+
+// Patch applies a merge patch or JSON patch to the basic product and stores
+// the result through Update, so list cost and attributes are validated and
+// price history and search index stay up to date.
+func (p *ProductController) Patch(ctx context.Context, id model.ProductID, version int, patch jsonpatch.Func) (*model.ProductBasic, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Patch")
+	defer span.End()
+
+	current, err := p.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patched, version, err := applyPatch(current, current.Version, version, patch)
+	if err != nil {
+		return nil, err
+	}
+	patched.ID = id
+	patched.Version = version
+	if err := p.Update(ctx, id, patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// validateListCost ensures the list cost has a known currency and is not negative.
+func validateListCost(cost money.Money) error {
+	if err := cost.Validate(); err != nil {
+		return err
+	}
+	if cost.IsNegative() {
+		return fmt.Errorf("%w: list cost cannot be negative", money.ErrInvalidAmount)
+	}
+	return nil
+}
+
+// validateAttributes checks the product attributes against the schema of
+// the category the product's sub-category belongs to, and returns that sub-category.
+func (p *ProductController) validateAttributes(ctx context.Context, data *model.ProductBasic) (*model.SubCategoryDetails, error) {
+	subCat, err := p.subCategoryController.Get(ctx, data.SubCatID)
+	if errors.Is(err, model.ErrSubCategoryNotFound) || errors.Is(err, model.ErrCategoryNotFound) {
+		return nil, fmt.Errorf("%w: sub-category id=%d: %w", model.ErrInvalidSubCategory, data.SubCatID, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve subcategory for attribute validation: %w", err)
+	}
+	var schema []model.AttributeDefinition
+	if subCat.Category != nil {
+		schema = subCat.Category.Attributes
+	}
+	if err := model.ValidateAttributes(schema, data.Attributes); err != nil {
+		return nil, err
+	}
+	return subCat, nil
 }
 
 func (p *ProductController) Get(ctx context.Context, id model.ProductID) (*model.ProductInformation, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Get")
+	defer span.End()
+
 	pb, err := p.repo.Get(ctx, id)
 	if err != nil {
 		return nil, err
@@ -57,19 +246,31 @@ func (p *ProductController) Get(ctx context.Context, id model.ProductID) (*model
 			Description:  pb.Description,
 			Manufacturer: pb.Manufacturer,
 			ListCost:     pb.ListCost,
+			Attributes:   pb.Attributes,
+			Version:      pb.Version,
 		},
 		SubCategoryDetails: subCat,
 	}, nil
 }
 
-func (p *ProductController) GetAll(ctx context.Context) ([]*model.ProductInformation, error) {
+// GetAll returns all products, keeping only those whose attributes satisfy every filter.
+func (p *ProductController) GetAll(ctx context.Context, filters ...model.AttributeFilter) ([]*model.ProductInformation, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.GetAll")
+	defer span.End()
+
 	all, err := p.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrProductNotFound) {
+		return []*model.ProductInformation{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	var result []*model.ProductInformation
+	result := []*model.ProductInformation{}
 	for _, pb := range all {
+		if !matchesAll(pb.Attributes, filters) {
+			continue
+		}
 		subCat, err := p.subCategoryController.Get(ctx, pb.SubCatID)
 		if err != nil {
 			continue // optionally log
@@ -81,6 +282,8 @@ func (p *ProductController) GetAll(ctx context.Context) ([]*model.ProductInforma
 				Description:  pb.Description,
 				Manufacturer: pb.Manufacturer,
 				ListCost:     pb.ListCost,
+				Attributes:   pb.Attributes,
+				Version:      pb.Version,
 			},
 			SubCategoryDetails: subCat,
 		})
@@ -88,6 +291,164 @@ func (p *ProductController) GetAll(ctx context.Context) ([]*model.ProductInforma
 	return result, nil
 }
 
-func (p *ProductController) Delete(ctx context.Context, id model.ProductID) (*model.ProductBasic, error) {
-	return p.repo.Delete(ctx, id)
+func matchesAll(values model.AttributeValues, filters []model.AttributeFilter) bool {
+	for _, f := range filters {
+		if !f.Match(values) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *ProductController) Delete(ctx context.Context, id model.ProductID, version int) (*model.ProductBasic, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Delete")
+	defer span.End()
+
+	return p.repo.Delete(ctx, id, version, p.commit(ctx, model.EventProductDeleted, audit.OperationDelete, func(_, _ *model.ProductBasic) error {
+		p.index.Remove(ctx, id)
+		return nil
+	}))
+}
+
+// DeleteBySubCategory soft deletes every product of a sub-category.
+func (p *ProductController) DeleteBySubCategory(ctx context.Context, id model.SubCategoryID) error {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.DeleteBySubCategory")
+	defer span.End()
+
+	all, err := p.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrProductNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, pb := range all {
+		if pb.SubCatID != id {
+			continue
+		}
+		_, err := p.Delete(ctx, pb.ID, model.AnyVersion)
+		if err != nil && !errors.Is(err, model.ErrProductNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDeleted returns the soft deleted products that can still be restored,
+// keeping only those whose attributes satisfy every filter. The sub-category
+// is left empty when it has been deleted as well.
+func (p *ProductController) GetDeleted(ctx context.Context, filters ...model.AttributeFilter) ([]*model.ProductInformation, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.GetDeleted")
+	defer span.End()
+
+	deleted, err := p.repo.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*model.ProductInformation
+	for _, pb := range deleted {
+		if !matchesAll(pb.Attributes, filters) {
+			continue
+		}
+		subCat, _ := p.subCategoryController.Get(ctx, pb.SubCatID)
+		result = append(result, &model.ProductInformation{
+			ProductBaseInfo:    pb.ProductBaseInfo,
+			SubCategoryDetails: subCat,
+		})
+	}
+	return result, nil
+}
+
+// Restore undoes the soft deletion of a product and adds it back to the search
+// index. Returns model.ErrParentDeleted while its sub-category or category is
+// deleted.
+func (p *ProductController) Restore(ctx context.Context, id model.ProductID, version int) (*model.ProductBasic, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Restore")
+	defer span.End()
+
+	return p.repo.Restore(ctx, id, version, p.commit(ctx, model.EventProductRestored, audit.OperationRestore, func(_, after *model.ProductBasic) error {
+		subCat, err := p.subCategoryController.Get(ctx, after.SubCatID)
+		if errors.Is(err, model.ErrSubCategoryNotFound) || errors.Is(err, model.ErrCategoryNotFound) {
+			return fmt.Errorf("%w: sub-category id=%d of product id=%d: %w", model.ErrParentDeleted, after.SubCatID, id, err)
+		}
+		if err != nil {
+			return err
+		}
+		p.index.Put(ctx, id, productFields(after, subCat)...)
+		return nil
+	}))
+}
+
+// commit returns the repository hook that applies a product change to the
+// price history and search index through apply, publishes it as an event of
+// eventType and records it in the audit log as op, all while the repository
+// is locked like in CategoryController.commit.
+func (p *ProductController) commit(ctx context.Context, eventType string, op audit.Operation, apply func(before, after *model.ProductBasic) error) func(before, after *model.ProductBasic) error {
+	return func(before, after *model.ProductBasic) error {
+		return p.outbox.Transact(ctx, func(tx *events.Tx) error {
+			if err := tx.Emit(eventType, entityProduct, after.ID, after); err != nil {
+				return err
+			}
+			if err := apply(before, after); err != nil {
+				return err
+			}
+			return recordAudit(ctx, p.audit, entityProduct, after.ID, op, before, after)
+		})
+	}
+}
+
+// Search returns up to limit products matching the full-text query, most relevant first.
+func (p *ProductController) Search(ctx context.Context, query string, limit int) ([]*model.ProductSearchResult, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Search")
+	defer span.End()
+
+	var result []*model.ProductSearchResult
+	for _, hit := range p.index.Search(ctx, query, limit) {
+		info, err := p.Get(ctx, hit.ID)
+		if err != nil {
+			continue // removed or no longer resolvable since it was indexed
+		}
+		result = append(result, &model.ProductSearchResult{ProductInformation: info, Score: hit.Score})
+	}
+	return result, nil
+}
+
+// Reindex rebuilds the index entries of all products, e.g. after category or
+// sub-category names changed.
+func (p *ProductController) Reindex(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Reindex")
+	defer span.End()
+
+	all, err := p.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrProductNotFound) {
+		return nil // nothing to index
+	}
+	if err != nil {
+		return err
+	}
+	for _, pb := range all {
+		subCat, err := p.subCategoryController.Get(ctx, pb.SubCatID)
+		if err != nil {
+			continue
+		}
+		p.index.Put(ctx, pb.ID, productFields(pb, subCat)...)
+	}
+	return nil
+}
+
+// productFields returns the weighted text fields of a product used for search.
+func productFields(pb *model.ProductBasic, subCat *model.SubCategoryDetails) []search.Field {
+	fields := []search.Field{
+		{Text: pb.Name, Weight: 3},
+		{Text: pb.Manufacturer, Weight: 2},
+		{Text: pb.Description, Weight: 1},
+	}
+	if subCat != nil {
+		fields = append(fields, search.Field{Text: subCat.Name, Weight: 1.5})
+		if subCat.Category != nil {
+			fields = append(fields, search.Field{Text: subCat.Category.Name, Weight: 1.5})
+		}
+	}
+	return fields
 }
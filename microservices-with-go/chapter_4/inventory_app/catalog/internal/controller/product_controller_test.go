@@ -4,12 +4,29 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"inventory.com/catalog/internal/repository/memory"
+	"inventory.com/catalog/internal/search"
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tenant"
 )
 
+// defaultTenant returns a context bound to tenant.Default, like the ones
+// the tenant middleware hands to the handlers.
+func defaultTenant() context.Context {
+	return tenant.WithID(context.Background(), tenant.Default)
+}
+
+func newAuditRecorder() *audit.Recorder {
+	return audit.NewRecorder(audit.NewMemoryStore())
+}
+
 type MockSubCategoryGetController struct {
 	mock.Mock
 }
@@ -19,18 +36,44 @@ func (m *MockSubCategoryGetController) Get(ctx context.Context, id model.SubCate
 	return args.Get(0).(*model.SubCategoryDetails), args.Error(1)
 }
 
+type MockPriceRecorder struct {
+	mock.Mock
+}
+
+func (m *MockPriceRecorder) Record(ctx context.Context, id model.ProductID, price money.Money) error {
+	args := m.Called(ctx, id, price)
+	return args.Error(0)
+}
+
+func (m *MockPriceRecorder) PriceAt(ctx context.Context, id model.ProductID, at time.Time) (*model.PriceChange, error) {
+	args := m.Called(ctx, id, at)
+	change, _ := args.Get(0).(*model.PriceChange)
+	return change, args.Error(1)
+}
+
 type MockProductRepo struct {
 	mock.Mock
 }
 
-func (m *MockProductRepo) Create(ctx context.Context, data *model.ProductBasic) (*model.ProductBasic, error) {
+func (m *MockProductRepo) Create(ctx context.Context, data *model.ProductBasic, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
 	args := m.Called(ctx, data)
-	return args.Get(0).(*model.ProductBasic), args.Error(1)
+	created, err := args.Get(0).(*model.ProductBasic), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return created, commit(nil, created)
 }
 
-func (m *MockProductRepo) Update(ctx context.Context, id model.ProductID, data *model.ProductBasic) error {
+func (m *MockProductRepo) Update(ctx context.Context, id model.ProductID, data *model.ProductBasic, commit func(before, after *model.ProductBasic) error) error {
 	args := m.Called(ctx, id, data)
-	return args.Error(0)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	before, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return commit(before, data)
 }
 
 func (m *MockProductRepo) Get(ctx context.Context, id model.ProductID) (*model.ProductBasic, error) {
@@ -43,15 +86,33 @@ func (m *MockProductRepo) GetAll(ctx context.Context) ([]*model.ProductBasic, er
 	return args.Get(0).([]*model.ProductBasic), args.Error(1)
 }
 
-func (m *MockProductRepo) Delete(ctx context.Context, id model.ProductID) (*model.ProductBasic, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*model.ProductBasic), args.Error(1)
+func (m *MockProductRepo) Delete(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
+	args := m.Called(ctx, id, version)
+	deleted, err := args.Get(0).(*model.ProductBasic), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, commit(&model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{ID: id}}, deleted)
+}
+
+func (m *MockProductRepo) GetDeleted(ctx context.Context) ([]*model.ProductBasic, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.ProductBasic), args.Error(1)
+}
+
+func (m *MockProductRepo) Restore(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
+	args := m.Called(ctx, id, version)
+	restored, err := args.Get(0).(*model.ProductBasic), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return restored, commit(&model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{ID: id}}, restored)
 }
 
 func TestProductController_GetAll(t *testing.T) {
 	mockRepo := new(MockProductRepo)
 	mockSubCategoryCtrl := new(MockSubCategoryGetController)
-	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl)
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
 
 	subCategory := &model.SubCategoryDetails{
 		SubCategoryBaseInfo: model.SubCategoryBaseInfo{
@@ -72,20 +133,198 @@ func TestProductController_GetAll(t *testing.T) {
 		{ProductBaseInfo: model.ProductBaseInfo{ID: 2, Name: "Phone"}, SubCatID: model.SubCategoryID(1)},
 	}, nil)
 	mockSubCategoryCtrl.On("Get", mock.Anything, mock.Anything).Return(subCategory, nil)
-	result, err := ctrl.GetAll(context.Background())
+	result, err := ctrl.GetAll(defaultTenant())
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestProductController_GetAll_Empty(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	ctrl := NewProductController(mockRepo, new(MockSubCategoryGetController), new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.ProductBasic(nil), model.ErrProductNotFound)
+
+	result, err := ctrl.GetAll(defaultTenant())
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+}
+
 func TestProductController_Delete_Error(t *testing.T) {
 	mockRepo := new(MockProductRepo)
 	mockSubCategoryCtrl := new(MockSubCategoryGetController)
-	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl)
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+
+	mockRepo.On("Delete", mock.Anything, model.ProductID(404), model.AnyVersion).Return((*model.ProductBasic)(nil), model.ErrProductNotFound)
+
+	_, err := ctrl.Delete(defaultTenant(), 404, model.AnyVersion)
+	assert.ErrorIs(t, err, model.ErrProductNotFound)
+	mockRepo.AssertExpectations(t)
+}
 
-	mockRepo.On("Delete", mock.Anything, model.ProductID(404)).Return(&model.ProductBasic{}, errors.New("not found"))
+func TestProductController_Create_InvalidAttribute(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	mockSubCategoryCtrl := new(MockSubCategoryGetController)
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+
+	subCategory := &model.SubCategoryDetails{
+		SubCategoryBaseInfo: model.SubCategoryBaseInfo{ID: 1, Name: "Television"},
+		Category: &model.Category{
+			ID:   1,
+			Name: "Electronics",
+			Attributes: []model.AttributeDefinition{
+				{Name: "screenSize", Type: model.AttributeTypeNumber, Required: true, Unit: "inch"},
+			},
+		},
+	}
+	mockSubCategoryCtrl.On("Get", mock.Anything, model.SubCategoryID(1)).Return(subCategory, nil)
+
+	_, err := ctrl.Create(defaultTenant(), &model.ProductBasic{
+		ProductBaseInfo: model.ProductBaseInfo{
+			Name:       "TV",
+			ListCost:   money.Money{Amount: 49999, Currency: "USD"},
+			Attributes: model.AttributeValues{"screenSize": "large"},
+		},
+		SubCatID: 1,
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidAttribute)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductController_Create_UnknownSubCategory(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	mockSubCategoryCtrl := new(MockSubCategoryGetController)
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+	mockSubCategoryCtrl.On("Get", mock.Anything, model.SubCategoryID(9)).Return((*model.SubCategoryDetails)(nil), model.ErrSubCategoryNotFound)
 
-	_, err := ctrl.Delete(context.Background(), 404)
-	assert.Error(t, err)
+	_, err := ctrl.Create(defaultTenant(), &model.ProductBasic{
+		ProductBaseInfo: model.ProductBaseInfo{Name: "TV", ListCost: money.Money{Amount: 49999, Currency: "USD"}},
+		SubCatID:        9,
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidSubCategory)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductController_Reindex_Errors(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	ctrl := NewProductController(mockRepo, new(MockSubCategoryGetController), new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.ProductBasic(nil), model.ErrProductNotFound).Once()
+	assert.NoError(t, ctrl.Reindex(defaultTenant()), "no products, nothing to index")
+
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.ProductBasic(nil), tenant.ErrUnknownTenant).Once()
+	assert.ErrorIs(t, ctrl.Reindex(defaultTenant()), tenant.ErrUnknownTenant, "the event is retried")
+}
+
+func TestProductController_GetAll_AttributeFilter(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	mockSubCategoryCtrl := new(MockSubCategoryGetController)
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.ProductBasic{
+		{ProductBaseInfo: model.ProductBaseInfo{ID: 1, Attributes: model.AttributeValues{"screenSize": 42.0}}, SubCatID: 1},
+		{ProductBaseInfo: model.ProductBaseInfo{ID: 2, Attributes: model.AttributeValues{"screenSize": 55.0}}, SubCatID: 1},
+	}, nil)
+	mockSubCategoryCtrl.On("Get", mock.Anything, mock.Anything).Return(&model.SubCategoryDetails{}, nil)
+
+	filter, err := model.ParseAttributeFilter("screenSize>=50")
+	assert.NoError(t, err)
+
+	result, err := ctrl.GetAll(defaultTenant(), filter)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, model.ProductID(2), result[0].ID)
+}
+
+func TestProductController_Update_RecordsPriceChange(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	mockSubCategoryCtrl := new(MockSubCategoryGetController)
+	mockPrices := new(MockPriceRecorder)
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, mockPrices, search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+
+	oldCost := money.Money{Amount: 1000, Currency: "USD"}
+	newCost := money.Money{Amount: 1200, Currency: "USD"}
+	updated := &model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{ID: 7, ListCost: newCost}, SubCatID: 1}
+
+	mockSubCategoryCtrl.On("Get", mock.Anything, model.SubCategoryID(1)).Return(&model.SubCategoryDetails{}, nil)
+	mockRepo.On("Get", mock.Anything, model.ProductID(7)).Return(&model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{ID: 7, ListCost: oldCost}}, nil)
+	mockRepo.On("Update", mock.Anything, model.ProductID(7), updated).Return(nil)
+	mockPrices.On("PriceAt", mock.Anything, model.ProductID(7), mock.Anything).Return(&model.PriceChange{Price: oldCost}, nil)
+	mockPrices.On("Record", mock.Anything, model.ProductID(7), newCost).Return(nil)
+
+	assert.NoError(t, ctrl.Update(defaultTenant(), 7, updated))
+	mockRepo.AssertExpectations(t)
+	mockPrices.AssertExpectations(t)
+}
+
+func TestProductController_ApplyScheduledPrices(t *testing.T) {
+	ctx := defaultTenant()
+	mockRepo := new(MockProductRepo)
+	mockSubCategoryCtrl := new(MockSubCategoryGetController)
+	history := memory.NewPriceHistory()
+	auditStore := audit.NewMemoryStore()
+	outbox := events.NewMemoryOutbox()
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, NewPriceController(history, mockRepo), search.NewTenantIndex(), audit.NewRecorder(auditStore), outbox)
+
+	now := time.Now()
+	oldCost := money.Money{Amount: 1000, Currency: "USD"}
+	newCost := money.Money{Amount: 800, Currency: "USD"}
+	assert.NoError(t, history.Add(ctx, &model.PriceChange{ProductID: 7, Price: oldCost, EffectiveFrom: now.Add(-2 * time.Hour)}))
+	assert.NoError(t, history.Add(ctx, &model.PriceChange{ProductID: 7, Price: newCost, EffectiveFrom: now.Add(-time.Minute)}))
+
+	stored := &model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{ID: 7, ListCost: oldCost, Version: 1}, SubCatID: 1}
+	updated := &model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{ID: 7, ListCost: newCost, Version: 1}, SubCatID: 1}
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.ProductBasic{stored}, nil)
+	mockRepo.On("Get", mock.Anything, model.ProductID(7)).Return(stored, nil).Once()
+	mockRepo.On("Get", mock.Anything, model.ProductID(7)).Return(updated, nil)
+	mockRepo.On("Update", mock.Anything, model.ProductID(7), updated).Return(nil)
+	mockSubCategoryCtrl.On("Get", mock.Anything, model.SubCategoryID(1)).Return(&model.SubCategoryDetails{}, nil)
+
+	assert.NoError(t, ctrl.ApplyScheduledPrices(ctx, now))
+	mockRepo.AssertExpectations(t)
+
+	entries, err := history.GetByProductID(ctx, 7)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2, "the scheduled change is not recorded again")
+
+	pending, err := outbox.Pending(ctx, time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, model.EventProductUpdated, pending[0].Event.Type)
+
+	trail, err := auditStore.Find(ctx, audit.Query{EntityType: entityProduct, EntityID: "7"})
+	assert.NoError(t, err)
+	assert.Len(t, trail, 1)
+	assert.Equal(t, audit.OperationUpdate, trail[0].Operation)
+}
+
+func TestProductController_ApplyScheduledPrices_Errors(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	ctrl := NewProductController(mockRepo, new(MockSubCategoryGetController), new(MockPriceRecorder), search.NewTenantIndex(), newAuditRecorder(), events.NewMemoryOutbox())
+
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.ProductBasic(nil), model.ErrProductNotFound).Once()
+	assert.NoError(t, ctrl.ApplyScheduledPrices(defaultTenant(), time.Now()))
+
+	failure := errors.New("storage unavailable")
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.ProductBasic(nil), failure).Once()
+	assert.ErrorIs(t, ctrl.ApplyScheduledPrices(defaultTenant(), time.Now()), failure)
+}
+
+func TestProductController_Restore(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	mockSubCategoryCtrl := new(MockSubCategoryGetController)
+	index := search.NewTenantIndex()
+	ctrl := NewProductController(mockRepo, mockSubCategoryCtrl, new(MockPriceRecorder), index, newAuditRecorder(), events.NewMemoryOutbox())
+
+	restored := &model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{ID: 1, Name: "Laptop", Version: 3}, SubCatID: 1}
+	mockRepo.On("Restore", mock.Anything, model.ProductID(1), 2).Return(restored, nil)
+	mockSubCategoryCtrl.On("Get", mock.Anything, model.SubCategoryID(1)).Return(&model.SubCategoryDetails{}, nil)
+
+	result, err := ctrl.Restore(defaultTenant(), 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, restored, result)
+	hits := index.Search(defaultTenant(), "laptop", 10)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, model.ProductID(1), hits[0].ID)
 	mockRepo.AssertExpectations(t)
 }
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/tracing"
+)
+
+// IPurger permanently removes entities that were soft deleted before a given
+// time, calling commit with every entity before it is removed.
+type IPurger[T any] interface {
+	Purge(ctx context.Context, before time.Time, commit func(purged *T) error) (int, error)
+}
+
+// IPriceHistoryPurger removes the price history of purged products.
+type IPriceHistoryPurger interface {
+	DeleteByProductID(ctx context.Context, id model.ProductID) error
+}
+
+// RetentionController purges soft deleted entities once they have been kept
+// for the retention period, after which they can no longer be restored.
+type RetentionController struct {
+	retention     time.Duration
+	products      IPurger[model.ProductBasic]
+	subCategories IPurger[model.SubCategoryBasic]
+	categories    IPurger[model.Category]
+	prices        IPriceHistoryPurger
+	audit         IAuditRecorder
+}
+
+func NewRetentionController(retention time.Duration, products IPurger[model.ProductBasic], subCategories IPurger[model.SubCategoryBasic], categories IPurger[model.Category], prices IPriceHistoryPurger, audit IAuditRecorder) *RetentionController {
+	return &RetentionController{
+		retention:     retention,
+		products:      products,
+		subCategories: subCategories,
+		categories:    categories,
+		prices:        prices,
+		audit:         audit,
+	}
+}
+
+// Purge removes every entity deleted more than the retention period before now
+// and returns how many were removed. Every removal is recorded in the audit
+// log, and purged products take their price history with them.
+func (c *RetentionController) Purge(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "controller.RetentionController.Purge")
+	defer span.End()
+
+	before := now.Add(-c.retention)
+	purges := []func() (int, error){
+		func() (int, error) {
+			record := audited(ctx, c.audit, entityProduct, func(p *model.ProductBasic) any { return p.ID })
+			return c.products.Purge(ctx, before, func(purged *model.ProductBasic) error {
+				if err := record(purged); err != nil {
+					return err
+				}
+				if err := c.prices.DeleteByProductID(ctx, purged.ID); err != nil {
+					return fmt.Errorf("failed to purge the price history of product %d: %w", purged.ID, err)
+				}
+				return nil
+			})
+		},
+		func() (int, error) {
+			return c.subCategories.Purge(ctx, before, audited(ctx, c.audit, entitySubCategory, func(s *model.SubCategoryBasic) any { return s.BaseInfo.ID }))
+		},
+		func() (int, error) {
+			return c.categories.Purge(ctx, before, audited(ctx, c.audit, entityCategory, func(cat *model.Category) any { return cat.ID }))
+		},
+	}
+	total := 0
+	for _, purge := range purges {
+		purged, err := purge()
+		total += purged
+		if err != nil {
+			return total, fmt.Errorf("failed to purge deleted entities: %w", err)
+		}
+	}
+	return total, nil
+}
+
+// audited returns the purge hook recording the removal of an entity in the audit log.
+func audited[T any](ctx context.Context, recorder IAuditRecorder, entityType string, id func(*T) any) func(purged *T) error {
+	return func(purged *T) error {
+		return recordAudit(ctx, recorder, entityType, id(purged), audit.OperationPurge, purged, nil)
+	}
+}
+
+// Run purges expired entities every interval until ctx is cancelled.
+func (c *RetentionController) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := c.Purge(ctx, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to purge deleted entities", "error", err)
+			}
+			if purged > 0 {
+				slog.InfoContext(ctx, "purged deleted entities", "count", purged)
+			}
+		}
+	}
+}
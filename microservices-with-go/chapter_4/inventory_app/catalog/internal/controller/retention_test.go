@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/internal/repository/memory"
+	"inventory.com/catalog/internal/search"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/money"
+)
+
+// failingRecorder is an audit recorder whose store is unavailable.
+type failingRecorder struct{ err error }
+
+func (r failingRecorder) Record(ctx context.Context, entityType string, entityID any, op audit.Operation, before, after any) error {
+	return r.err
+}
+
+func TestRetentionController_Purge_RecordsAudit(t *testing.T) {
+	ctx := defaultTenant()
+	store := audit.NewMemoryStore()
+	recorder := audit.NewRecorder(store)
+	repo := memory.NewCategory()
+	categories := NewCategoryController(repo, recorder, events.NewMemoryOutbox())
+	retention := NewRetentionController(time.Hour, memory.NewProduct(), memory.NewSubCategory(), repo, memory.NewPriceHistory(), recorder)
+
+	created, err := categories.Create(ctx, &model.Category{Name: "Garden"})
+	require.NoError(t, err)
+	_, err = categories.Delete(ctx, created.ID, model.AnyVersion)
+	require.NoError(t, err)
+
+	purged, err := retention.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, purged, "tombstones are kept for the retention period")
+
+	purged, err = retention.Purge(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	trail, err := store.Find(ctx, audit.Query{EntityType: entityCategory, EntityID: "1"})
+	require.NoError(t, err)
+	require.Len(t, trail, 3)
+	assert.Equal(t, audit.OperationCreate, trail[0].Operation)
+	assert.Equal(t, audit.OperationDelete, trail[1].Operation)
+	assert.Equal(t, audit.OperationPurge, trail[2].Operation)
+	assert.NotEmpty(t, trail[2].Before)
+	assert.Empty(t, trail[2].After)
+}
+
+func TestRetentionController_Purge_KeepsUnauditedEntities(t *testing.T) {
+	ctx := defaultTenant()
+	repo := memory.NewCategory()
+	categories := NewCategoryController(repo, newAuditRecorder(), events.NewMemoryOutbox())
+	created, err := categories.Create(ctx, &model.Category{Name: "Garden"})
+	require.NoError(t, err)
+	_, err = categories.Delete(ctx, created.ID, model.AnyVersion)
+	require.NoError(t, err)
+
+	failure := errors.New("audit store unavailable")
+	retention := NewRetentionController(time.Hour, memory.NewProduct(), memory.NewSubCategory(), repo, memory.NewPriceHistory(), failingRecorder{failure})
+	_, err = retention.Purge(ctx, time.Now().Add(2*time.Hour))
+	assert.ErrorIs(t, err, failure)
+
+	deleted, err := categories.GetDeleted(ctx)
+	require.NoError(t, err)
+	assert.Len(t, deleted, 1, "a purge that cannot be audited keeps the tombstone")
+}
+
+// catalogFixture wires the catalog controllers to in-memory repositories the
+// way the service does, including the delete cascades.
+type catalogFixture struct {
+	categories    *CategoryController
+	subCategories *SubCategoryController
+	products      *ProductController
+	retention     *RetentionController
+	prices        *memory.PriceHistory
+	audit         *audit.MemoryStore
+}
+
+func newCatalogFixture() *catalogFixture {
+	store := audit.NewMemoryStore()
+	recorder := audit.NewRecorder(store)
+	outbox := events.NewMemoryOutbox()
+	categoryRepo, subCategoryRepo, productRepo := memory.NewCategory(), memory.NewSubCategory(), memory.NewProduct()
+	prices := memory.NewPriceHistory()
+
+	categories := NewCategoryController(categoryRepo, recorder, outbox)
+	subCategories := NewSubCategoryController(subCategoryRepo, categories, recorder, outbox)
+	products := NewProductController(productRepo, subCategories, NewPriceController(prices, productRepo), search.NewTenantIndex(), recorder, outbox)
+	categories.CascadeTo(subCategories)
+	subCategories.CascadeTo(products)
+	return &catalogFixture{
+		categories:    categories,
+		subCategories: subCategories,
+		products:      products,
+		retention:     NewRetentionController(time.Hour, productRepo, subCategoryRepo, categoryRepo, prices, recorder),
+		prices:        prices,
+		audit:         store,
+	}
+}
+
+// seed creates a category with one sub-category holding one product.
+func (f *catalogFixture) seed(t *testing.T) (*model.Category, *model.SubCategoryBasic, *model.ProductBasic) {
+	t.Helper()
+	ctx := defaultTenant()
+	category, err := f.categories.Create(ctx, &model.Category{Name: "Garden"})
+	require.NoError(t, err)
+	subCategory, err := f.subCategories.Create(ctx, &model.SubCategoryBasic{BaseInfo: model.SubCategoryBaseInfo{Name: "Tools"}, CatID: category.ID})
+	require.NoError(t, err)
+	product, err := f.products.Create(ctx, &model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{Name: "Rake", ListCost: money.Money{Amount: 1500, Currency: "USD"}}, SubCatID: subCategory.BaseInfo.ID})
+	require.NoError(t, err)
+	return category, subCategory, product
+}
+
+func TestCategoryController_Delete_Cascades(t *testing.T) {
+	ctx := defaultTenant()
+	f := newCatalogFixture()
+	category, subCategory, product := f.seed(t)
+
+	_, err := f.categories.Delete(ctx, category.ID, model.AnyVersion)
+	require.NoError(t, err)
+
+	subCategories, err := f.subCategories.GetDeleted(ctx)
+	require.NoError(t, err)
+	require.Len(t, subCategories, 1)
+	assert.Equal(t, subCategory.BaseInfo.ID, subCategories[0].ID)
+	products, err := f.products.GetDeleted(ctx)
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, product.ID, products[0].ID)
+
+	_, err = f.products.Restore(ctx, product.ID, model.AnyVersion)
+	assert.ErrorIs(t, err, model.ErrParentDeleted)
+	_, err = f.subCategories.Restore(ctx, subCategory.BaseInfo.ID, model.AnyVersion)
+	assert.ErrorIs(t, err, model.ErrParentDeleted)
+
+	// Restoring from the top down brings the tree back.
+	_, err = f.categories.Restore(ctx, category.ID, model.AnyVersion)
+	require.NoError(t, err)
+	_, err = f.subCategories.Restore(ctx, subCategory.BaseInfo.ID, model.AnyVersion)
+	require.NoError(t, err)
+	_, err = f.products.Restore(ctx, product.ID, model.AnyVersion)
+	require.NoError(t, err)
+	got, err := f.products.Get(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Garden", got.SubCategoryDetails.Category.Name)
+}
+
+func TestRetentionController_Purge_RemovesTreeAndPriceHistory(t *testing.T) {
+	ctx := defaultTenant()
+	f := newCatalogFixture()
+	category, _, product := f.seed(t)
+	_, err := f.prices.GetByProductID(ctx, product.ID)
+	require.NoError(t, err)
+
+	_, err = f.categories.Delete(ctx, category.ID, model.AnyVersion)
+	require.NoError(t, err)
+	purged, err := f.retention.Purge(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, purged)
+
+	_, err = f.prices.GetByProductID(ctx, product.ID)
+	assert.ErrorIs(t, err, model.ErrPriceNotFound, "the price history is purged with its product")
+	for _, entityType := range []string{entityProduct, entitySubCategory, entityCategory} {
+		trail, err := f.audit.Find(ctx, audit.Query{EntityType: entityType, EntityID: "1"})
+		require.NoError(t, err)
+		require.NotEmpty(t, trail)
+		assert.Equal(t, audit.OperationPurge, trail[len(trail)-1].Operation, entityType)
+	}
+}
@@ -2,44 +2,98 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/tracing"
 )
 
 type ISubCategoryRepository interface {
-	Create(ctx context.Context, data *model.SubCategoryBasic) (*model.SubCategoryBasic, error)
-	Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic) error
+	Create(ctx context.Context, data *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error)
+	Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) error
 	Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error)
 	GetAll(ctx context.Context) ([]*model.SubCategoryBasic, error)
-	Delete(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error)
+	Delete(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error)
+	GetDeleted(ctx context.Context) ([]*model.SubCategoryBasic, error)
+	Restore(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error)
 }
 
 type ICategoryGetController interface {
 	Get(ctx context.Context, id model.CategoryID) (*model.Category, error)
 }
 
+// IProductCascade soft deletes the products of a deleted sub-category.
+type IProductCascade interface {
+	DeleteBySubCategory(ctx context.Context, id model.SubCategoryID) error
+}
+
 type SubCategoryController struct {
 	repo          ISubCategoryRepository
 	catController ICategoryGetController
+	audit         IAuditRecorder
+	outbox        IEventOutbox
+	products      IProductCascade
 }
 
-func NewSubCategoryController(repo ISubCategoryRepository, catController ICategoryGetController) *SubCategoryController {
+func NewSubCategoryController(repo ISubCategoryRepository, catController ICategoryGetController, audit IAuditRecorder, outbox IEventOutbox) *SubCategoryController {
 	return &SubCategoryController{
 		repo:          repo,
 		catController: catController,
+		audit:         audit,
+		outbox:        outbox,
 	}
 }
 
+// CascadeTo makes Delete soft delete the products of a deleted sub-category
+// as well, like CategoryController.CascadeTo.
+func (s *SubCategoryController) CascadeTo(products IProductCascade) {
+	s.products = products
+}
+
 func (s *SubCategoryController) Create(ctx context.Context, data *model.SubCategoryBasic) (*model.SubCategoryBasic, error) {
-	return s.repo.Create(ctx, data)
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.Create")
+	defer span.End()
+
+	return s.repo.Create(ctx, data, s.commit(ctx, model.EventSubCategoryCreated, audit.OperationCreate))
 }
 
 func (s *SubCategoryController) Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic) error {
-	return s.repo.Update(ctx, id, data)
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.Update")
+	defer span.End()
+
+	return s.repo.Update(ctx, id, data, s.commit(ctx, model.EventSubCategoryUpdated, audit.OperationUpdate))
+}
+
+// Patch applies a merge patch or JSON patch to the basic sub-category and
+// stores the result through Update.
+func (s *SubCategoryController) Patch(ctx context.Context, id model.SubCategoryID, version int, patch jsonpatch.Func) (*model.SubCategoryBasic, error) {
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.Patch")
+	defer span.End()
+
+	current, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patched, version, err := applyPatch(current, current.BaseInfo.Version, version, patch)
+	if err != nil {
+		return nil, err
+	}
+	patched.BaseInfo.ID = id
+	patched.BaseInfo.Version = version
+	if err := s.Update(ctx, id, patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
 }
 
 func (s *SubCategoryController) Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryDetails, error) {
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.Get")
+	defer span.End()
+
 	sc, err := s.repo.Get(ctx, id)
 	if err != nil {
 		return nil, err
@@ -51,20 +105,27 @@ func (s *SubCategoryController) Get(ctx context.Context, id model.SubCategoryID)
 
 	return &model.SubCategoryDetails{
 		SubCategoryBaseInfo: model.SubCategoryBaseInfo{
-			ID:   sc.BaseInfo.ID,
-			Name: sc.BaseInfo.Name,
+			ID:      sc.BaseInfo.ID,
+			Name:    sc.BaseInfo.Name,
+			Version: sc.BaseInfo.Version,
 		},
 		Category: cat,
 	}, nil
 }
 
 func (s *SubCategoryController) GetAll(ctx context.Context) ([]*model.SubCategoryDetails, error) {
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.GetAll")
+	defer span.End()
+
 	basics, err := s.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrSubCategoryNotFound) {
+		return []*model.SubCategoryDetails{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	var result []*model.SubCategoryDetails
+	result := []*model.SubCategoryDetails{}
 	for _, b := range basics {
 		cat, err := s.catController.Get(ctx, b.CatID)
 		if err != nil {
@@ -72,8 +133,9 @@ func (s *SubCategoryController) GetAll(ctx context.Context) ([]*model.SubCategor
 		}
 		result = append(result, &model.SubCategoryDetails{
 			SubCategoryBaseInfo: model.SubCategoryBaseInfo{
-				ID:   b.BaseInfo.ID,
-				Name: b.BaseInfo.Name,
+				ID:      b.BaseInfo.ID,
+				Name:    b.BaseInfo.Name,
+				Version: b.BaseInfo.Version,
 			},
 			Category: cat,
 		})
@@ -81,6 +143,98 @@ func (s *SubCategoryController) GetAll(ctx context.Context) ([]*model.SubCategor
 	return result, nil
 }
 
-func (s *SubCategoryController) Delete(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error) {
-	return s.repo.Delete(ctx, id)
+func (s *SubCategoryController) Delete(ctx context.Context, id model.SubCategoryID, version int) (*model.SubCategoryBasic, error) {
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.Delete")
+	defer span.End()
+
+	deleted, err := s.repo.Delete(ctx, id, version, s.commit(ctx, model.EventSubCategoryDeleted, audit.OperationDelete))
+	if err != nil {
+		return nil, err
+	}
+	// Products are deleted after their sub-category, like in CategoryController.Delete.
+	if s.products != nil {
+		if err := s.products.DeleteBySubCategory(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to delete the products of sub-category %d: %w", id, err)
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteByCategory soft deletes every sub-category of a category, and with
+// them their products.
+func (s *SubCategoryController) DeleteByCategory(ctx context.Context, id model.CategoryID) error {
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.DeleteByCategory")
+	defer span.End()
+
+	all, err := s.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrSubCategoryNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, sc := range all {
+		if sc.CatID != id {
+			continue
+		}
+		_, err := s.Delete(ctx, sc.BaseInfo.ID, model.AnyVersion)
+		if err != nil && !errors.Is(err, model.ErrSubCategoryNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDeleted returns the soft deleted sub-categories that can still be
+// restored. The category is left empty when it has been deleted as well.
+func (s *SubCategoryController) GetDeleted(ctx context.Context) ([]*model.SubCategoryDetails, error) {
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.GetDeleted")
+	defer span.End()
+
+	basics, err := s.repo.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*model.SubCategoryDetails
+	for _, b := range basics {
+		cat, _ := s.catController.Get(ctx, b.CatID)
+		result = append(result, &model.SubCategoryDetails{
+			SubCategoryBaseInfo: b.BaseInfo,
+			Category:            cat,
+		})
+	}
+	return result, nil
+}
+
+// Restore undoes the soft deletion of a sub-category. Returns
+// model.ErrParentDeleted while its category is deleted.
+func (s *SubCategoryController) Restore(ctx context.Context, id model.SubCategoryID, version int) (*model.SubCategoryBasic, error) {
+	ctx, span := tracing.Start(ctx, "controller.SubCategoryController.Restore")
+	defer span.End()
+
+	commit := s.commit(ctx, model.EventSubCategoryRestored, audit.OperationRestore)
+	return s.repo.Restore(ctx, id, version, func(before, after *model.SubCategoryBasic) error {
+		_, err := s.catController.Get(ctx, after.CatID)
+		if errors.Is(err, model.ErrCategoryNotFound) {
+			return fmt.Errorf("%w: category id=%d of sub-category id=%d", model.ErrParentDeleted, after.CatID, id)
+		}
+		if err != nil {
+			return err
+		}
+		return commit(before, after)
+	})
+}
+
+// commit returns the repository hook that publishes and audits a
+// sub-category change, like CategoryController.commit.
+func (s *SubCategoryController) commit(ctx context.Context, eventType string, op audit.Operation) func(before, after *model.SubCategoryBasic) error {
+	return func(before, after *model.SubCategoryBasic) error {
+		return s.outbox.Transact(ctx, func(tx *events.Tx) error {
+			if err := tx.Emit(eventType, entitySubCategory, after.BaseInfo.ID, after); err != nil {
+				return err
+			}
+			return recordAudit(ctx, s.audit, entitySubCategory, after.BaseInfo.ID, op, before, after)
+		})
+	}
 }
@@ -8,20 +8,32 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/events"
 )
 
 type MockSubCategoryRepo struct {
 	mock.Mock
 }
 
-func (m *MockSubCategoryRepo) Create(ctx context.Context, data *model.SubCategoryBasic) (*model.SubCategoryBasic, error) {
+func (m *MockSubCategoryRepo) Create(ctx context.Context, data *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
 	args := m.Called(ctx, data)
-	return args.Get(0).(*model.SubCategoryBasic), args.Error(1)
+	created, err := args.Get(0).(*model.SubCategoryBasic), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return created, commit(nil, created)
 }
 
-func (m *MockSubCategoryRepo) Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic) error {
+func (m *MockSubCategoryRepo) Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) error {
 	args := m.Called(ctx, id, data)
-	return args.Error(0)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	before, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return commit(before, data)
 }
 
 func (m *MockSubCategoryRepo) Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error) {
@@ -34,9 +46,27 @@ func (m *MockSubCategoryRepo) GetAll(ctx context.Context) ([]*model.SubCategoryB
 	return args.Get(0).([]*model.SubCategoryBasic), args.Error(1)
 }
 
-func (m *MockSubCategoryRepo) Delete(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*model.SubCategoryBasic), args.Error(1)
+func (m *MockSubCategoryRepo) Delete(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
+	args := m.Called(ctx, id, version)
+	deleted, err := args.Get(0).(*model.SubCategoryBasic), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, commit(&model.SubCategoryBasic{BaseInfo: model.SubCategoryBaseInfo{ID: id}}, deleted)
+}
+
+func (m *MockSubCategoryRepo) GetDeleted(ctx context.Context) ([]*model.SubCategoryBasic, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.SubCategoryBasic), args.Error(1)
+}
+
+func (m *MockSubCategoryRepo) Restore(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
+	args := m.Called(ctx, id, version)
+	restored, err := args.Get(0).(*model.SubCategoryBasic), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	return restored, commit(&model.SubCategoryBasic{BaseInfo: model.SubCategoryBaseInfo{ID: id}}, restored)
 }
 
 type MockCategoryGetController struct {
@@ -51,13 +81,25 @@ func (m *MockCategoryGetController) Get(ctx context.Context, id model.CategoryID
 func TestSubCategoryController_Create(t *testing.T) {
 	mockRepo := new(MockSubCategoryRepo)
 	mockCategoryController := new(MockCategoryGetController)
-	ctrl := NewSubCategoryController(mockRepo, mockCategoryController)
+	ctrl := NewSubCategoryController(mockRepo, mockCategoryController, newAuditRecorder(), events.NewMemoryOutbox())
 
 	expected := &model.SubCategoryBasic{CatID: 1}
 	mockRepo.On("Create", mock.Anything, expected).Return(expected, nil)
 
-	result, err := ctrl.Create(context.Background(), expected)
+	result, err := ctrl.Create(defaultTenant(), expected)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestSubCategoryController_GetAll_Empty(t *testing.T) {
+	mockRepo := new(MockSubCategoryRepo)
+	ctrl := NewSubCategoryController(mockRepo, new(MockCategoryGetController), newAuditRecorder(), events.NewMemoryOutbox())
+
+	mockRepo.On("GetAll", mock.Anything).Return([]*model.SubCategoryBasic(nil), model.ErrSubCategoryNotFound)
+
+	result, err := ctrl.GetAll(defaultTenant())
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+}
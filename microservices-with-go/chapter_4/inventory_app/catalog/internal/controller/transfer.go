@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tracing"
+)
+
+var (
+	// ErrUnresolvedParent is returned when an import row references a parent that does not exist.
+	ErrUnresolvedParent = errors.New("parent not found")
+	// ErrAmbiguousParent is returned when a parent name matches more than one entity.
+	ErrAmbiguousParent = errors.New("parent name is ambiguous, reference it by ID")
+)
+
+type ICategoryTransferController interface {
+	Create(ctx context.Context, data *model.Category) (*model.Category, error)
+	GetAll(ctx context.Context) ([]*model.Category, error)
+}
+
+type ISubCategoryTransferController interface {
+	Create(ctx context.Context, data *model.SubCategoryBasic) (*model.SubCategoryBasic, error)
+	GetAll(ctx context.Context) ([]*model.SubCategoryDetails, error)
+}
+
+type IProductTransferController interface {
+	Create(ctx context.Context, data *model.ProductBasic) (*model.ProductBasic, error)
+	GetAll(ctx context.Context, filters ...model.AttributeFilter) ([]*model.ProductInformation, error)
+}
+
+// TransferController implements bulk import and export of the whole catalog.
+// Imports go through the regular controllers so every row gets the same
+// validation as an individual create request.
+type TransferController struct {
+	categories    ICategoryTransferController
+	subCategories ISubCategoryTransferController
+	products      IProductTransferController
+}
+
+func NewTransferController(categories ICategoryTransferController, subCategories ISubCategoryTransferController, products IProductTransferController) *TransferController {
+	return &TransferController{
+		categories:    categories,
+		subCategories: subCategories,
+		products:      products,
+	}
+}
+
+// Import creates the entities described by rows. Categories are imported
+// first, then sub-categories, then products, so parents may be referenced by
+// name regardless of their position in the file. A failing row does not stop
+// the import; it is recorded in the report instead. In dry-run mode rows are
+// only validated and nothing is created.
+func (t *TransferController) Import(ctx context.Context, rows []model.ImportRow, dryRun bool) (*model.ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "controller.TransferController.Import")
+	defer span.End()
+
+	report := &model.ImportReport{DryRun: dryRun, Total: len(rows), Errors: []model.RowError{}}
+	idx, err := t.loadLookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, recordType := range []model.RecordType{model.RecordTypeCategory, model.RecordTypeSubCategory, model.RecordTypeProduct} {
+		for _, row := range rows {
+			if row.Record.Type != recordType {
+				continue
+			}
+			if err := t.importRecord(ctx, idx, &row.Record, dryRun); err != nil {
+				report.Errors = append(report.Errors, model.RowError{Row: row.Row, Error: err.Error()})
+				continue
+			}
+			report.Created++
+		}
+	}
+	for _, row := range rows {
+		switch row.Record.Type {
+		case model.RecordTypeCategory, model.RecordTypeSubCategory, model.RecordTypeProduct:
+		default:
+			report.Errors = append(report.Errors, model.RowError{Row: row.Row, Error: fmt.Sprintf("unknown record type %q", row.Record.Type)})
+		}
+	}
+
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	report.Failed = len(report.Errors)
+	return report, nil
+}
+
+func (t *TransferController) importRecord(ctx context.Context, idx *transferLookup, record *model.CatalogRecord, dryRun bool) error {
+	if strings.TrimSpace(record.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	switch record.Type {
+	case model.RecordTypeCategory:
+		category := &model.Category{Name: record.Name, Attributes: record.Schema}
+		if dryRun {
+			if err := model.ValidateSchema(category.Attributes); err != nil {
+				return err
+			}
+			category.ID = model.CategoryID(idx.placeholderID())
+		} else {
+			created, err := t.categories.Create(ctx, category)
+			if err != nil {
+				return err
+			}
+			category = created
+		}
+		idx.addCategory(category)
+
+	case model.RecordTypeSubCategory:
+		category, err := idx.resolveCategory(record.Parent)
+		if err != nil {
+			return err
+		}
+		subCategory := &model.SubCategoryBasic{
+			BaseInfo: model.SubCategoryBaseInfo{Name: record.Name},
+			CatID:    category.ID,
+		}
+		if dryRun {
+			subCategory.BaseInfo.ID = model.SubCategoryID(idx.placeholderID())
+		} else {
+			created, err := t.subCategories.Create(ctx, subCategory)
+			if err != nil {
+				return err
+			}
+			subCategory = created
+		}
+		idx.addSubCategory(subCategory.BaseInfo.ID, subCategory.BaseInfo.Name, category.ID)
+
+	case model.RecordTypeProduct:
+		subCategoryID, category, err := idx.resolveSubCategory(record.Parent)
+		if err != nil {
+			return err
+		}
+		if record.ListCost == nil {
+			return errors.New("listCost is required")
+		}
+		product := &model.ProductBasic{
+			ProductBaseInfo: model.ProductBaseInfo{
+				Name:         record.Name,
+				Description:  record.Description,
+				Manufacturer: record.Manufacturer,
+				ListCost:     *record.ListCost,
+				Attributes:   record.Attributes,
+			},
+			SubCatID: subCategoryID,
+		}
+		if dryRun {
+			if err := validateListCost(product.ListCost); err != nil {
+				return err
+			}
+			return model.ValidateAttributes(category.Attributes, product.Attributes)
+		}
+		if _, err := t.products.Create(ctx, product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export streams every category, sub-category and product to emit, parents
+// before children. Parents are referenced by name when the name is unique,
+// which keeps the output portable between catalogs, and by ID otherwise.
+func (t *TransferController) Export(ctx context.Context, emit func(model.CatalogRecord) error) error {
+	ctx, span := tracing.Start(ctx, "controller.TransferController.Export")
+	defer span.End()
+
+	idx, err := t.loadLookup(ctx)
+	if err != nil {
+		return err
+	}
+
+	categories, _ := t.categories.GetAll(ctx)
+	for _, c := range categories {
+		if err := emit(model.CatalogRecord{Type: model.RecordTypeCategory, ID: int(c.ID), Name: c.Name, Schema: c.Attributes}); err != nil {
+			return err
+		}
+	}
+
+	subCategories, _ := t.subCategories.GetAll(ctx)
+	for _, s := range subCategories {
+		record := model.CatalogRecord{Type: model.RecordTypeSubCategory, ID: int(s.ID), Name: s.Name}
+		if s.Category != nil {
+			record.Parent = idx.categoryRef(s.Category.ID, s.Category.Name)
+		}
+		if err := emit(record); err != nil {
+			return err
+		}
+	}
+
+	products, _ := t.products.GetAll(ctx)
+	for _, p := range products {
+		cost := p.ListCost
+		record := model.CatalogRecord{
+			Type:         model.RecordTypeProduct,
+			ID:           int(p.ID),
+			Name:         p.Name,
+			Description:  p.Description,
+			Manufacturer: p.Manufacturer,
+			ListCost:     &cost,
+			Attributes:   p.Attributes,
+		}
+		if p.SubCategoryDetails != nil {
+			record.Parent = idx.subCategoryRef(p.SubCategoryDetails.ID, p.SubCategoryDetails.Name)
+		}
+		if err := emit(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadLookup indexes the existing categories and sub-categories by ID and name.
+// The list calls fail when a collection is empty, which is treated as no entries.
+func (t *TransferController) loadLookup(ctx context.Context) (*transferLookup, error) {
+	idx := &transferLookup{
+		categories:       make(map[model.CategoryID]*model.Category),
+		categoryNames:    make(map[string][]model.CategoryID),
+		subCategories:    make(map[model.SubCategoryID]model.CategoryID),
+		subCategoryNames: make(map[string][]model.SubCategoryID),
+	}
+	categories, _ := t.categories.GetAll(ctx)
+	for _, c := range categories {
+		idx.addCategory(c)
+	}
+	subCategories, _ := t.subCategories.GetAll(ctx)
+	for _, s := range subCategories {
+		if s.Category != nil {
+			idx.addSubCategory(s.ID, s.Name, s.Category.ID)
+		}
+	}
+	return idx, nil
+}
+
+// transferLookup resolves parent references of import rows, including
+// entities created earlier in the same import.
+type transferLookup struct {
+	categories       map[model.CategoryID]*model.Category
+	categoryNames    map[string][]model.CategoryID
+	subCategories    map[model.SubCategoryID]model.CategoryID
+	subCategoryNames map[string][]model.SubCategoryID
+	lastPlaceholder  int
+}
+
+// placeholderID returns a negative ID standing in for an entity a dry run would create.
+func (l *transferLookup) placeholderID() int {
+	l.lastPlaceholder--
+	return l.lastPlaceholder
+}
+
+func (l *transferLookup) addCategory(c *model.Category) {
+	l.categories[c.ID] = c
+	key := nameKey(c.Name)
+	l.categoryNames[key] = append(l.categoryNames[key], c.ID)
+}
+
+func (l *transferLookup) addSubCategory(id model.SubCategoryID, name string, catID model.CategoryID) {
+	l.subCategories[id] = catID
+	key := nameKey(name)
+	l.subCategoryNames[key] = append(l.subCategoryNames[key], id)
+}
+
+func (l *transferLookup) resolveCategory(ref string) (*model.Category, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		if c, ok := l.categories[model.CategoryID(id)]; ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: category %q", ErrUnresolvedParent, ref)
+	}
+	ids := l.categoryNames[nameKey(ref)]
+	switch len(ids) {
+	case 0:
+		return nil, fmt.Errorf("%w: category %q", ErrUnresolvedParent, ref)
+	case 1:
+		return l.categories[ids[0]], nil
+	}
+	return nil, fmt.Errorf("%w: category %q", ErrAmbiguousParent, ref)
+}
+
+func (l *transferLookup) resolveSubCategory(ref string) (model.SubCategoryID, *model.Category, error) {
+	var id model.SubCategoryID
+	if parsed, err := strconv.Atoi(ref); err == nil {
+		id = model.SubCategoryID(parsed)
+		if _, ok := l.subCategories[id]; !ok {
+			return 0, nil, fmt.Errorf("%w: subcategory %q", ErrUnresolvedParent, ref)
+		}
+	} else {
+		ids := l.subCategoryNames[nameKey(ref)]
+		switch len(ids) {
+		case 0:
+			return 0, nil, fmt.Errorf("%w: subcategory %q", ErrUnresolvedParent, ref)
+		case 1:
+			id = ids[0]
+		default:
+			return 0, nil, fmt.Errorf("%w: subcategory %q", ErrAmbiguousParent, ref)
+		}
+	}
+	category, ok := l.categories[l.subCategories[id]]
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: category of subcategory %q", ErrUnresolvedParent, ref)
+	}
+	return id, category, nil
+}
+
+func (l *transferLookup) categoryRef(id model.CategoryID, name string) string {
+	if len(l.categoryNames[nameKey(name)]) == 1 {
+		return name
+	}
+	return strconv.Itoa(int(id))
+}
+
+func (l *transferLookup) subCategoryRef(id model.SubCategoryID, name string) string {
+	if len(l.subCategoryNames[nameKey(name)]) == 1 {
+		return name
+	}
+	return strconv.Itoa(int(id))
+}
+
+// nameKey normalises names so that parent references are case-insensitive.
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -2,11 +2,15 @@ package ginhandler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/money"
 )
 
 // handleError is a helper to send consistent error responses
@@ -16,12 +20,26 @@ func handleError(ctx *gin.Context, status int, msg string) {
 	})
 }
 
+// isNotFound reports whether err was caused by a catalog entity that does
+// not exist or is deleted.
+func isNotFound(err error) bool {
+	return errors.Is(err, model.ErrCategoryNotFound) || errors.Is(err, model.ErrSubCategoryNotFound) || errors.Is(err, model.ErrProductNotFound)
+}
+
+// isValidationError reports whether err was caused by invalid client input.
+func isValidationError(err error) bool {
+	return errors.Is(err, model.ErrInvalidAttribute) || errors.Is(err, model.ErrInvalidSubCategory) || errors.Is(err, money.ErrInvalid)
+}
+
 type ICategoryController interface {
 	Create(ctx context.Context, data *model.Category) (*model.Category, error)
 	Update(ctx context.Context, id model.CategoryID, data *model.Category) error
 	Get(ctx context.Context, id model.CategoryID) (*model.Category, error)
 	GetAll(ctx context.Context) ([]*model.Category, error)
-	Delete(ctx context.Context, id model.CategoryID) (*model.Category, error)
+	Patch(ctx context.Context, id model.CategoryID, version int, patch jsonpatch.Func) (*model.Category, error)
+	Delete(ctx context.Context, id model.CategoryID, version int) (*model.Category, error)
+	GetDeleted(ctx context.Context) ([]*model.Category, error)
+	Restore(ctx context.Context, id model.CategoryID, version int) (*model.Category, error)
 }
 
 type categoryHandler struct {
@@ -36,6 +54,10 @@ func (handler *categoryHandler) post(ctx *gin.Context) {
 	}
 
 	created, err := handler.ctrl.Create(ctx.Request.Context(), &data)
+	if isValidationError(err) {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		handleError(ctx, http.StatusInternalServerError, "failed to create category")
 		return
@@ -50,26 +72,75 @@ func (handler *categoryHandler) update(ctx *gin.Context) {
 		return
 	}
 
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var data model.Category
 	if err := ctx.ShouldBindJSON(&data); err != nil {
 		handleError(ctx, http.StatusBadRequest, "invalid request payload")
 		return
 	}
 	data.ID = model.CategoryID(id)
+	data.Version = version
 	err = handler.ctrl.Update(ctx.Request.Context(), data.ID, &data)
 	if err != nil {
-		handleError(ctx, http.StatusInternalServerError, "failed to update category")
+		handleWriteError(ctx, err, "failed to update category")
 		return
 	}
+	ctx.Header("ETag", formatETag(data.Version))
 	ctx.JSON(http.StatusAccepted, data)
 }
 
+// patch applies a merge patch (application/merge-patch+json) or JSON patch
+// (application/json-patch+json) to the category, honouring If-Match.
+func (handler *categoryHandler) patch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid category ID")
+		return
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	patch, ok := readPatch(ctx)
+	if !ok {
+		return
+	}
+	patched, err := handler.ctrl.Patch(ctx.Request.Context(), model.CategoryID(id), version, patch)
+	if err != nil {
+		handleWriteError(ctx, err, "failed to patch category")
+		return
+	}
+	ctx.Header("ETag", formatETag(patched.Version))
+	ctx.JSON(http.StatusOK, patched)
+}
+
 func (handler *categoryHandler) getAll(ctx *gin.Context) {
-	all, err := handler.ctrl.GetAll(ctx.Request.Context())
+	withDeleted, err := includeDeleted(ctx)
 	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	all, err := handler.ctrl.GetAll(ctx.Request.Context())
+	if err != nil && !withDeleted {
 		handleError(ctx, http.StatusInternalServerError, "failed to retrieve categories")
 		return
 	}
+	if withDeleted {
+		deleted, err := handler.ctrl.GetDeleted(ctx.Request.Context())
+		if err != nil {
+			handleError(ctx, http.StatusInternalServerError, "failed to retrieve deleted categories")
+			return
+		}
+		all = append(all, deleted...)
+	}
 	ctx.JSON(http.StatusOK, all)
 }
 
@@ -81,10 +152,17 @@ func (handler *categoryHandler) get(ctx *gin.Context) {
 	}
 
 	category, err := handler.ctrl.Get(ctx.Request.Context(), model.CategoryID(id))
+	if isNotFound(err) {
+		handleError(ctx, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		handleError(ctx, http.StatusInternalServerError, "failed to retrieve category")
 		return
 	}
+	if writeETag(ctx, category.Version) {
+		return
+	}
 	ctx.JSON(http.StatusOK, category)
 }
 
@@ -95,23 +173,52 @@ func (handler *categoryHandler) delete(ctx *gin.Context) {
 		return
 	}
 
-	_, err = handler.ctrl.Delete(ctx.Request.Context(), model.CategoryID(id))
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = handler.ctrl.Delete(ctx.Request.Context(), model.CategoryID(id), version)
 	if err != nil {
-		handleError(ctx, http.StatusInternalServerError, "failed to delete category")
+		handleWriteError(ctx, err, "failed to delete category")
 		return
 	}
 	ctx.JSON(http.StatusNoContent, struct{}{})
 }
 
+// restore undoes the soft deletion of a category, honouring If-Match.
+func (handler *categoryHandler) restore(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid category ID")
+		return
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	restored, err := handler.ctrl.Restore(ctx.Request.Context(), model.CategoryID(id), version)
+	if err != nil {
+		handleWriteError(ctx, err, "failed to restore category")
+		return
+	}
+	ctx.Header("ETag", formatETag(restored.Version))
+	ctx.JSON(http.StatusOK, restored)
+}
+
 func InitCategoryHandler(engine *gin.Engine, ctrl ICategoryController) {
 	handler := &categoryHandler{ctrl: ctrl}
 
 	categoryRouterGroup := engine.Group("/categories")
 	{
-		categoryRouterGroup.POST("", handler.post)
-		categoryRouterGroup.PUT("/:id", handler.update)
-		categoryRouterGroup.GET("", handler.getAll)
-		categoryRouterGroup.GET("/:id", handler.get)
-		categoryRouterGroup.DELETE("/:id", handler.delete)
+		categoryRouterGroup.POST("", auth.Require(auth.PermCatalogWrite), handler.post)
+		categoryRouterGroup.PUT("/:id", auth.Require(auth.PermCatalogWrite), handler.update)
+		categoryRouterGroup.PATCH("/:id", auth.Require(auth.PermCatalogWrite), handler.patch)
+		categoryRouterGroup.GET("", auth.Require(auth.PermCatalogRead), handler.getAll)
+		categoryRouterGroup.GET("/:id", auth.Require(auth.PermCatalogRead), handler.get)
+		categoryRouterGroup.DELETE("/:id", auth.Require(auth.PermCatalogWrite), handler.delete)
+		categoryRouterGroup.POST("/:id/restore", auth.Require(auth.PermCatalogWrite), handler.restore)
 	}
 }
@@ -0,0 +1,24 @@
+package ginhandler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidIncludeDeleted = errors.New("invalid includeDeleted query parameter")
+
+// includeDeleted reports whether a list request asked for soft deleted
+// entities through ?includeDeleted=true.
+func includeDeleted(ctx *gin.Context) (bool, error) {
+	raw := ctx.Query("includeDeleted")
+	if raw == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, errInvalidIncludeDeleted
+	}
+	return include, nil
+}
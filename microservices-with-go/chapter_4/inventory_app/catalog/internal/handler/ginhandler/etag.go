@@ -0,0 +1,82 @@
+package ginhandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/jsonpatch"
+)
+
+var errInvalidETag = errors.New("invalid ETag in precondition header")
+
+// formatETag returns the entity tag of an entity version.
+func formatETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseETag extracts the version from an entity tag, accepting weak tags.
+func parseETag(tag string) (int, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, errInvalidETag
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return 0, errInvalidETag
+	}
+	return version, nil
+}
+
+// ifMatchVersion returns the version required by the If-Match header, or
+// model.AnyVersion when the header is absent or "*".
+func ifMatchVersion(ctx *gin.Context) (int, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return model.AnyVersion, nil
+	}
+	return parseETag(header)
+}
+
+// writeETag sets the ETag header and, when the If-None-Match header matches
+// the version, responds with 304 Not Modified. It reports whether the
+// response was completed.
+func writeETag(ctx *gin.Context, version int) bool {
+	current := formatETag(version)
+	ctx.Header("ETag", current)
+
+	for _, tag := range strings.Split(ctx.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			ctx.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// handleWriteError responds to a failed update, patch, delete or restore,
+// mapping version conflicts to 412 Precondition Failed, validation errors to
+// 400, patches that cannot be applied to 422 Unprocessable Entity, restoring
+// an entity that is not deleted, or whose parent is deleted, to 409 Conflict
+// and missing entities to 404.
+func handleWriteError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, model.ErrVersionConflict):
+		handleError(ctx, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, model.ErrNotDeleted), errors.Is(err, model.ErrParentDeleted):
+		handleError(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, jsonpatch.ErrCannotApply):
+		handleError(ctx, http.StatusUnprocessableEntity, err.Error())
+	case isValidationError(err):
+		handleError(ctx, http.StatusBadRequest, err.Error())
+	case isNotFound(err):
+		handleError(ctx, http.StatusNotFound, err.Error())
+	default:
+		handleError(ctx, http.StatusInternalServerError, msg)
+	}
+}
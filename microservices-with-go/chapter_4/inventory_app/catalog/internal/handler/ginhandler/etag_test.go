@@ -0,0 +1,87 @@
+package ginhandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/internal/controller"
+	"inventory.com/catalog/internal/repository/memory"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/tenant"
+)
+
+// newCategoryEngine serves the category routes to a catalog editor of the default tenant.
+func newCategoryEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) {
+		c := auth.WithPrincipal(ctx.Request.Context(), &auth.Principal{Subject: "editor", Roles: []string{auth.RoleCatalogEditor}})
+		ctx.Request = ctx.Request.WithContext(tenant.WithID(c, tenant.Default))
+	})
+	ctrl := controller.NewCategoryController(memory.NewCategory(), audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+	InitCategoryHandler(engine, ctrl)
+
+	resp := serve(engine, http.MethodPost, "/categories", `{"name":"Books"}`, nil)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	return engine
+}
+
+func serve(engine *gin.Engine, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestGet_ETag(t *testing.T) {
+	engine := newCategoryEngine(t)
+
+	resp := serve(engine, http.MethodGet, "/categories/1", "", nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"1"`, resp.Header().Get("ETag"))
+
+	resp = serve(engine, http.MethodGet, "/categories/1", "", map[string]string{"If-None-Match": `W/"1"`})
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Equal(t, `"1"`, resp.Header().Get("ETag"))
+	assert.Empty(t, resp.Body.String())
+
+	resp = serve(engine, http.MethodGet, "/categories/1", "", map[string]string{"If-None-Match": `"0", "2"`})
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestUpdate_VersionMismatch(t *testing.T) {
+	engine := newCategoryEngine(t)
+
+	resp := serve(engine, http.MethodPut, "/categories/1", `{"name":"Comics"}`, map[string]string{"If-Match": `"2"`})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Code)
+
+	resp = serve(engine, http.MethodPut, "/categories/1", `{"name":"Comics"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, `"2"`, resp.Header().Get("ETag"))
+
+	resp = serve(engine, http.MethodPut, "/categories/1", `{"name":"Novels"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Code, "the ETag is stale after the update")
+
+	patch := map[string]string{"If-Match": `"1"`, "Content-Type": jsonpatch.MediaTypeMergePatch}
+	resp = serve(engine, http.MethodPatch, "/categories/1", `{"name":"Novels"}`, patch)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Code)
+
+	resp = serve(engine, http.MethodDelete, "/categories/1", "", map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Code)
+
+	resp = serve(engine, http.MethodPut, "/categories/1", `{"name":"Novels"}`, map[string]string{"If-Match": "not-an-etag"})
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -0,0 +1,94 @@
+package ginhandler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/internal/controller"
+	"inventory.com/catalog/internal/repository/memory"
+	"inventory.com/catalog/internal/search"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/tenant"
+)
+
+// newCatalogEngine serves the category, sub-category and product routes to a
+// catalog editor of the default tenant, with one entity of each.
+func newCatalogEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) {
+		c := auth.WithPrincipal(ctx.Request.Context(), &auth.Principal{Subject: "editor", Roles: []string{auth.RoleCatalogEditor}})
+		ctx.Request = ctx.Request.WithContext(tenant.WithID(c, tenant.Default))
+	})
+	recorder, outbox := audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox()
+	categories := controller.NewCategoryController(memory.NewCategory(), recorder, outbox)
+	subCategories := controller.NewSubCategoryController(memory.NewSubCategory(), categories, recorder, outbox)
+	products := memory.NewProduct()
+	prices := controller.NewPriceController(memory.NewPriceHistory(), products)
+	InitCategoryHandler(engine, categories)
+	InitSubCategoryHandler(engine, subCategories)
+	InitProductHandler(engine, controller.NewProductController(products, subCategories, prices, search.NewTenantIndex(), recorder, outbox))
+
+	resp := serve(engine, http.MethodPost, "/categories", `{"name":"Garden"}`, nil)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	resp = serve(engine, http.MethodPost, "/subcategories", `{"name":"Tools","categoryId":1}`, nil)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	resp = serve(engine, http.MethodPost, "/products", `{"name":"Rake","listCost":{"amount":"10.00","currency":"USD"},"subCategoryId":1}`, nil)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	return engine
+}
+
+func TestGet_NotFound(t *testing.T) {
+	engine := newCatalogEngine(t)
+
+	for _, path := range []string{"/categories/9", "/subcategories/9", "/products/9"} {
+		resp := serve(engine, http.MethodGet, path, "", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+	}
+
+	resp := serve(engine, http.MethodDelete, "/products/1", "", nil)
+	require.Equal(t, http.StatusNoContent, resp.Code, resp.Body.String())
+	resp = serve(engine, http.MethodGet, "/products/1", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code, "a deleted product is hidden")
+}
+
+func TestWrite_NotFound(t *testing.T) {
+	engine := newCatalogEngine(t)
+
+	for _, path := range []string{"/categories/9", "/subcategories/9", "/products/9"} {
+		resp := serve(engine, http.MethodPost, path+"/restore", "", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+		resp = serve(engine, http.MethodDelete, path, "", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+	}
+	resp := serve(engine, http.MethodPut, "/categories/9", `{"name":"Kitchen"}`, nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestPatch_NotFound(t *testing.T) {
+	engine := newCatalogEngine(t)
+
+	for _, path := range []string{"/categories/9", "/subcategories/9", "/products/9"} {
+		resp := serve(engine, http.MethodPatch, path, `{"name":"Shears"}`, map[string]string{"Content-Type": jsonpatch.MediaTypeMergePatch})
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+		resp = serve(engine, http.MethodPatch, path, `[{"op":"replace","path":"/name","value":"Shears"}]`, map[string]string{"Content-Type": jsonpatch.MediaTypeJSONPatch})
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+	}
+}
+
+func TestProduct_UnknownSubCategory(t *testing.T) {
+	engine := newCatalogEngine(t)
+
+	body := `{"name":"Hoe","listCost":{"amount":"10.00","currency":"USD"},"subCategoryId":9}`
+	resp := serve(engine, http.MethodPost, "/products", body, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+	resp = serve(engine, http.MethodPut, "/products/1", body, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, "the product exists, its new sub-category does not")
+}
@@ -0,0 +1,32 @@
+package ginhandler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/pkg/jsonpatch"
+)
+
+// readPatch parses the request body as a merge patch or JSON patch depending
+// on the Content-Type header. On failure the error response has been written
+// and false is returned.
+func readPatch(ctx *gin.Context) (jsonpatch.Func, bool) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid request payload")
+		return nil, false
+	}
+	patch, err := jsonpatch.Parse(ctx.GetHeader("Content-Type"), body)
+	if errors.Is(err, jsonpatch.ErrUnsupportedMediaType) {
+		ctx.Header("Accept-Patch", jsonpatch.MediaTypeMergePatch+", "+jsonpatch.MediaTypeJSONPatch)
+		handleError(ctx, http.StatusUnsupportedMediaType, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return patch, true
+}
@@ -0,0 +1,94 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/money"
+)
+
+type IPriceController interface {
+	Schedule(ctx context.Context, id model.ProductID, price money.Money, effectiveFrom time.Time) (*model.PriceChange, error)
+	History(ctx context.Context, id model.ProductID) ([]*model.PriceChange, error)
+	PriceAt(ctx context.Context, id model.ProductID, at time.Time) (*model.PriceChange, error)
+}
+
+type priceHandler struct {
+	ctrl IPriceController
+}
+
+// schedulePriceRequest is the payload for scheduling a future price change.
+type schedulePriceRequest struct {
+	Price         money.Money `json:"price" binding:"required"`
+	EffectiveFrom time.Time   `json:"effectiveFrom" binding:"required"`
+}
+
+// getAll returns the price history of a product, or the single price in
+// effect at the instant given by the optional "at" query parameter (RFC 3339).
+func (handler *priceHandler) getAll(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid product ID")
+		return
+	}
+
+	if at := ctx.Query("at"); at != "" {
+		instant, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			handleError(ctx, http.StatusBadRequest, "invalid at timestamp, expected RFC 3339")
+			return
+		}
+		price, err := handler.ctrl.PriceAt(ctx.Request.Context(), model.ProductID(id), instant)
+		if err != nil {
+			handleError(ctx, http.StatusNotFound, "no price in effect at the given time")
+			return
+		}
+		ctx.JSON(http.StatusOK, price)
+		return
+	}
+
+	history, err := handler.ctrl.History(ctx.Request.Context(), model.ProductID(id))
+	if err != nil {
+		handleError(ctx, http.StatusInternalServerError, "failed to retrieve price history")
+		return
+	}
+	ctx.JSON(http.StatusOK, history)
+}
+
+func (handler *priceHandler) post(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid product ID")
+		return
+	}
+
+	var data schedulePriceRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	change, err := handler.ctrl.Schedule(ctx.Request.Context(), model.ProductID(id), data.Price, data.EffectiveFrom)
+	if isValidationError(err) || errors.Is(err, model.ErrInvalidPriceChange) {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		handleError(ctx, http.StatusInternalServerError, "failed to schedule price change")
+		return
+	}
+	ctx.JSON(http.StatusCreated, change)
+}
+
+func InitPriceHandler(engine *gin.Engine, ctrl IPriceController) {
+	handler := &priceHandler{ctrl: ctrl}
+	router := engine.Group("/products")
+	router.GET(":id/prices", auth.Require(auth.PermCatalogRead), handler.getAll)
+	router.POST(":id/prices", auth.Require(auth.PermCatalogWrite), handler.post)
+}
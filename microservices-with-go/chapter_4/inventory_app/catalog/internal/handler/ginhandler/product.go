@@ -3,19 +3,31 @@ package ginhandler
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/jsonpatch"
 )
 
 type IProductController interface {
 	Create(ctx context.Context, data *model.ProductBasic) (*model.ProductBasic, error)
 	Update(ctx context.Context, id model.ProductID, data *model.ProductBasic) error
 	Get(ctx context.Context, id model.ProductID) (*model.ProductInformation, error)
-	GetAll(ctx context.Context) ([]*model.ProductInformation, error)
-	Delete(ctx context.Context, id model.ProductID) (*model.ProductBasic, error)
+	GetAll(ctx context.Context, filters ...model.AttributeFilter) ([]*model.ProductInformation, error)
+	Patch(ctx context.Context, id model.ProductID, version int, patch jsonpatch.Func) (*model.ProductBasic, error)
+	Delete(ctx context.Context, id model.ProductID, version int) (*model.ProductBasic, error)
+	GetDeleted(ctx context.Context, filters ...model.AttributeFilter) ([]*model.ProductInformation, error)
+	Restore(ctx context.Context, id model.ProductID, version int) (*model.ProductBasic, error)
+	Search(ctx context.Context, query string, limit int) ([]*model.ProductSearchResult, error)
 }
+
+// defaultSearchLimit caps the number of search results when no limit is given.
+const defaultSearchLimit = 20
+
 type productHandler struct {
 	ctrl IProductController
 }
@@ -27,6 +39,10 @@ func (handler *productHandler) post(ctx *gin.Context) {
 		return
 	}
 	created, err := handler.ctrl.Create(ctx.Request.Context(), &data)
+	if isValidationError(err) {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		handleError(ctx, http.StatusInternalServerError, "failed to create product")
 		return
@@ -41,26 +57,80 @@ func (handler *productHandler) update(ctx *gin.Context) {
 		return
 	}
 
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var data model.ProductBasic
 	if err := ctx.ShouldBindJSON(&data); err != nil {
 		handleError(ctx, http.StatusBadRequest, "invalid request payload")
 		return
 	}
 	data.ID = model.ProductID(id)
+	data.Version = version
 	err = handler.ctrl.Update(ctx.Request.Context(), data.ID, &data)
 	if err != nil {
-		handleError(ctx, http.StatusInternalServerError, "failed to update product")
+		handleWriteError(ctx, err, "failed to update product")
 		return
 	}
+	ctx.Header("ETag", formatETag(data.Version))
 	ctx.JSON(http.StatusAccepted, data)
 }
 
+// patch applies a merge patch (application/merge-patch+json) or JSON patch
+// (application/json-patch+json) to the product, honouring If-Match.
+func (handler *productHandler) patch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid product ID")
+		return
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	patch, ok := readPatch(ctx)
+	if !ok {
+		return
+	}
+	patched, err := handler.ctrl.Patch(ctx.Request.Context(), model.ProductID(id), version, patch)
+	if err != nil {
+		handleWriteError(ctx, err, "failed to patch product")
+		return
+	}
+	ctx.Header("ETag", formatETag(patched.Version))
+	ctx.JSON(http.StatusOK, patched)
+}
+
 func (handler *productHandler) getAll(ctx *gin.Context) {
-	all, err := handler.ctrl.GetAll(ctx.Request.Context())
+	filters, err := parseAttributeFilters(ctx.Request.URL.RawQuery)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	withDeleted, err := includeDeleted(ctx)
 	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	all, err := handler.ctrl.GetAll(ctx.Request.Context(), filters...)
+	if err != nil && !withDeleted {
 		handleError(ctx, http.StatusInternalServerError, "failed to retrieve products")
 		return
 	}
+	if withDeleted {
+		deleted, err := handler.ctrl.GetDeleted(ctx.Request.Context(), filters...)
+		if err != nil {
+			handleError(ctx, http.StatusInternalServerError, "failed to retrieve deleted products")
+			return
+		}
+		all = append(all, deleted...)
+	}
 	ctx.JSON(http.StatusOK, all)
 }
 
@@ -71,10 +141,17 @@ func (handler *productHandler) get(ctx *gin.Context) {
 		return
 	}
 	product, err := handler.ctrl.Get(ctx.Request.Context(), model.ProductID(id))
+	if isNotFound(err) {
+		handleError(ctx, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		handleError(ctx, http.StatusInternalServerError, "failed to retrieve product")
 		return
 	}
+	if writeETag(ctx, product.Version) {
+		return
+	}
 	ctx.JSON(http.StatusOK, product)
 }
 
@@ -84,20 +161,99 @@ func (handler *productHandler) delete(ctx *gin.Context) {
 		handleError(ctx, http.StatusBadRequest, "invalid product ID")
 		return
 	}
-	_, err = handler.ctrl.Delete(ctx.Request.Context(), model.ProductID(id))
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	_, err = handler.ctrl.Delete(ctx.Request.Context(), model.ProductID(id), version)
 	if err != nil {
-		handleError(ctx, http.StatusInternalServerError, "failed to delete product")
+		handleWriteError(ctx, err, "failed to delete product")
 		return
 	}
 	ctx.JSON(http.StatusNoContent, struct{}{})
 }
 
+// restore undoes the soft deletion of a product, honouring If-Match.
+func (handler *productHandler) restore(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid product ID")
+		return
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	restored, err := handler.ctrl.Restore(ctx.Request.Context(), model.ProductID(id), version)
+	if err != nil {
+		handleWriteError(ctx, err, "failed to restore product")
+		return
+	}
+	ctx.Header("ETag", formatETag(restored.Version))
+	ctx.JSON(http.StatusOK, restored)
+}
+
+func (handler *productHandler) search(ctx *gin.Context) {
+	query := ctx.Query("q")
+	if query == "" {
+		handleError(ctx, http.StatusBadRequest, "missing search query")
+		return
+	}
+	limit := defaultSearchLimit
+	if raw := ctx.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			handleError(ctx, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := handler.ctrl.Search(ctx.Request.Context(), query, limit)
+	if err != nil {
+		handleError(ctx, http.StatusInternalServerError, "failed to search products")
+		return
+	}
+	if results == nil {
+		results = []*model.ProductSearchResult{}
+	}
+	ctx.JSON(http.StatusOK, results)
+}
+
+// parseAttributeFilters extracts attribute filters such as attr.screenSize>=50
+// from the raw query string. The raw query is used because comparison operators
+// other than "=" do not survive the standard key=value query parsing.
+func parseAttributeFilters(rawQuery string) ([]model.AttributeFilter, error) {
+	var filters []model.AttributeFilter
+	for _, part := range strings.Split(rawQuery, "&") {
+		expr, err := url.QueryUnescape(part)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := strings.CutPrefix(expr, "attr.")
+		if !ok {
+			continue
+		}
+		filter, err := model.ParseAttributeFilter(name)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
 func InitProductHandler(engine *gin.Engine, ctrl IProductController) {
 	handler := &productHandler{ctrl: ctrl}
 	router := engine.Group("/products")
-	router.POST("", handler.post)
-	router.PUT(":id", handler.update)
-	router.GET("", handler.getAll)
-	router.GET(":id", handler.get)
-	router.DELETE(":id", handler.delete)
+	router.POST("", auth.Require(auth.PermCatalogWrite), handler.post)
+	router.PUT(":id", auth.Require(auth.PermCatalogWrite), handler.update)
+	router.PATCH(":id", auth.Require(auth.PermCatalogWrite), handler.patch)
+	router.GET("", auth.Require(auth.PermCatalogRead), handler.getAll)
+	router.GET("search", auth.Require(auth.PermCatalogRead), handler.search)
+	router.GET(":id", auth.Require(auth.PermCatalogRead), handler.get)
+	router.DELETE(":id", auth.Require(auth.PermCatalogWrite), handler.delete)
+	router.POST(":id/restore", auth.Require(auth.PermCatalogWrite), handler.restore)
 }
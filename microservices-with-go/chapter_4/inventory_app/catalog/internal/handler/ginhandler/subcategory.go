@@ -7,6 +7,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/jsonpatch"
 )
 
 type ISubCategoryController interface {
@@ -14,7 +16,10 @@ type ISubCategoryController interface {
 	Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic) error
 	Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryDetails, error)
 	GetAll(ctx context.Context) ([]*model.SubCategoryDetails, error)
-	Delete(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error)
+	Patch(ctx context.Context, id model.SubCategoryID, version int, patch jsonpatch.Func) (*model.SubCategoryBasic, error)
+	Delete(ctx context.Context, id model.SubCategoryID, version int) (*model.SubCategoryBasic, error)
+	GetDeleted(ctx context.Context) ([]*model.SubCategoryDetails, error)
+	Restore(ctx context.Context, id model.SubCategoryID, version int) (*model.SubCategoryBasic, error)
 }
 
 type subCategoryHandler struct {
@@ -41,27 +46,75 @@ func (handler *subCategoryHandler) update(ctx *gin.Context) {
 		handleError(ctx, http.StatusBadRequest, "invalid subcategory ID")
 		return
 	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
 	var data model.SubCategoryBasic
 	if err := ctx.ShouldBindJSON(&data); err != nil {
 		handleError(ctx, http.StatusBadRequest, "invalid request payload")
 		return
 	}
 	data.BaseInfo.ID = model.SubCategoryID(id)
+	data.BaseInfo.Version = version
 	err = handler.ctrl.Update(ctx.Request.Context(), data.BaseInfo.ID, &data)
 	if err != nil {
-		handleError(ctx, http.StatusInternalServerError, "failed to update subcategory")
+		handleWriteError(ctx, err, "failed to update subcategory")
 		return
 	}
+	ctx.Header("ETag", formatETag(data.BaseInfo.Version))
 
 	ctx.JSON(http.StatusAccepted, data)
 }
 
+// patch applies a merge patch (application/merge-patch+json) or JSON patch
+// (application/json-patch+json) to the subcategory, honouring If-Match.
+func (handler *subCategoryHandler) patch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid subcategory ID")
+		return
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	patch, ok := readPatch(ctx)
+	if !ok {
+		return
+	}
+	patched, err := handler.ctrl.Patch(ctx.Request.Context(), model.SubCategoryID(id), version, patch)
+	if err != nil {
+		handleWriteError(ctx, err, "failed to patch subcategory")
+		return
+	}
+	ctx.Header("ETag", formatETag(patched.BaseInfo.Version))
+	ctx.JSON(http.StatusOK, patched)
+}
+
 func (handler *subCategoryHandler) getAll(ctx *gin.Context) {
-	all, err := handler.ctrl.GetAll(ctx.Request.Context())
+	withDeleted, err := includeDeleted(ctx)
 	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	all, err := handler.ctrl.GetAll(ctx.Request.Context())
+	if err != nil && !withDeleted {
 		handleError(ctx, http.StatusInternalServerError, "failed to retrieve subcategories")
 		return
 	}
+	if withDeleted {
+		deleted, err := handler.ctrl.GetDeleted(ctx.Request.Context())
+		if err != nil {
+			handleError(ctx, http.StatusInternalServerError, "failed to retrieve deleted subcategories")
+			return
+		}
+		all = append(all, deleted...)
+	}
 	ctx.JSON(http.StatusOK, all)
 }
 
@@ -72,10 +125,17 @@ func (handler *subCategoryHandler) get(ctx *gin.Context) {
 		return
 	}
 	subCategory, err := handler.ctrl.Get(ctx.Request.Context(), model.SubCategoryID(id))
+	if isNotFound(err) {
+		handleError(ctx, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		handleError(ctx, http.StatusInternalServerError, "failed to retrieve subcategory")
 		return
 	}
+	if writeETag(ctx, subCategory.Version) {
+		return
+	}
 	ctx.JSON(http.StatusOK, subCategory)
 }
 
@@ -85,20 +145,48 @@ func (handler *subCategoryHandler) delete(ctx *gin.Context) {
 		handleError(ctx, http.StatusBadRequest, "invalid subcategory ID")
 		return
 	}
-	_, err = handler.ctrl.Delete(ctx.Request.Context(), model.SubCategoryID(id))
+	version, err := ifMatchVersion(ctx)
 	if err != nil {
-		handleError(ctx, http.StatusInternalServerError, "failed to delete subcategory")
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	_, err = handler.ctrl.Delete(ctx.Request.Context(), model.SubCategoryID(id), version)
+	if err != nil {
+		handleWriteError(ctx, err, "failed to delete subcategory")
 		return
 	}
 	ctx.JSON(http.StatusNoContent, struct{}{})
 }
 
+// restore undoes the soft deletion of a subcategory, honouring If-Match.
+func (handler *subCategoryHandler) restore(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid subcategory ID")
+		return
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	restored, err := handler.ctrl.Restore(ctx.Request.Context(), model.SubCategoryID(id), version)
+	if err != nil {
+		handleWriteError(ctx, err, "failed to restore subcategory")
+		return
+	}
+	ctx.Header("ETag", formatETag(restored.BaseInfo.Version))
+	ctx.JSON(http.StatusOK, restored)
+}
+
 func InitSubCategoryHandler(engine *gin.Engine, ctrl ISubCategoryController) {
 	handler := &subCategoryHandler{ctrl: ctrl}
 	router := engine.Group("/subcategories")
-	router.POST("", handler.post)
-	router.PUT(":id", handler.update)
-	router.GET("", handler.getAll)
-	router.GET(":id", handler.get)
-	router.DELETE(":id", handler.delete)
+	router.POST("", auth.Require(auth.PermCatalogWrite), handler.post)
+	router.PUT(":id", auth.Require(auth.PermCatalogWrite), handler.update)
+	router.PATCH(":id", auth.Require(auth.PermCatalogWrite), handler.patch)
+	router.GET("", auth.Require(auth.PermCatalogRead), handler.getAll)
+	router.GET(":id", auth.Require(auth.PermCatalogRead), handler.get)
+	router.DELETE(":id", auth.Require(auth.PermCatalogWrite), handler.delete)
+	router.POST(":id/restore", auth.Require(auth.PermCatalogWrite), handler.restore)
 }
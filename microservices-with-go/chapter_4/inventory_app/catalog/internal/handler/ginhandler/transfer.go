@@ -0,0 +1,101 @@
+package ginhandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/catalog/internal/transfer"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/auth"
+)
+
+type ITransferController interface {
+	Import(ctx context.Context, rows []model.ImportRow, dryRun bool) (*model.ImportReport, error)
+	Export(ctx context.Context, emit func(model.CatalogRecord) error) error
+}
+
+type transferHandler struct {
+	ctrl ITransferController
+}
+
+// importCatalog accepts a CSV or NDJSON body. The format is taken from the
+// "format" query parameter, falling back to the Content-Type header.
+func (handler *transferHandler) importCatalog(ctx *gin.Context) {
+	formatName := ctx.Query("format")
+	if formatName == "" {
+		formatName = ctx.ContentType()
+	}
+	format, err := transfer.ParseFormat(formatName)
+	if err != nil {
+		handleError(ctx, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	dryRun, err := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, "invalid dryRun flag")
+		return
+	}
+
+	rows, rowErrors, err := transfer.Decode(ctx.Request.Body, format)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := handler.ctrl.Import(ctx.Request.Context(), rows, dryRun)
+	if err != nil {
+		handleError(ctx, http.StatusInternalServerError, "failed to import catalog")
+		return
+	}
+	report.Total += len(rowErrors)
+	report.Errors = append(report.Errors, rowErrors...)
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	report.Failed = len(report.Errors)
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// exportCatalog streams the full catalog in the format given by the "format"
+// query parameter (csv or ndjson, default ndjson).
+func (handler *transferHandler) exportCatalog(ctx *gin.Context) {
+	format, err := transfer.ParseFormat(ctx.DefaultQuery("format", string(transfer.FormatNDJSON)))
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	encoder, err := transfer.NewEncoder(ctx.Writer, format)
+	if err != nil {
+		handleError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=catalog.%s", format))
+	ctx.Status(http.StatusOK)
+
+	err = handler.ctrl.Export(ctx.Request.Context(), func(record model.CatalogRecord) error {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// The status line has already been sent, so the error can only be logged.
+		slog.ErrorContext(ctx.Request.Context(), "failed to stream catalog export", "error", err)
+	}
+}
+
+func InitTransferHandler(engine *gin.Engine, ctrl ITransferController) {
+	handler := &transferHandler{ctrl: ctrl}
+	engine.POST("/import", auth.Require(auth.PermCatalogWrite), handler.importCatalog)
+	engine.GET("/export", auth.Require(auth.PermCatalogRead), handler.exportCatalog)
+}
@@ -0,0 +1,80 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"inventory.com/catalog/internal/controller"
+	"inventory.com/catalog/pkg/model"
+)
+
+// CategoryRepository is the category repository being decorated.
+type CategoryRepository interface {
+	controller.ICategoryRepository
+	controller.IPurger[model.Category]
+	Ping(ctx context.Context) error
+}
+
+// Category instruments a category repository.
+type Category struct {
+	repository
+	next CategoryRepository
+}
+
+func NewCategory(next CategoryRepository) *Category {
+	return &Category{repository: nameOf(next), next: next}
+}
+
+func (r *Category) Ping(ctx context.Context) error {
+	ctx, end := r.start(ctx, "Ping")
+	defer end()
+	return r.next.Ping(ctx)
+}
+
+func (r *Category) Create(ctx context.Context, data *model.Category, commit func(before, after *model.Category) error) (*model.Category, error) {
+	ctx, end := r.start(ctx, "Create")
+	defer end()
+	return r.next.Create(ctx, data, commit)
+}
+
+func (r *Category) Update(ctx context.Context, id model.CategoryID, data *model.Category, commit func(before, after *model.Category) error) error {
+	ctx, end := r.start(ctx, "Update")
+	defer end()
+	return r.next.Update(ctx, id, data, commit)
+}
+
+func (r *Category) Get(ctx context.Context, id model.CategoryID) (*model.Category, error) {
+	ctx, end := r.start(ctx, "Get")
+	defer end()
+	return r.next.Get(ctx, id)
+}
+
+func (r *Category) GetAll(ctx context.Context) ([]*model.Category, error) {
+	ctx, end := r.start(ctx, "GetAll")
+	defer end()
+	return r.next.GetAll(ctx)
+}
+
+func (r *Category) Delete(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error) {
+	ctx, end := r.start(ctx, "Delete")
+	defer end()
+	return r.next.Delete(ctx, id, version, commit)
+}
+
+func (r *Category) GetDeleted(ctx context.Context) ([]*model.Category, error) {
+	ctx, end := r.start(ctx, "GetDeleted")
+	defer end()
+	return r.next.GetDeleted(ctx)
+}
+
+func (r *Category) Restore(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error) {
+	ctx, end := r.start(ctx, "Restore")
+	defer end()
+	return r.next.Restore(ctx, id, version, commit)
+}
+
+func (r *Category) Purge(ctx context.Context, before time.Time, commit func(purged *model.Category) error) (int, error) {
+	ctx, end := r.start(ctx, "Purge")
+	defer end()
+	return r.next.Purge(ctx, before, commit)
+}
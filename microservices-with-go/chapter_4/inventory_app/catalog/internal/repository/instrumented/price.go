@@ -0,0 +1,56 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"inventory.com/catalog/internal/controller"
+	"inventory.com/catalog/pkg/model"
+)
+
+// PriceHistoryRepository is the price history repository being decorated.
+type PriceHistoryRepository interface {
+	controller.IPriceHistoryRepository
+	controller.IPriceHistoryPurger
+	Ping(ctx context.Context) error
+}
+
+// PriceHistory instruments a price history repository.
+type PriceHistory struct {
+	repository
+	next PriceHistoryRepository
+}
+
+func NewPriceHistory(next PriceHistoryRepository) *PriceHistory {
+	return &PriceHistory{repository: nameOf(next), next: next}
+}
+
+func (r *PriceHistory) Ping(ctx context.Context) error {
+	ctx, end := r.start(ctx, "Ping")
+	defer end()
+	return r.next.Ping(ctx)
+}
+
+func (r *PriceHistory) Add(ctx context.Context, change *model.PriceChange) error {
+	ctx, end := r.start(ctx, "Add")
+	defer end()
+	return r.next.Add(ctx, change)
+}
+
+func (r *PriceHistory) GetByProductID(ctx context.Context, id model.ProductID) ([]*model.PriceChange, error) {
+	ctx, end := r.start(ctx, "GetByProductID")
+	defer end()
+	return r.next.GetByProductID(ctx, id)
+}
+
+func (r *PriceHistory) At(ctx context.Context, id model.ProductID, at time.Time) (*model.PriceChange, error) {
+	ctx, end := r.start(ctx, "At")
+	defer end()
+	return r.next.At(ctx, id, at)
+}
+
+func (r *PriceHistory) DeleteByProductID(ctx context.Context, id model.ProductID) error {
+	ctx, end := r.start(ctx, "DeleteByProductID")
+	defer end()
+	return r.next.DeleteByProductID(ctx, id)
+}
@@ -0,0 +1,80 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"inventory.com/catalog/internal/controller"
+	"inventory.com/catalog/pkg/model"
+)
+
+// ProductRepository is the product repository being decorated.
+type ProductRepository interface {
+	controller.IProductRepository
+	controller.IPurger[model.ProductBasic]
+	Ping(ctx context.Context) error
+}
+
+// Product instruments a product repository.
+type Product struct {
+	repository
+	next ProductRepository
+}
+
+func NewProduct(next ProductRepository) *Product {
+	return &Product{repository: nameOf(next), next: next}
+}
+
+func (r *Product) Ping(ctx context.Context) error {
+	ctx, end := r.start(ctx, "Ping")
+	defer end()
+	return r.next.Ping(ctx)
+}
+
+func (r *Product) Create(ctx context.Context, data *model.ProductBasic, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
+	ctx, end := r.start(ctx, "Create")
+	defer end()
+	return r.next.Create(ctx, data, commit)
+}
+
+func (r *Product) Update(ctx context.Context, id model.ProductID, data *model.ProductBasic, commit func(before, after *model.ProductBasic) error) error {
+	ctx, end := r.start(ctx, "Update")
+	defer end()
+	return r.next.Update(ctx, id, data, commit)
+}
+
+func (r *Product) Get(ctx context.Context, id model.ProductID) (*model.ProductBasic, error) {
+	ctx, end := r.start(ctx, "Get")
+	defer end()
+	return r.next.Get(ctx, id)
+}
+
+func (r *Product) GetAll(ctx context.Context) ([]*model.ProductBasic, error) {
+	ctx, end := r.start(ctx, "GetAll")
+	defer end()
+	return r.next.GetAll(ctx)
+}
+
+func (r *Product) Delete(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
+	ctx, end := r.start(ctx, "Delete")
+	defer end()
+	return r.next.Delete(ctx, id, version, commit)
+}
+
+func (r *Product) GetDeleted(ctx context.Context) ([]*model.ProductBasic, error) {
+	ctx, end := r.start(ctx, "GetDeleted")
+	defer end()
+	return r.next.GetDeleted(ctx)
+}
+
+func (r *Product) Restore(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
+	ctx, end := r.start(ctx, "Restore")
+	defer end()
+	return r.next.Restore(ctx, id, version, commit)
+}
+
+func (r *Product) Purge(ctx context.Context, before time.Time, commit func(purged *model.ProductBasic) error) (int, error) {
+	ctx, end := r.start(ctx, "Purge")
+	defer end()
+	return r.next.Purge(ctx, before, commit)
+}
@@ -0,0 +1,80 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"inventory.com/catalog/internal/controller"
+	"inventory.com/catalog/pkg/model"
+)
+
+// SubCategoryRepository is the sub-category repository being decorated.
+type SubCategoryRepository interface {
+	controller.ISubCategoryRepository
+	controller.IPurger[model.SubCategoryBasic]
+	Ping(ctx context.Context) error
+}
+
+// SubCategory instruments a sub-category repository.
+type SubCategory struct {
+	repository
+	next SubCategoryRepository
+}
+
+func NewSubCategory(next SubCategoryRepository) *SubCategory {
+	return &SubCategory{repository: nameOf(next), next: next}
+}
+
+func (r *SubCategory) Ping(ctx context.Context) error {
+	ctx, end := r.start(ctx, "Ping")
+	defer end()
+	return r.next.Ping(ctx)
+}
+
+func (r *SubCategory) Create(ctx context.Context, data *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
+	ctx, end := r.start(ctx, "Create")
+	defer end()
+	return r.next.Create(ctx, data, commit)
+}
+
+func (r *SubCategory) Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) error {
+	ctx, end := r.start(ctx, "Update")
+	defer end()
+	return r.next.Update(ctx, id, data, commit)
+}
+
+func (r *SubCategory) Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error) {
+	ctx, end := r.start(ctx, "Get")
+	defer end()
+	return r.next.Get(ctx, id)
+}
+
+func (r *SubCategory) GetAll(ctx context.Context) ([]*model.SubCategoryBasic, error) {
+	ctx, end := r.start(ctx, "GetAll")
+	defer end()
+	return r.next.GetAll(ctx)
+}
+
+func (r *SubCategory) Delete(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
+	ctx, end := r.start(ctx, "Delete")
+	defer end()
+	return r.next.Delete(ctx, id, version, commit)
+}
+
+func (r *SubCategory) GetDeleted(ctx context.Context) ([]*model.SubCategoryBasic, error) {
+	ctx, end := r.start(ctx, "GetDeleted")
+	defer end()
+	return r.next.GetDeleted(ctx)
+}
+
+func (r *SubCategory) Restore(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
+	ctx, end := r.start(ctx, "Restore")
+	defer end()
+	return r.next.Restore(ctx, id, version, commit)
+}
+
+func (r *SubCategory) Purge(ctx context.Context, before time.Time, commit func(purged *model.SubCategoryBasic) error) (int, error) {
+	ctx, end := r.start(ctx, "Purge")
+	defer end()
+	return r.next.Purge(ctx, before, commit)
+}
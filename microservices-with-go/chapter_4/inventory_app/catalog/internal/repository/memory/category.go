@@ -2,20 +2,24 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tenant"
 )
 
-var (
-	ErrCategoryNotFound = errors.New("category not found")
-)
-
-// Category represents an in-memory repository for categories.
+// Category represents an in-memory repository for categories. Data and ID
+// sequences are partitioned per tenant; every method only sees the partition
+// of the tenant its context is bound to.
 type Category struct {
-	mu    sync.RWMutex
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[categoryPartition]
+}
+
+// categoryPartition holds the categories and ID sequence of one tenant.
+type categoryPartition struct {
 	data  []*model.Category
 	seqID int
 }
@@ -23,80 +27,249 @@ type Category struct {
 // NewCategory returns a new in-memory Category repository.
 func NewCategory() *Category {
 	return &Category{
-		data:  make([]*model.Category, 0),
-		seqID: 0,
+		tenants: tenant.NewPartitions(func() *categoryPartition {
+			return &categoryPartition{data: make([]*model.Category, 0)}
+		}),
 	}
 }
 
-// Create adds a new category to the in-memory store.
-func (repo *Category) Create(ctx context.Context, data *model.Category) (*model.Category, error) {
+// Ping checks that the repository answers. It waits for the lock like any
+// read, so a repository stuck holding its lock fails a timed check. It needs
+// no tenant and creates no partition.
+func (repo *Category) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
+// Create adds a new category to the in-memory store. The stored category is
+// a copy of data with its ID and version assigned.
+//
+// Every write calls commit with the entity before and after the change while
+// the repository is locked, before is nil for a new entity. The change is
+// only stored if commit returns nil, so whatever commit writes alongside it,
+// e.g. outbox events, is never observed without it.
+func (repo *Category) Create(ctx context.Context, data *model.Category, commit func(before, after *model.Category) error) (*model.Category, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	repo.seqID++
-	data.ID = model.CategoryID(repo.seqID)
-	repo.data = append(repo.data, data)
+	created := *data
+	created.ID = model.CategoryID(part.seqID + 1)
+	created.Version = 1
+	if err := commit(nil, &created); err != nil {
+		return nil, err
+	}
+	part.seqID++
+	part.data = append(part.data, &created)
 
-	return data, nil
+	return &created, nil
 }
 
-// Update updates an existing category. Returns ErrCategoryNotFound if not found.
-func (repo *Category) Update(ctx context.Context, id model.CategoryID, data *model.Category) error {
+// Update updates an existing category. Returns model.ErrCategoryNotFound if not found.
+// Unless data.Version is model.AnyVersion it must match the stored version, otherwise
+// model.ErrVersionConflict is returned. On success data.Version is set to the new version.
+func (repo *Category) Update(ctx context.Context, id model.CategoryID, data *model.Category, commit func(before, after *model.Category) error) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
 
-	_, existing := repo.find(id)
-	if existing == nil {
-		return fmt.Errorf("%w: id=%d", ErrCategoryNotFound, id)
+	_, existing := part.find(id)
+	if existing == nil || existing.DeletedAt != nil {
+		return fmt.Errorf("%w: id=%d", model.ErrCategoryNotFound, id)
 	}
-	existing.Name = data.Name
+	if err := checkVersion(existing.Version, data.Version); err != nil {
+		return err
+	}
+	updated := *existing
+	updated.Name = data.Name
+	updated.Attributes = data.Attributes
+	updated.Version++
+	if err := commit(existing, &updated); err != nil {
+		return err
+	}
+	*existing = updated
+	data.Version = existing.Version
 	return nil
 }
 
-// GetAll returns all categories. Returns ErrCategoryNotFound if no categories exist.
+// GetAll returns all categories that are not deleted. Returns model.ErrCategoryNotFound if no categories exist.
 // TODO: Add pagination support
 func (repo *Category) GetAll(ctx context.Context) ([]*model.Category, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(repo.data) == 0 {
-		return nil, ErrCategoryNotFound
+	all := filter(part.data, func(c *model.Category) bool { return c.DeletedAt == nil })
+	if len(all) == 0 {
+		return nil, model.ErrCategoryNotFound
 	}
-	return repo.data, nil
+	return all, nil
 }
 
-// Delete removes a category by ID. Returns the deleted category or ErrCategoryNotFound.
-func (repo *Category) Delete(ctx context.Context, id model.CategoryID) (*model.Category, error) {
+// GetDeleted returns the soft deleted categories that have not been purged yet.
+func (repo *Category) GetDeleted(ctx context.Context) ([]*model.Category, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter(part.data, func(c *model.Category) bool { return c.DeletedAt != nil }), nil
+}
+
+// Delete soft deletes a category by ID, keeping it as a tombstone until it is
+// purged. Returns the deleted category or model.ErrCategoryNotFound.
+// Unless version is model.AnyVersion it must match the stored version.
+func (repo *Category) Delete(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	index, found := repo.find(id)
+	_, found := part.find(id)
+	if found == nil || found.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: id=%d", model.ErrCategoryNotFound, id)
+	}
+	if err := checkVersion(found.Version, version); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deleted := *found
+	deleted.DeletedAt = &now
+	deleted.Version++
+	if err := commit(found, &deleted); err != nil {
+		return nil, err
+	}
+	*found = deleted
+	return found, nil
+}
+
+// Restore undoes the soft deletion of a category. Returns model.ErrNotDeleted
+// if the category is not deleted.
+func (repo *Category) Restore(ctx context.Context, id model.CategoryID, version int, commit func(before, after *model.Category) error) (*model.Category, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, found := part.find(id)
 	if found == nil {
-		return nil, fmt.Errorf("%w: id=%d", ErrCategoryNotFound, id)
+		return nil, fmt.Errorf("%w: id=%d", model.ErrCategoryNotFound, id)
+	}
+	if found.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: category id=%d", model.ErrNotDeleted, id)
+	}
+	if err := checkVersion(found.Version, version); err != nil {
+		return nil, err
 	}
 
-	repo.data = append(repo.data[:index], repo.data[index+1:]...)
+	restored := *found
+	restored.DeletedAt = nil
+	restored.Version++
+	if err := commit(found, &restored); err != nil {
+		return nil, err
+	}
+	*found = restored
 	return found, nil
 }
 
-// Get retrieves a category by ID. Returns ErrCategoryNotFound if not found.
+// Purge permanently removes categories deleted before the given time and
+// returns how many were removed. commit is called with every category before
+// it is removed; when it fails the category and those after it are kept.
+func (repo *Category) Purge(ctx context.Context, before time.Time, commit func(purged *model.Category) error) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int
+	part.data, purged, err = purge(part.data, func(c *model.Category) bool { return deletedBefore(c.DeletedAt, before) }, commit)
+	return purged, err
+}
+
+// Get retrieves a category by ID. Returns model.ErrCategoryNotFound if not found or deleted.
 func (repo *Category) Get(ctx context.Context, id model.CategoryID) (*model.Category, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	_, found := repo.find(id)
-	if found == nil {
-		return nil, fmt.Errorf("%w: id=%d", ErrCategoryNotFound, id)
+	_, found := part.find(id)
+	if found == nil || found.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: id=%d", model.ErrCategoryNotFound, id)
 	}
 	return found, nil
 }
 
 // find returns the index and pointer to the category, or -1 and nil if not found.
-func (repo *Category) find(id model.CategoryID) (int, *model.Category) {
-	for i, d := range repo.data {
+func (part *categoryPartition) find(id model.CategoryID) (int, *model.Category) {
+	for i, d := range part.data {
 		if d.ID == id {
 			return i, d
 		}
 	}
 	return -1, nil
 }
+
+// filter returns the entities for which keep reports true.
+func filter[T any](data []T, keep func(T) bool) []T {
+	result := make([]T, 0, len(data))
+	for _, d := range data {
+		if keep(d) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// purge removes the entities for which expired reports true, calling commit
+// before each removal. It stops at the first failing commit, keeping that
+// entity and all after it, and returns the remaining entities and how many
+// were removed.
+func purge[T any](data []*T, expired func(*T) bool, commit func(purged *T) error) ([]*T, int, error) {
+	kept := make([]*T, 0, len(data))
+	for i, d := range data {
+		if !expired(d) {
+			kept = append(kept, d)
+			continue
+		}
+		if err := commit(d); err != nil {
+			return append(kept, data[i:]...), i - len(kept), err
+		}
+	}
+	return kept, len(data) - len(kept), nil
+}
+
+// deletedBefore reports whether a tombstone is older than the given time.
+func deletedBefore(deletedAt *time.Time, before time.Time) bool {
+	return deletedAt != nil && deletedAt.Before(before)
+}
+
+// checkVersion compares the stored version of an entity with the version a
+// caller expects, where model.AnyVersion matches every version.
+func checkVersion(current, expected int) error {
+	if expected != model.AnyVersion && expected != current {
+		return fmt.Errorf("%w: expected version %d, current version %d", model.ErrVersionConflict, expected, current)
+	}
+	return nil
+}
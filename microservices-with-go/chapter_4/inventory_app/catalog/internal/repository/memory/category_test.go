@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tenant"
+	"time"
+)
+
+// noCommit is a commit hook accepting every change.
+func noCommit[T any](before, after *T) error { return nil }
+
+// purgeAll is a purge hook accepting every removal.
+func purgeAll[T any](purged *T) error { return nil }
+
+func TestCategory_CommitFailureKeepsState(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	repo := NewCategory()
+	failure := errors.New("outbox unavailable")
+	fail := func(before, after *model.Category) error { return failure }
+
+	_, err := repo.Create(ctx, &model.Category{Name: "TV"}, fail)
+	assert.ErrorIs(t, err, failure)
+	_, err = repo.GetAll(ctx)
+	assert.ErrorIs(t, err, model.ErrCategoryNotFound, "a failed commit stores nothing")
+
+	created, err := repo.Create(ctx, &model.Category{Name: "TV"}, func(before, after *model.Category) error {
+		assert.Nil(t, before)
+		assert.Equal(t, model.CategoryID(1), after.ID, "the sequence is not advanced by a failed commit")
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repo.Update(ctx, created.ID, &model.Category{Name: "Television", Version: 1}, fail), failure)
+	_, err = repo.Delete(ctx, created.ID, 1, fail)
+	assert.ErrorIs(t, err, failure)
+	got, err := repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "TV", got.Name)
+	assert.Equal(t, 1, got.Version)
+
+	require.NoError(t, repo.Update(ctx, created.ID, &model.Category{Name: "Television", Version: 1}, func(before, after *model.Category) error {
+		assert.Equal(t, "TV", before.Name)
+		assert.Equal(t, "Television", after.Name)
+		assert.Equal(t, 2, after.Version)
+		return nil
+	}))
+}
+
+func TestCategory_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	repo := NewCategory()
+
+	first, err := repo.Create(acme, &model.Category{Name: "TV"}, noCommit)
+	require.NoError(t, err)
+	second, err := repo.Create(acme, &model.Category{Name: "Audio"}, noCommit)
+	require.NoError(t, err)
+	other, err := repo.Create(globex, &model.Category{Name: "Garden"}, noCommit)
+	require.NoError(t, err)
+	assert.Equal(t, model.CategoryID(1), first.ID)
+	assert.Equal(t, model.CategoryID(2), second.ID)
+	assert.Equal(t, model.CategoryID(1), other.ID, "every tenant has its own ID sequence")
+
+	require.NoError(t, repo.Update(acme, 1, &model.Category{Name: "Television", Version: model.AnyVersion}, noCommit))
+	got, err := repo.Get(globex, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Garden", got.Name, "the same ID of another tenant is not touched")
+	_, err = repo.Get(globex, 2)
+	assert.ErrorIs(t, err, model.ErrCategoryNotFound)
+
+	_, err = repo.Delete(globex, 1, model.AnyVersion, noCommit)
+	require.NoError(t, err)
+	got, err = repo.Get(acme, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Television", got.Name)
+	all, err := repo.GetAll(acme)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	_, err = repo.GetAll(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+}
+
+func TestCategory_Purge(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	repo := NewCategory()
+	for _, name := range []string{"TV", "Audio", "Garden"} {
+		_, err := repo.Create(ctx, &model.Category{Name: name}, noCommit)
+		require.NoError(t, err)
+	}
+	for _, id := range []model.CategoryID{1, 3} {
+		_, err := repo.Delete(ctx, id, model.AnyVersion, noCommit)
+		require.NoError(t, err)
+	}
+
+	purged, err := repo.Purge(ctx, time.Now().Add(-time.Minute), purgeAll)
+	require.NoError(t, err)
+	assert.Zero(t, purged, "recent tombstones are kept")
+
+	failure := errors.New("audit unavailable")
+	var seen []model.CategoryID
+	purged, err = repo.Purge(ctx, time.Now().Add(time.Minute), func(purged *model.Category) error {
+		seen = append(seen, purged.ID)
+		if purged.ID == 3 {
+			return failure
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, []model.CategoryID{1, 3}, seen)
+	deleted, err := repo.GetDeleted(ctx)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1, "the tombstone whose commit failed is kept")
+	assert.Equal(t, model.CategoryID(3), deleted[0].ID)
+
+	purged, err = repo.Purge(ctx, time.Now().Add(time.Minute), purgeAll)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
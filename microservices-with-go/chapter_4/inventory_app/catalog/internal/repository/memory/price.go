@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+// PriceHistory handles in-memory storage of product price histories.
+// Entries of each product are kept ordered by EffectiveFrom. Histories are
+// partitioned per tenant like those of Category.
+type PriceHistory struct {
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[map[model.ProductID][]*model.PriceChange]
+}
+
+// NewPriceHistory returns a new in-memory PriceHistory repository.
+func NewPriceHistory() *PriceHistory {
+	return &PriceHistory{
+		tenants: tenant.NewPartitions(func() *map[model.ProductID][]*model.PriceChange {
+			data := make(map[model.ProductID][]*model.PriceChange)
+			return &data
+		}),
+	}
+}
+
+// Ping checks that the repository answers, like Category.Ping.
+func (repo *PriceHistory) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
+// Add inserts a copy of a price change into the product timeline. A change
+// with the same EffectiveFrom as an existing entry replaces it. EffectiveTo
+// of every entry is recalculated so that the timeline has no gaps or overlaps.
+func (repo *PriceHistory) Add(ctx context.Context, change *model.PriceChange) error {
+	change = clonePriceChange(change)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	histories, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
+	data := *histories
+
+	entries := data[change.ProductID]
+	i := sort.Search(len(entries), func(i int) bool {
+		return !entries[i].EffectiveFrom.Before(change.EffectiveFrom)
+	})
+	if i < len(entries) && entries[i].EffectiveFrom.Equal(change.EffectiveFrom) {
+		entries[i] = change
+	} else {
+		entries = append(entries, nil)
+		copy(entries[i+1:], entries[i:])
+		entries[i] = change
+	}
+
+	for j, e := range entries {
+		e.EffectiveTo = nil
+		if j+1 < len(entries) {
+			to := entries[j+1].EffectiveFrom
+			e.EffectiveTo = &to
+		}
+	}
+	data[change.ProductID] = entries
+	return nil
+}
+
+// GetByProductID returns a copy of the full price history of a product, oldest first.
+func (repo *PriceHistory) GetByProductID(ctx context.Context, id model.ProductID) ([]*model.PriceChange, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	histories, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := *histories
+
+	entries := data[id]
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: productId=%d", model.ErrPriceNotFound, id)
+	}
+	history := make([]*model.PriceChange, len(entries))
+	for i, e := range entries {
+		history[i] = clonePriceChange(e)
+	}
+	return history, nil
+}
+
+// At returns a copy of the price change in effect for a product at the given instant.
+func (repo *PriceHistory) At(ctx context.Context, id model.ProductID, at time.Time) (*model.PriceChange, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	histories, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := *histories
+
+	for _, e := range data[id] {
+		if e.InEffect(at) {
+			return clonePriceChange(e), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: productId=%d at=%s", model.ErrPriceNotFound, id, at.Format(time.RFC3339))
+}
+
+// DeleteByProductID removes the whole price history of a product, as when
+// the product is purged.
+func (repo *PriceHistory) DeleteByProductID(ctx context.Context, id model.ProductID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	histories, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
+
+	delete(*histories, id)
+	return nil
+}
+
+// clonePriceChange returns a copy of a price change that shares no memory
+// with it, so stored entries cannot be changed from outside the repository.
+func clonePriceChange(change *model.PriceChange) *model.PriceChange {
+	clone := *change
+	if change.EffectiveTo != nil {
+		to := *change.EffectiveTo
+		clone.EffectiveTo = &to
+	}
+	return &clone
+}
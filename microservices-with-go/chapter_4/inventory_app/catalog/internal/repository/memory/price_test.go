@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tenant"
+)
+
+func TestPriceHistory_ReturnsCopies(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	repo := NewPriceHistory()
+	from := time.Now().Add(-time.Hour)
+	price := money.Money{Amount: 1000, Currency: "USD"}
+
+	change := &model.PriceChange{ProductID: 1, Price: price, EffectiveFrom: from}
+	require.NoError(t, repo.Add(ctx, change))
+	require.NoError(t, repo.Add(ctx, &model.PriceChange{ProductID: 1, Price: price, EffectiveFrom: from.Add(time.Minute)}))
+	change.Price.Amount = 1
+	assert.Nil(t, change.EffectiveTo, "the caller's change is not stored")
+
+	history, err := repo.GetByProductID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, int64(1000), history[0].Price.Amount)
+	history[0].Price.Amount = 2
+	*history[0].EffectiveTo = from
+
+	current, err := repo.At(ctx, 1, from)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), current.Price.Amount)
+	assert.Equal(t, from.Add(time.Minute), *current.EffectiveTo)
+	current.Price.Amount = 3
+
+	again, err := repo.At(ctx, 1, from)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), again.Price.Amount)
+}
+
+func TestPriceHistory_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	repo := NewPriceHistory()
+	from := time.Now().Add(-time.Hour)
+
+	require.NoError(t, repo.Add(acme, &model.PriceChange{ProductID: 1, Price: money.Money{Amount: 1000, Currency: "USD"}, EffectiveFrom: from}))
+	require.NoError(t, repo.Add(globex, &model.PriceChange{ProductID: 1, Price: money.Money{Amount: 50, Currency: "EUR"}, EffectiveFrom: from}))
+
+	history, err := repo.GetByProductID(acme, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1, "the change of the same product of another tenant does not end this one")
+	assert.Nil(t, history[0].EffectiveTo)
+	current, err := repo.At(globex, 1, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), current.Price.Amount)
+
+	_, err = repo.At(tenant.WithID(context.Background(), tenant.Default), 1, time.Now())
+	assert.ErrorIs(t, err, model.ErrPriceNotFound)
+	_, err = repo.GetByProductID(context.Background(), 1)
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+}
+
+func TestPriceHistory_DeleteByProductID(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	repo := NewPriceHistory()
+	from := time.Now().Add(-time.Hour)
+	require.NoError(t, repo.Add(ctx, &model.PriceChange{ProductID: 1, Price: money.Money{Amount: 1000, Currency: "USD"}, EffectiveFrom: from}))
+	require.NoError(t, repo.Add(ctx, &model.PriceChange{ProductID: 2, Price: money.Money{Amount: 500, Currency: "USD"}, EffectiveFrom: from}))
+
+	require.NoError(t, repo.DeleteByProductID(ctx, 1))
+	_, err := repo.GetByProductID(ctx, 1)
+	assert.ErrorIs(t, err, model.ErrPriceNotFound)
+	history, err := repo.GetByProductID(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, history, 1, "other products keep their history")
+}
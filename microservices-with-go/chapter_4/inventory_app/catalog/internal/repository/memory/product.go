@@ -2,20 +2,22 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"inventory.com/catalog/pkg/model"
-)
-
-var (
-	ErrProductNotFound = errors.New("product not found")
+	"inventory.com/pkg/tenant"
 )
 
 // Product handles in-memory storage for products.
 type Product struct {
-	mu    sync.RWMutex
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[productPartition]
+}
+
+// productPartition holds the products and ID sequence of one tenant.
+type productPartition struct {
 	data  []*model.ProductBasic
 	seqID int
 }
@@ -23,74 +25,189 @@ type Product struct {
 // NewProduct returns a new in-memory Product repository.
 func NewProduct() *Product {
 	return &Product{
-		data: make([]*model.ProductBasic, 0),
+		tenants: tenant.NewPartitions(func() *productPartition {
+			return &productPartition{data: make([]*model.ProductBasic, 0)}
+		}),
 	}
 }
 
-// Create adds a new product to the in-memory store.
-func (repo *Product) Create(ctx context.Context, input *model.ProductBasic) (*model.ProductBasic, error) {
+// Ping checks that the repository answers, like Category.Ping.
+func (repo *Product) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
+// Create adds a copy of input to the in-memory store, committing it like
+// Category.Create.
+func (repo *Product) Create(ctx context.Context, input *model.ProductBasic, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	repo.seqID++
-	input.ID = model.ProductID(repo.seqID)
-	repo.data = append(repo.data, input)
-	return input, nil
+	created := *input
+	created.ID = model.ProductID(part.seqID + 1)
+	created.Version = 1
+	if err := commit(nil, &created); err != nil {
+		return nil, err
+	}
+	part.seqID++
+	part.data = append(part.data, &created)
+	return &created, nil
 }
 
-// Update modifies an existing product by ID.
-func (repo *Product) Update(ctx context.Context, id model.ProductID, updated *model.ProductBasic) error {
+// Update modifies an existing product by ID, subject to the same version
+// check as Category.Update. On success updated.Version is set to the new version.
+func (repo *Product) Update(ctx context.Context, id model.ProductID, updated *model.ProductBasic, commit func(before, after *model.ProductBasic) error) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
 
-	_, existing := repo.find(id)
-	if existing == nil {
-		return fmt.Errorf("%w: id=%d", ErrProductNotFound, id)
+	_, existing := part.find(id)
+	if existing == nil || existing.DeletedAt != nil {
+		return fmt.Errorf("%w: id=%d", model.ErrProductNotFound, id)
+	}
+	if err := checkVersion(existing.Version, updated.Version); err != nil {
+		return err
 	}
-	*existing = *updated
-	existing.ID = id // Make sure ID doesn't get overwritten
+	changed := *updated
+	changed.ID = id // Make sure ID doesn't get overwritten
+	changed.Version = existing.Version + 1
+	changed.DeletedAt = nil // Deletion goes through Delete only
+	if err := commit(existing, &changed); err != nil {
+		return err
+	}
+	*existing = changed
+	updated.Version = changed.Version
 	return nil
 }
 
-// Get retrieves a product by ID.
+// Get retrieves a product by ID, unless it is deleted.
 func (repo *Product) Get(ctx context.Context, id model.ProductID) (*model.ProductBasic, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	_, existing := repo.find(id)
-	if existing == nil {
-		return nil, fmt.Errorf("%w: id=%d", ErrProductNotFound, id)
+	_, existing := part.find(id)
+	if existing == nil || existing.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: id=%d", model.ErrProductNotFound, id)
 	}
 	return existing, nil
 }
 
-// GetAll returns all products. Returns ErrProductNotFound if no entries exist.
+// GetAll returns all products that are not deleted. Returns model.ErrProductNotFound if no entries exist.
 func (repo *Product) GetAll(ctx context.Context) ([]*model.ProductBasic, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	all := filter(part.data, func(p *model.ProductBasic) bool { return p.DeletedAt == nil })
+	if len(all) == 0 {
+		return nil, model.ErrProductNotFound
+	}
+	return all, nil
+}
+
+// GetDeleted returns the soft deleted products that have not been purged yet.
+func (repo *Product) GetDeleted(ctx context.Context) ([]*model.ProductBasic, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(repo.data) == 0 {
-		return nil, ErrProductNotFound
+	return filter(part.data, func(p *model.ProductBasic) bool { return p.DeletedAt != nil }), nil
+}
+
+// Delete soft deletes a product by ID and returns the deleted product.
+// Unless version is model.AnyVersion it must match the stored version.
+func (repo *Product) Delete(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, existing := part.find(id)
+	if existing == nil || existing.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: id=%d", model.ErrProductNotFound, id)
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	deleted := *existing
+	deleted.DeletedAt = &now
+	deleted.Version++
+	if err := commit(existing, &deleted); err != nil {
+		return nil, err
 	}
-	return repo.data, nil
+	*existing = deleted
+	return existing, nil
 }
 
-// Delete removes a product by ID and returns the deleted product.
-func (repo *Product) Delete(ctx context.Context, id model.ProductID) (*model.ProductBasic, error) {
+// Restore undoes the soft deletion of a product, subject to the same checks
+// as Category.Restore.
+func (repo *Product) Restore(ctx context.Context, id model.ProductID, version int, commit func(before, after *model.ProductBasic) error) (*model.ProductBasic, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	index, existing := repo.find(id)
+	_, existing := part.find(id)
 	if existing == nil {
-		return nil, fmt.Errorf("%w: id=%d", ErrProductNotFound, id)
+		return nil, fmt.Errorf("%w: id=%d", model.ErrProductNotFound, id)
+	}
+	if existing.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: product id=%d", model.ErrNotDeleted, id)
 	}
-	repo.data = append(repo.data[:index], repo.data[index+1:]...)
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	restored := *existing
+	restored.DeletedAt = nil
+	restored.Version++
+	if err := commit(existing, &restored); err != nil {
+		return nil, err
+	}
+	*existing = restored
 	return existing, nil
 }
 
+// Purge permanently removes products deleted before the given time, committing
+// every removal like Category.Purge.
+func (repo *Product) Purge(ctx context.Context, before time.Time, commit func(purged *model.ProductBasic) error) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int
+	part.data, purged, err = purge(part.data, func(p *model.ProductBasic) bool { return deletedBefore(p.DeletedAt, before) }, commit)
+	return purged, err
+}
+
 // find locates a product by ID and returns index and pointer.
-func (repo *Product) find(id model.ProductID) (int, *model.ProductBasic) {
-	for i, p := range repo.data {
+func (part *productPartition) find(id model.ProductID) (int, *model.ProductBasic) {
+	for i, p := range part.data {
 		if p.ID == id {
 			return i, p
 		}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+func TestProduct_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	repo := NewProduct()
+
+	product := func(name string) *model.ProductBasic {
+		return &model.ProductBasic{ProductBaseInfo: model.ProductBaseInfo{Name: name, Version: model.AnyVersion}, SubCatID: 1}
+	}
+	first, err := repo.Create(acme, product("TV"), noCommit)
+	require.NoError(t, err)
+	second, err := repo.Create(acme, product("Radio"), noCommit)
+	require.NoError(t, err)
+	other, err := repo.Create(globex, product("Mower"), noCommit)
+	require.NoError(t, err)
+	assert.Equal(t, model.ProductID(1), first.ID)
+	assert.Equal(t, model.ProductID(2), second.ID)
+	assert.Equal(t, model.ProductID(1), other.ID, "every tenant has its own ID sequence")
+
+	require.NoError(t, repo.Update(acme, 1, product("OLED TV"), noCommit))
+	got, err := repo.Get(globex, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Mower", got.Name, "the same ID of another tenant is not touched")
+	_, err = repo.Get(globex, 2)
+	assert.ErrorIs(t, err, model.ErrProductNotFound)
+
+	_, err = repo.Delete(globex, 1, model.AnyVersion, noCommit)
+	require.NoError(t, err)
+	got, err = repo.Get(acme, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "OLED TV", got.Name)
+
+	_, err = repo.GetAll(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+}
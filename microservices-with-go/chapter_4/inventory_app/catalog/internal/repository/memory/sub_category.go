@@ -2,20 +2,22 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"inventory.com/catalog/pkg/model"
-)
-
-var (
-	ErrSubCategoryNotFound = errors.New("sub-category not found")
+	"inventory.com/pkg/tenant"
 )
 
 // SubCategory handles in-memory storage for sub-categories.
 type SubCategory struct {
-	mu    sync.RWMutex
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[subCategoryPartition]
+}
+
+// subCategoryPartition holds the sub-categories and ID sequence of one tenant.
+type subCategoryPartition struct {
 	data  []*model.SubCategoryBasic
 	seqID int
 }
@@ -23,75 +25,190 @@ type SubCategory struct {
 // NewSubCategory returns a new in-memory SubCategory repository.
 func NewSubCategory() *SubCategory {
 	return &SubCategory{
-		data: make([]*model.SubCategoryBasic, 0),
+		tenants: tenant.NewPartitions(func() *subCategoryPartition {
+			return &subCategoryPartition{data: make([]*model.SubCategoryBasic, 0)}
+		}),
 	}
 }
 
-// Create adds a new sub-category to the in-memory store.
-func (repo *SubCategory) Create(ctx context.Context, input *model.SubCategoryBasic) (*model.SubCategoryBasic, error) {
+// Ping checks that the repository answers, like Category.Ping.
+func (repo *SubCategory) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
+// Create adds a copy of input to the in-memory store, committing it like
+// Category.Create.
+func (repo *SubCategory) Create(ctx context.Context, input *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	repo.seqID++
-	input.BaseInfo.ID = model.SubCategoryID(repo.seqID)
-	repo.data = append(repo.data, input)
-	return input, nil
+	created := *input
+	created.BaseInfo.ID = model.SubCategoryID(part.seqID + 1)
+	created.BaseInfo.Version = 1
+	if err := commit(nil, &created); err != nil {
+		return nil, err
+	}
+	part.seqID++
+	part.data = append(part.data, &created)
+	return &created, nil
 }
 
-// Update modifies an existing sub-category by ID.
-func (repo *SubCategory) Update(ctx context.Context, id model.SubCategoryID, updated *model.SubCategoryBasic) error {
+// Update modifies an existing sub-category by ID, subject to the same version
+// check as Category.Update. On success updated.BaseInfo.Version is set to the new version.
+func (repo *SubCategory) Update(ctx context.Context, id model.SubCategoryID, updated *model.SubCategoryBasic, commit func(before, after *model.SubCategoryBasic) error) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
 
-	_, existing := repo.find(id)
-	if existing == nil {
-		return fmt.Errorf("%w: id=%d", ErrSubCategoryNotFound, id)
+	_, existing := part.find(id)
+	if existing == nil || existing.BaseInfo.DeletedAt != nil {
+		return fmt.Errorf("%w: id=%d", model.ErrSubCategoryNotFound, id)
+	}
+	if err := checkVersion(existing.BaseInfo.Version, updated.BaseInfo.Version); err != nil {
+		return err
 	}
-	existing.BaseInfo.Name = updated.BaseInfo.Name
-	existing.CatID = updated.CatID
+	changed := *existing
+	changed.BaseInfo.Name = updated.BaseInfo.Name
+	changed.CatID = updated.CatID
+	changed.BaseInfo.Version++
+	if err := commit(existing, &changed); err != nil {
+		return err
+	}
+	*existing = changed
+	updated.BaseInfo.Version = existing.BaseInfo.Version
 	return nil
 }
 
-// Get returns a sub-category by ID.
+// Get returns a sub-category by ID, unless it is deleted.
 func (repo *SubCategory) Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	_, existing := repo.find(id)
-	if existing == nil {
-		return nil, fmt.Errorf("%w: id=%d", ErrSubCategoryNotFound, id)
+	_, existing := part.find(id)
+	if existing == nil || existing.BaseInfo.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: id=%d", model.ErrSubCategoryNotFound, id)
 	}
 	return existing, nil
 }
 
-// GetAll returns all sub-categories.
-// Returns ErrSubCategoryNotFound if store is empty.
+// GetAll returns all sub-categories that are not deleted.
+// Returns model.ErrSubCategoryNotFound if store is empty.
 func (repo *SubCategory) GetAll(ctx context.Context) ([]*model.SubCategoryBasic, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	all := filter(part.data, func(s *model.SubCategoryBasic) bool { return s.BaseInfo.DeletedAt == nil })
+	if len(all) == 0 {
+		return nil, model.ErrSubCategoryNotFound
+	}
+	return all, nil
+}
+
+// GetDeleted returns the soft deleted sub-categories that have not been purged yet.
+func (repo *SubCategory) GetDeleted(ctx context.Context) ([]*model.SubCategoryBasic, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(repo.data) == 0 {
-		return nil, ErrSubCategoryNotFound
+	return filter(part.data, func(s *model.SubCategoryBasic) bool { return s.BaseInfo.DeletedAt != nil }), nil
+}
+
+// Delete soft deletes a sub-category by ID and returns the deleted item.
+// Unless version is model.AnyVersion it must match the stored version.
+func (repo *SubCategory) Delete(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, existing := part.find(id)
+	if existing == nil || existing.BaseInfo.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: id=%d", model.ErrSubCategoryNotFound, id)
+	}
+	if err := checkVersion(existing.BaseInfo.Version, version); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	deleted := *existing
+	deleted.BaseInfo.DeletedAt = &now
+	deleted.BaseInfo.Version++
+	if err := commit(existing, &deleted); err != nil {
+		return nil, err
 	}
-	return repo.data, nil
+	*existing = deleted
+	return existing, nil
 }
 
-// Delete removes a sub-category by ID and returns the deleted item.
-func (repo *SubCategory) Delete(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryBasic, error) {
+// Restore undoes the soft deletion of a sub-category, subject to the same
+// checks as Category.Restore.
+func (repo *SubCategory) Restore(ctx context.Context, id model.SubCategoryID, version int, commit func(before, after *model.SubCategoryBasic) error) (*model.SubCategoryBasic, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	index, existing := repo.find(id)
+	_, existing := part.find(id)
 	if existing == nil {
-		return nil, fmt.Errorf("%w: id=%d", ErrSubCategoryNotFound, id)
+		return nil, fmt.Errorf("%w: id=%d", model.ErrSubCategoryNotFound, id)
+	}
+	if existing.BaseInfo.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: sub-category id=%d", model.ErrNotDeleted, id)
 	}
-	repo.data = append(repo.data[:index], repo.data[index+1:]...)
+	if err := checkVersion(existing.BaseInfo.Version, version); err != nil {
+		return nil, err
+	}
+	restored := *existing
+	restored.BaseInfo.DeletedAt = nil
+	restored.BaseInfo.Version++
+	if err := commit(existing, &restored); err != nil {
+		return nil, err
+	}
+	*existing = restored
 	return existing, nil
 }
 
+// Purge permanently removes sub-categories deleted before the given time, committing
+// every removal like Category.Purge.
+func (repo *SubCategory) Purge(ctx context.Context, before time.Time, commit func(purged *model.SubCategoryBasic) error) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int
+	part.data, purged, err = purge(part.data, func(s *model.SubCategoryBasic) bool { return deletedBefore(s.BaseInfo.DeletedAt, before) }, commit)
+	return purged, err
+}
+
 // find locates the sub-category by ID and returns index and pointer.
-func (repo *SubCategory) find(id model.SubCategoryID) (int, *model.SubCategoryBasic) {
-	for i, d := range repo.data {
+func (part *subCategoryPartition) find(id model.SubCategoryID) (int, *model.SubCategoryBasic) {
+	for i, d := range part.data {
 		if d.BaseInfo.ID == id {
 			return i, d
 		}
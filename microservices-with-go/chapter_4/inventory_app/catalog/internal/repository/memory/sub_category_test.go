@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+func TestSubCategory_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	repo := NewSubCategory()
+
+	first, err := repo.Create(acme, &model.SubCategoryBasic{BaseInfo: model.SubCategoryBaseInfo{Name: "OLED"}, CatID: 1}, noCommit)
+	require.NoError(t, err)
+	second, err := repo.Create(acme, &model.SubCategoryBasic{BaseInfo: model.SubCategoryBaseInfo{Name: "LCD"}, CatID: 1}, noCommit)
+	require.NoError(t, err)
+	other, err := repo.Create(globex, &model.SubCategoryBasic{BaseInfo: model.SubCategoryBaseInfo{Name: "Lawn"}, CatID: 1}, noCommit)
+	require.NoError(t, err)
+	assert.Equal(t, model.SubCategoryID(1), first.BaseInfo.ID)
+	assert.Equal(t, model.SubCategoryID(2), second.BaseInfo.ID)
+	assert.Equal(t, model.SubCategoryID(1), other.BaseInfo.ID, "every tenant has its own ID sequence")
+
+	require.NoError(t, repo.Update(acme, 1, &model.SubCategoryBasic{BaseInfo: model.SubCategoryBaseInfo{Name: "QD-OLED", Version: model.AnyVersion}, CatID: 1}, noCommit))
+	got, err := repo.Get(globex, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Lawn", got.BaseInfo.Name, "the same ID of another tenant is not touched")
+	_, err = repo.Get(globex, 2)
+	assert.ErrorIs(t, err, model.ErrSubCategoryNotFound)
+
+	_, err = repo.Delete(globex, 1, model.AnyVersion, noCommit)
+	require.NoError(t, err)
+	got, err = repo.Get(acme, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "QD-OLED", got.BaseInfo.Name)
+
+	_, err = repo.GetAll(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+}
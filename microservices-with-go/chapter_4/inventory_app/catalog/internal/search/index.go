@@ -0,0 +1,240 @@
+// Package search implements an in-process inverted index for catalog products
+// with prefix matching, typo tolerance and TF-IDF relevance ranking.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"inventory.com/catalog/pkg/model"
+)
+
+// Match weights applied to a posting depending on how the query token matched the term.
+const (
+	exactMatchWeight  = 1.0
+	prefixMatchWeight = 0.7
+	fuzzyMatchWeight  = 0.5
+)
+
+// minPrefixLength is the shortest query token that is expanded to matching prefixes.
+const minPrefixLength = 2
+
+// Field is a piece of text indexed for a product with its relevance weight.
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Hit is a single search result.
+type Hit struct {
+	ID    model.ProductID
+	Score float64
+}
+
+// Index is a concurrency-safe inverted index over product documents.
+type Index struct {
+	mu       sync.RWMutex
+	postings map[string]map[model.ProductID]float64 // term -> product -> weighted term frequency
+	docTerms map[model.ProductID][]string           // product -> indexed terms, used for removal
+	terms    []string                               // sorted vocabulary for prefix lookups
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[model.ProductID]float64),
+		docTerms: make(map[model.ProductID][]string),
+	}
+}
+
+// Put indexes a product, replacing any previously indexed version of it.
+func (idx *Index) Put(id model.ProductID, fields ...Field) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+
+	weights := make(map[string]float64)
+	for _, f := range fields {
+		for _, term := range Tokenize(f.Text) {
+			weights[term] += f.Weight
+		}
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, w := range weights {
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = make(map[model.ProductID]float64)
+			idx.postings[term] = docs
+			idx.insertTerm(term)
+		}
+		docs[id] = w
+		terms = append(terms, term)
+	}
+	idx.docTerms[id] = terms
+}
+
+// Remove deletes a product from the index.
+func (idx *Index) Remove(id model.ProductID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+// Search returns up to limit products matching every token of the query,
+// ordered by descending relevance. A limit of zero or less returns all hits.
+func (idx *Index) Search(query string, limit int) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	tokens := Tokenize(query)
+	if len(tokens) == 0 || len(idx.docTerms) == 0 {
+		return nil
+	}
+
+	var scores map[model.ProductID]float64
+	for _, token := range tokens {
+		tokenScores := idx.scoreToken(token)
+		if scores == nil {
+			scores = tokenScores
+			continue
+		}
+		// Every token must match: keep only products matched by all tokens so far.
+		for id := range scores {
+			if s, ok := tokenScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// scoreToken returns, for every product matching the token exactly, by prefix
+// or within the allowed edit distance, the best TF-IDF score of the matches.
+func (idx *Index) scoreToken(token string) map[model.ProductID]float64 {
+	scores := make(map[model.ProductID]float64)
+	apply := func(term string, matchWeight float64) {
+		docs := idx.postings[term]
+		idf := math.Log(1 + float64(len(idx.docTerms))/float64(len(docs)))
+		for id, tf := range docs {
+			if s := matchWeight * tf * idf; s > scores[id] {
+				scores[id] = s
+			}
+		}
+	}
+
+	if _, ok := idx.postings[token]; ok {
+		apply(token, exactMatchWeight)
+	}
+	if len([]rune(token)) >= minPrefixLength {
+		for i := sort.SearchStrings(idx.terms, token); i < len(idx.terms) && strings.HasPrefix(idx.terms[i], token); i++ {
+			if idx.terms[i] != token {
+				apply(idx.terms[i], prefixMatchWeight)
+			}
+		}
+	}
+	if maxDist := allowedTypos(token); maxDist > 0 {
+		for _, term := range idx.terms {
+			if term != token && withinDistance(token, term, maxDist) {
+				apply(term, fuzzyMatchWeight)
+			}
+		}
+	}
+	return scores
+}
+
+func (idx *Index) remove(id model.ProductID) {
+	for _, term := range idx.docTerms[id] {
+		docs := idx.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+			idx.deleteTerm(term)
+		}
+	}
+	delete(idx.docTerms, id)
+}
+
+func (idx *Index) insertTerm(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	idx.terms = append(idx.terms, "")
+	copy(idx.terms[i+1:], idx.terms[i:])
+	idx.terms[i] = term
+}
+
+func (idx *Index) deleteTerm(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	if i < len(idx.terms) && idx.terms[i] == term {
+		idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+	}
+}
+
+// Tokenize lower-cases the text and splits it into letter and digit runs.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// allowedTypos returns the edit distance tolerated for a query token:
+// none for short tokens, one from four characters and two from eight.
+func allowedTypos(token string) int {
+	switch n := len([]rune(token)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	}
+	return 0
+}
+
+// withinDistance reports whether the Levenshtein distance between a and b is at most max.
+func withinDistance(a, b string, max int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return false
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return false
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)] <= max
+}
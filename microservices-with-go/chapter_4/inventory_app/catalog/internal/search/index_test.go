@@ -0,0 +1,45 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"inventory.com/catalog/pkg/model"
+)
+
+func newTestIndex() *Index {
+	idx := NewIndex()
+	idx.Put(1, Field{Text: "Samsung Television 55", Weight: 3}, Field{Text: "Electronics", Weight: 1.5})
+	idx.Put(2, Field{Text: "Sony Headphones", Weight: 3}, Field{Text: "Great sound for television audio", Weight: 1})
+	idx.Put(3, Field{Text: "Apple Laptop", Weight: 3}, Field{Text: "Electronics", Weight: 1.5})
+	return idx
+}
+
+func ids(hits []Hit) []model.ProductID {
+	var result []model.ProductID
+	for _, h := range hits {
+		result = append(result, h.ID)
+	}
+	return result
+}
+
+func TestIndex_SearchRanksNameAboveDescription(t *testing.T) {
+	assert.Equal(t, []model.ProductID{1, 2}, ids(newTestIndex().Search("television", 0)))
+}
+
+func TestIndex_SearchPrefixAndTypos(t *testing.T) {
+	idx := newTestIndex()
+	assert.Equal(t, []model.ProductID{3}, ids(idx.Search("lap", 0)))
+	assert.Equal(t, []model.ProductID{2}, ids(idx.Search("headphnes", 0)))
+	assert.Equal(t, []model.ProductID{3}, ids(idx.Search("electronics apple", 0)))
+}
+
+func TestIndex_RemoveAndReplace(t *testing.T) {
+	idx := newTestIndex()
+	idx.Remove(3)
+	assert.Empty(t, idx.Search("laptop", 0))
+
+	idx.Put(1, Field{Text: "LG Monitor", Weight: 3})
+	assert.Empty(t, idx.Search("samsung", 0))
+	assert.Equal(t, []model.ProductID{1}, ids(idx.Search("monitor", 0)))
+}
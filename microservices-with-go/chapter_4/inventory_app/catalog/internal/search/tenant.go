@@ -0,0 +1,44 @@
+package search
+
+import (
+	"context"
+
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+// TenantIndex keeps a separate Index per tenant and picks the one of the
+// tenant the context is bound to, so searches never return products of
+// another tenant.
+type TenantIndex struct {
+	indexes *tenant.Partitions[Index]
+}
+
+// NewTenantIndex returns a TenantIndex without any indexed product.
+func NewTenantIndex() *TenantIndex {
+	return &TenantIndex{indexes: tenant.NewPartitions(NewIndex)}
+}
+
+// Put indexes a product of the tenant of ctx. Without a tenant nothing is indexed.
+func (t *TenantIndex) Put(ctx context.Context, id model.ProductID, fields ...Field) {
+	if index, err := t.indexes.For(ctx); err == nil {
+		index.Put(id, fields...)
+	}
+}
+
+// Remove deletes a product of the tenant of ctx from the index.
+func (t *TenantIndex) Remove(ctx context.Context, id model.ProductID) {
+	if index, err := t.indexes.For(ctx); err == nil {
+		index.Remove(id)
+	}
+}
+
+// Search searches the products of the tenant of ctx. Without a tenant
+// nothing is found.
+func (t *TenantIndex) Search(ctx context.Context, query string, limit int) []Hit {
+	index, err := t.indexes.For(ctx)
+	if err != nil {
+		return nil
+	}
+	return index.Search(query, limit)
+}
@@ -0,0 +1,246 @@
+// Package transfer encodes and decodes catalog records as CSV or JSON Lines
+// (NDJSON) for bulk import and export.
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/money"
+)
+
+// ErrUnsupportedFormat is returned for formats other than csv and ndjson.
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// Format is a bulk transfer file format.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// ParseFormat parses a format name, also accepting the matching MIME types.
+func ParseFormat(value string) (Format, error) {
+	mediaType, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(value)), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "csv", "text/csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl", "application/x-ndjson", "application/jsonl":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, value)
+}
+
+// csvColumns is the column layout written on export. On import columns are
+// matched by header name, so they may appear in any order and optional ones may be omitted.
+var csvColumns = []string{"type", "id", "name", "parent", "description", "manufacturer", "listCost", "currency", "attributes", "schema"}
+
+// Decode reads all records from r. Rows that cannot be decoded are reported
+// as row errors instead of aborting the whole import.
+func Decode(r io.Reader, format Format) ([]model.ImportRow, []model.RowError, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	}
+	return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+func decodeNDJSON(r io.Reader) ([]model.ImportRow, []model.RowError, error) {
+	var rows []model.ImportRow
+	var rowErrors []model.RowError
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var record model.CatalogRecord
+		if err := json.Unmarshal(text, &record); err != nil {
+			rowErrors = append(rowErrors, model.RowError{Row: line, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, model.ImportRow{Row: line, Record: record})
+	}
+	return rows, rowErrors, scanner.Err()
+}
+
+func decodeCSV(r io.Reader) ([]model.ImportRow, []model.RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"type", "name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("csv header is missing the %q column", required)
+		}
+	}
+
+	// Rows are numbered by the line they start on, header included, like
+	// the lines of JSON Lines input.
+	var rows []model.ImportRow
+	var rowErrors []model.RowError
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line := 0
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.StartLine
+			}
+			rowErrors = append(rowErrors, model.RowError{Row: line, Error: err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		record, err := csvRecord(columns, fields)
+		if err != nil {
+			rowErrors = append(rowErrors, model.RowError{Row: line, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, model.ImportRow{Row: line, Record: record})
+	}
+	return rows, rowErrors, nil
+}
+
+func csvRecord(columns map[string]int, fields []string) (model.CatalogRecord, error) {
+	get := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	record := model.CatalogRecord{
+		Type:         model.RecordType(strings.ToLower(get("type"))),
+		Name:         get("name"),
+		Parent:       get("parent"),
+		Description:  get("description"),
+		Manufacturer: get("manufacturer"),
+	}
+	if id := get("id"); id != "" {
+		parsed, err := strconv.Atoi(id)
+		if err != nil {
+			return record, fmt.Errorf("invalid id %q", id)
+		}
+		record.ID = parsed
+	}
+	if cost := get("listCost"); cost != "" {
+		parsed, err := money.Parse(cost, money.Currency(strings.ToUpper(get("currency"))))
+		if err != nil {
+			return record, err
+		}
+		record.ListCost = &parsed
+	}
+	if attrs := get("attributes"); attrs != "" {
+		if err := json.Unmarshal([]byte(attrs), &record.Attributes); err != nil {
+			return record, fmt.Errorf("invalid attributes: %w", err)
+		}
+	}
+	if schema := get("schema"); schema != "" {
+		if err := json.Unmarshal([]byte(schema), &record.Schema); err != nil {
+			return record, fmt.Errorf("invalid schema: %w", err)
+		}
+	}
+	return record, nil
+}
+
+// Encoder writes catalog records in one of the supported formats.
+type Encoder interface {
+	Encode(record model.CatalogRecord) error
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+// NewEncoder returns an encoder writing the given format to w.
+func NewEncoder(w io.Writer, format Format) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(record model.CatalogRecord) error {
+	return e.enc.Encode(record)
+}
+
+func (e *ndjsonEncoder) Flush() error { return nil }
+
+type csvEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvEncoder) Encode(record model.CatalogRecord) error {
+	if !e.headerWritten {
+		if err := e.w.Write(csvColumns); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	var cost, currency, attrs, schema string
+	if record.ListCost != nil {
+		cost, currency = record.ListCost.Decimal(), string(record.ListCost.Currency)
+	}
+	if len(record.Attributes) > 0 {
+		data, err := json.Marshal(record.Attributes)
+		if err != nil {
+			return err
+		}
+		attrs = string(data)
+	}
+	if len(record.Schema) > 0 {
+		data, err := json.Marshal(record.Schema)
+		if err != nil {
+			return err
+		}
+		schema = string(data)
+	}
+	return e.w.Write([]string{
+		string(record.Type), strconv.Itoa(record.ID), record.Name, record.Parent,
+		record.Description, record.Manufacturer, cost, currency, attrs, schema,
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,63 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/money"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	cost := money.Money{Amount: 89999, Currency: "USD"}
+	records := []model.CatalogRecord{
+		{Type: model.RecordTypeCategory, ID: 1, Name: "Electronics", Schema: []model.AttributeDefinition{{Name: "screenSize", Type: model.AttributeTypeNumber}}},
+		{Type: model.RecordTypeProduct, ID: 2, Name: "Bravia, 55\"", Parent: "Television", ListCost: &cost, Attributes: model.AttributeValues{"screenSize": 55.0}},
+	}
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, FormatCSV)
+	assert.NoError(t, err)
+	for _, r := range records {
+		assert.NoError(t, enc.Encode(r))
+	}
+	assert.NoError(t, enc.Flush())
+
+	rows, rowErrors, err := Decode(&buf, FormatCSV)
+	assert.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, records[0], rows[0].Record)
+	assert.Equal(t, records[1], rows[1].Record)
+}
+
+func TestDecodeNDJSON_ReportsBadRows(t *testing.T) {
+	input := `{"type":"category","name":"Books"}
+
+not json
+{"type":"subcategory","name":"Fiction","parent":"Books"}`
+
+	rows, rowErrors, err := Decode(strings.NewReader(input), FormatNDJSON)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, 4, rows[1].Row)
+	assert.Equal(t, []int{3}, []int{rowErrors[0].Row})
+}
+
+func TestDecodeCSV_NumbersRowsByLine(t *testing.T) {
+	input := "type,name,parent\n" +
+		"category,Books,\n" +
+		"\n" +
+		"subcategory,\"Fiction\nand more\",Books\n" +
+		"subcategory,Poetry \"x\",Books\n" +
+		"product,Novel,Fiction\n"
+
+	rows, rowErrors, err := Decode(strings.NewReader(input), FormatCSV)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []int{2, 4, 7}, []int{rows[0].Row, rows[1].Row, rows[2].Row})
+	assert.Len(t, rowErrors, 1)
+	assert.Equal(t, 6, rowErrors[0].Row)
+}
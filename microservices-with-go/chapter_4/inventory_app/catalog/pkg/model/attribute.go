@@ -0,0 +1,234 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidAttribute is returned when a product attribute does not match
+	// the schema declared by its category.
+	ErrInvalidAttribute = errors.New("invalid attribute")
+	// ErrInvalidAttributeFilter is returned when an attribute filter cannot be parsed.
+	ErrInvalidAttributeFilter = errors.New("invalid attribute filter")
+)
+
+// AttributeType defines the kind of value an attribute holds.
+type AttributeType string
+
+const (
+	AttributeTypeString AttributeType = "string"
+	AttributeTypeNumber AttributeType = "number"
+	AttributeTypeBool   AttributeType = "bool"
+	AttributeTypeEnum   AttributeType = "enum"
+)
+
+// AttributeDefinition describes a single custom attribute declared by a category.
+type AttributeDefinition struct {
+	Name     string        `json:"name"`
+	Type     AttributeType `json:"type"`
+	Required bool          `json:"required"`
+	Unit     string        `json:"unit,omitempty"`
+	Values   []string      `json:"values,omitempty"` // Allowed values when Type is enum
+}
+
+// AttributeValues holds the custom attribute values of a product, keyed by attribute name.
+type AttributeValues map[string]any
+
+// ValidateSchema checks that the attribute definitions of a category are well formed.
+func ValidateSchema(defs []AttributeDefinition) error {
+	seen := make(map[string]struct{}, len(defs))
+	for _, def := range defs {
+		if def.Name == "" {
+			return fmt.Errorf("%w: attribute name is required", ErrInvalidAttribute)
+		}
+		if _, ok := seen[def.Name]; ok {
+			return fmt.Errorf("%w: duplicate attribute %q", ErrInvalidAttribute, def.Name)
+		}
+		seen[def.Name] = struct{}{}
+
+		switch def.Type {
+		case AttributeTypeString, AttributeTypeNumber, AttributeTypeBool:
+		case AttributeTypeEnum:
+			if len(def.Values) == 0 {
+				return fmt.Errorf("%w: enum attribute %q has no values", ErrInvalidAttribute, def.Name)
+			}
+		default:
+			return fmt.Errorf("%w: attribute %q has unknown type %q", ErrInvalidAttribute, def.Name, def.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateAttributes checks the given values against the attribute schema.
+// Unknown attributes, missing required attributes and type mismatches are rejected.
+func ValidateAttributes(defs []AttributeDefinition, values AttributeValues) error {
+	byName := make(map[string]AttributeDefinition, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+
+	for name := range values {
+		if _, ok := byName[name]; !ok {
+			return fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttribute, name)
+		}
+	}
+
+	for _, def := range defs {
+		value, ok := values[def.Name]
+		if !ok || value == nil {
+			if def.Required {
+				return fmt.Errorf("%w: attribute %q is required", ErrInvalidAttribute, def.Name)
+			}
+			continue
+		}
+		if err := def.check(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check verifies that a single value matches the attribute definition.
+func (def AttributeDefinition) check(value any) error {
+	switch def.Type {
+	case AttributeTypeString:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+	case AttributeTypeNumber:
+		if _, ok := toNumber(value); ok {
+			return nil
+		}
+	case AttributeTypeBool:
+		if _, ok := value.(bool); ok {
+			return nil
+		}
+	case AttributeTypeEnum:
+		if s, ok := value.(string); ok {
+			for _, allowed := range def.Values {
+				if s == allowed {
+					return nil
+				}
+			}
+			return fmt.Errorf("%w: attribute %q must be one of %v", ErrInvalidAttribute, def.Name, def.Values)
+		}
+	}
+	return fmt.Errorf("%w: attribute %q must be of type %s", ErrInvalidAttribute, def.Name, def.Type)
+}
+
+// FilterOperator defines a comparison used by attribute filters.
+type FilterOperator string
+
+const (
+	FilterOpEqual          FilterOperator = "="
+	FilterOpNotEqual       FilterOperator = "!="
+	FilterOpGreater        FilterOperator = ">"
+	FilterOpGreaterOrEqual FilterOperator = ">="
+	FilterOpLess           FilterOperator = "<"
+	FilterOpLessOrEqual    FilterOperator = "<="
+)
+
+// filterOperators is ordered so that two-character operators are matched first.
+var filterOperators = []FilterOperator{
+	FilterOpGreaterOrEqual, FilterOpLessOrEqual, FilterOpNotEqual,
+	FilterOpEqual, FilterOpGreater, FilterOpLess,
+}
+
+// AttributeFilter is a single condition on a product attribute, e.g. screenSize>=50.
+type AttributeFilter struct {
+	Name  string
+	Op    FilterOperator
+	Value string
+}
+
+// ParseAttributeFilter parses an expression such as "screenSize>=50".
+func ParseAttributeFilter(expr string) (AttributeFilter, error) {
+	for i := 0; i < len(expr); i++ {
+		for _, op := range filterOperators {
+			if strings.HasPrefix(expr[i:], string(op)) {
+				f := AttributeFilter{
+					Name:  strings.TrimSpace(expr[:i]),
+					Op:    op,
+					Value: strings.TrimSpace(expr[i+len(op):]),
+				}
+				if f.Name == "" {
+					return AttributeFilter{}, fmt.Errorf("%w: %q", ErrInvalidAttributeFilter, expr)
+				}
+				return f, nil
+			}
+		}
+	}
+	return AttributeFilter{}, fmt.Errorf("%w: %q", ErrInvalidAttributeFilter, expr)
+}
+
+// Match reports whether the attribute values satisfy the filter.
+// Products without the attribute never match.
+func (f AttributeFilter) Match(values AttributeValues) bool {
+	value, ok := values[f.Name]
+	if !ok || value == nil {
+		return false
+	}
+
+	if n, ok := toNumber(value); ok {
+		want, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return false
+		}
+		return compare(n, want, f.Op)
+	}
+
+	var got string
+	switch v := value.(type) {
+	case string:
+		got = v
+	case bool:
+		got = strconv.FormatBool(v)
+	default:
+		got = fmt.Sprint(v)
+	}
+	switch f.Op {
+	case FilterOpEqual:
+		return got == f.Value
+	case FilterOpNotEqual:
+		return got != f.Value
+	}
+	return compare(float64(strings.Compare(got, f.Value)), 0, f.Op)
+}
+
+func compare(got, want float64, op FilterOperator) bool {
+	switch op {
+	case FilterOpEqual:
+		return got == want
+	case FilterOpNotEqual:
+		return got != want
+	case FilterOpGreater:
+		return got > want
+	case FilterOpGreaterOrEqual:
+		return got >= want
+	case FilterOpLess:
+		return got < want
+	case FilterOpLessOrEqual:
+		return got <= want
+	}
+	return false
+}
+
+// toNumber converts the numeric types produced by JSON decoding or Go callers to float64.
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	return 0, false
+}
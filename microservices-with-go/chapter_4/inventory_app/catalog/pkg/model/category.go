@@ -1,10 +1,21 @@
 package model
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrCategoryNotFound is returned when a category does not exist or is deleted.
+var ErrCategoryNotFound = errors.New("category not found")
+
 // CategoryID defines the unique identifier for a category.
 type CategoryID int
 
 // Category represents a product category.
 type Category struct {
-	ID   CategoryID `json:"id"`
-	Name string     `json:"name"`
+	ID         CategoryID            `json:"id"`
+	Name       string                `json:"name"`
+	Version    int                   `json:"version"`              // Incremented on every update, exposed as the ETag
+	Attributes []AttributeDefinition `json:"attributes,omitempty"` // Schema for the custom attributes of products in this category
+	DeletedAt  *time.Time            `json:"deletedAt,omitempty"`  // Set when the category is soft deleted
 }
@@ -0,0 +1,20 @@
+package model
+
+// Domain event types published by the catalog service. The payload of an
+// event is the state of the entity after the change.
+const (
+	EventCategoryCreated  = "CategoryCreated"
+	EventCategoryUpdated  = "CategoryUpdated"
+	EventCategoryDeleted  = "CategoryDeleted"
+	EventCategoryRestored = "CategoryRestored"
+
+	EventSubCategoryCreated  = "SubCategoryCreated"
+	EventSubCategoryUpdated  = "SubCategoryUpdated"
+	EventSubCategoryDeleted  = "SubCategoryDeleted"
+	EventSubCategoryRestored = "SubCategoryRestored"
+
+	EventProductCreated  = "ProductCreated"
+	EventProductUpdated  = "ProductUpdated"
+	EventProductDeleted  = "ProductDeleted"
+	EventProductRestored = "ProductRestored"
+)
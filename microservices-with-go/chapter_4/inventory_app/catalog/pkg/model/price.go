@@ -0,0 +1,33 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"inventory.com/pkg/money"
+)
+
+// PriceChange is a single entry in the price history of a product.
+// The price applies from EffectiveFrom until EffectiveTo; a nil EffectiveTo
+// means the price is open ended. Entries with EffectiveFrom in the future are
+// scheduled changes that take effect automatically.
+type PriceChange struct {
+	ProductID     ProductID   `json:"productID"`
+	Price         money.Money `json:"price"`
+	EffectiveFrom time.Time   `json:"effectiveFrom"`
+	EffectiveTo   *time.Time  `json:"effectiveTo,omitempty"`
+	ChangedBy     string      `json:"changedBy"`
+	ChangedAt     time.Time   `json:"changedAt"`
+}
+
+// InEffect reports whether the price applies at the given instant.
+func (p *PriceChange) InEffect(at time.Time) bool {
+	return !at.Before(p.EffectiveFrom) && (p.EffectiveTo == nil || at.Before(*p.EffectiveTo))
+}
+
+var (
+	// ErrInvalidPriceChange is returned when a scheduled price change is rejected.
+	ErrInvalidPriceChange = errors.New("invalid price change")
+	// ErrPriceNotFound is returned when a product has no price at the requested time.
+	ErrPriceNotFound = errors.New("price not found")
+)
@@ -1,15 +1,33 @@
 package model
 
+import (
+	"errors"
+	"time"
+
+	"inventory.com/pkg/money"
+)
+
+var (
+	// ErrProductNotFound is returned when a product does not exist or is deleted.
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInvalidSubCategory is returned when a product refers to a sub-category
+	// that does not exist or is deleted.
+	ErrInvalidSubCategory = errors.New("invalid sub-category")
+)
+
 // ProductID defines the unique identifier for a product.
 type ProductID int
 
 // ProductBaseInfo contains the common fields for product structures.
 type ProductBaseInfo struct {
-	ID           ProductID `json:"id"`
-	Name         string    `json:"name"`
-	Description  string    `json:"description"`
-	Manufacturer string    `json:"manufacturer"`
-	ListCost     int       `json:"listCost"`
+	ID           ProductID       `json:"id"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Manufacturer string          `json:"manufacturer"`
+	ListCost     money.Money     `json:"listCost"`
+	Attributes   AttributeValues `json:"attributes,omitempty"` // Validated against the category attribute schema
+	Version      int             `json:"version"`              // Incremented on every update, exposed as the ETag
+	DeletedAt    *time.Time      `json:"deletedAt,omitempty"`  // Set when the product is soft deleted
 }
 
 // ProductBasic represents the minimal product data required for
@@ -25,3 +43,9 @@ type ProductInformation struct {
 	ProductBaseInfo
 	SubCategoryDetails *SubCategoryDetails `json:"subCategory"` // Updated to include full detail, not just ID
 }
+
+// ProductSearchResult is a product returned by full-text search with its relevance score.
+type ProductSearchResult struct {
+	*ProductInformation
+	Score float64 `json:"score"`
+}
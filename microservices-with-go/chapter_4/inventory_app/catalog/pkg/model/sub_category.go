@@ -1,12 +1,22 @@
 package model
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrSubCategoryNotFound is returned when a sub-category does not exist or is deleted.
+var ErrSubCategoryNotFound = errors.New("sub-category not found")
+
 // SubCategoryID defines the unique identifier for a sub-category.
 type SubCategoryID int
 
 // SubCategoryBaseInfo contains the common fields for sub-category structures.
 type SubCategoryBaseInfo struct {
-	ID   SubCategoryID `json:"id"`
-	Name string        `json:"name"`
+	ID        SubCategoryID `json:"id"`
+	Name      string        `json:"name"`
+	Version   int           `json:"version"`             // Incremented on every update, exposed as the ETag
+	DeletedAt *time.Time    `json:"deletedAt,omitempty"` // Set when the sub-category is soft deleted
 }
 
 // SubCategoryBasic represents the basic sub-category information used for
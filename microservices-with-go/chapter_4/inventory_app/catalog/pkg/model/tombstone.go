@@ -0,0 +1,10 @@
+package model
+
+import "errors"
+
+var (
+	// ErrNotDeleted is returned when restoring an entity that has not been deleted.
+	ErrNotDeleted = errors.New("entity is not deleted")
+	// ErrParentDeleted is returned when restoring an entity whose parent is deleted.
+	ErrParentDeleted = errors.New("parent entity is deleted")
+)
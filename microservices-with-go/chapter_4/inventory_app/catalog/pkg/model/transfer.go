@@ -0,0 +1,49 @@
+package model
+
+import "inventory.com/pkg/money"
+
+// RecordType identifies the kind of entity held by a CatalogRecord.
+type RecordType string
+
+const (
+	RecordTypeCategory    RecordType = "category"
+	RecordTypeSubCategory RecordType = "subcategory"
+	RecordTypeProduct     RecordType = "product"
+)
+
+// CatalogRecord is the flat representation of a catalog entity used by bulk
+// import and export. Parent refers to the category of a sub-category or the
+// sub-category of a product, either by ID or by name.
+type CatalogRecord struct {
+	Type         RecordType            `json:"type"`
+	ID           int                   `json:"id,omitempty"` // Informational; new IDs are assigned on import
+	Name         string                `json:"name"`
+	Parent       string                `json:"parent,omitempty"`
+	Description  string                `json:"description,omitempty"`
+	Manufacturer string                `json:"manufacturer,omitempty"`
+	ListCost     *money.Money          `json:"listCost,omitempty"`
+	Schema       []AttributeDefinition `json:"schema,omitempty"`     // Category attribute schema
+	Attributes   AttributeValues       `json:"attributes,omitempty"` // Product attribute values
+}
+
+// RowError describes why a single import row was rejected. Rows are numbered
+// by their line in the source file, starting from 1 with the CSV header.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarises the outcome of a bulk import.
+type ImportReport struct {
+	DryRun  bool       `json:"dryRun"`
+	Total   int        `json:"total"`
+	Created int        `json:"created"` // In dry-run mode, the number of rows that would be created
+	Failed  int        `json:"failed"`
+	Errors  []RowError `json:"errors"`
+}
+
+// ImportRow is a decoded import record together with its row number in the source file.
+type ImportRow struct {
+	Row    int
+	Record CatalogRecord
+}
@@ -0,0 +1,10 @@
+package model
+
+import "errors"
+
+// ErrVersionConflict is returned when an update or delete is conditioned on a
+// version of an entity that is no longer the current one.
+var ErrVersionConflict = errors.New("version conflict")
+
+// AnyVersion disables the optimistic concurrency check of an update or delete.
+const AnyVersion = 0
@@ -0,0 +1,126 @@
+// Command authtoken issues tokens and API keys for local development:
+//
+//	authtoken -secret-file secret.txt -sub alice -roles catalog-editor -ttl 1h
+//	authtoken -private-key-file key.pem -sub alice -roles admin
+//	authtoken -api-key -sub reporting -roles viewer
+//
+// A new API key is printed together with the entry to add to the API keys file.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"inventory.com/pkg/auth"
+)
+
+func main() {
+	secretFile := flag.String("secret-file", "", "sign an HS256 token with the secret in this file")
+	privateKeyFile := flag.String("private-key-file", "", "sign an RS256 token with the PEM private key in this file")
+	apiKey := flag.Bool("api-key", false, "generate an API key instead of a token")
+	subject := flag.String("sub", "", "subject of the token or API key")
+	roles := flag.String("roles", auth.RoleViewer, "comma separated roles")
+	tenantID := flag.String("tenant", "", "restrict the token or API key to this tenant")
+	issuer := flag.String("iss", "", "issuer claim")
+	audience := flag.String("aud", "", "audience claim")
+	ttl := flag.Duration("ttl", time.Hour, "lifetime of the token")
+	flag.Parse()
+
+	if *subject == "" {
+		log.Fatal("-sub is required")
+	}
+	roleList := strings.Split(*roles, ",")
+
+	if *apiKey {
+		key, entry, err := newAPIKey(*subject, roleList, *tenantID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(key)
+		fmt.Println(entry)
+		return
+	}
+
+	now := time.Now()
+	claims := auth.Claims{
+		Subject:   *subject,
+		Roles:     roleList,
+		Tenant:    *tenantID,
+		Issuer:    *issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(*ttl).Unix(),
+	}
+	if *audience != "" {
+		claims.Audience = auth.Audience{*audience}
+	}
+	token, err := sign(claims, *secretFile, *privateKeyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(token)
+}
+
+func sign(claims auth.Claims, secretFile, privateKeyFile string) (string, error) {
+	switch {
+	case secretFile != "":
+		secret, err := os.ReadFile(secretFile)
+		if err != nil {
+			return "", err
+		}
+		return auth.SignHS256(claims, []byte(strings.TrimSpace(string(secret))))
+	case privateKeyFile != "":
+		key, err := readPrivateKey(privateKeyFile)
+		if err != nil {
+			return "", err
+		}
+		return auth.SignRS256(claims, key)
+	}
+	return "", errors.New("one of -secret-file, -private-key-file or -api-key is required")
+}
+
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", path)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key in %s is not an RSA key", path)
+	}
+	return rsaKey, nil
+}
+
+func newAPIKey(subject string, roles []string, tenantID string) (string, string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	key := hex.EncodeToString(raw)
+	entry, err := json.Marshal(auth.APIKey{SHA256: auth.HashAPIKey(key), Subject: subject, Roles: roles, Tenant: tenantID})
+	if err != nil {
+		return "", "", err
+	}
+	return key, string(entry), nil
+}
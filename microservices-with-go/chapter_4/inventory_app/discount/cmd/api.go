@@ -1 +1,67 @@
-package cmd
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/discount/internal/controller"
+	"inventory.com/discount/internal/handler"
+	"inventory.com/discount/internal/repository/memory"
+	"inventory.com/pkg/discovery"
+	"inventory.com/pkg/discovery/registry"
+	"inventory.com/pkg/health"
+	"inventory.com/pkg/lifecycle"
+	"inventory.com/pkg/logging"
+	"inventory.com/pkg/requestid"
+)
+
+const (
+	// heartbeatInterval is how often a ready instance reports to the service registry.
+	heartbeatInterval = 2 * time.Second
+	// healthCheckTimeout bounds each readiness check.
+	healthCheckTimeout = time.Second
+)
+
+// advertiseAddr is the address the discount service registers under in the service registry.
+var advertiseAddr = envOr("DISCOUNT_ADVERTISE_ADDR", "localhost:8084")
+
+func main() {
+	if err := logging.Init(logging.ConfigFromEnv("discount")); err != nil {
+		logging.Fatal("failed to set up logging", "error", err)
+	}
+	serviceRegistry, err := registry.FromEnv()
+	if err != nil {
+		logging.Fatal("failed to set up the service registry", "error", err)
+	}
+	repo := memory.New()
+	ctrl := controller.NewDiscountController(repo)
+
+	readiness := health.New(healthCheckTimeout)
+	readiness.Add("discounts", health.CheckerFunc(repo.Ping))
+
+	gin.SetMode(gin.DebugMode)
+	engine := gin.New()
+	engine.Use(requestid.Middleware(), logging.Middleware())
+
+	handler.RegisterDiscountRoutes(engine, ctrl)
+	health.InitHandler(engine, readiness)
+
+	service := lifecycle.New(lifecycle.DefaultConfig(":8084"), engine)
+	readiness.Add("lifecycle", service)
+	if serviceRegistry != nil {
+		instance := health.Instance{ID: discovery.GenerateInstanceID("discount"), Service: "discount", HostPort: advertiseAddr}
+		service.Go(func(ctx context.Context) { readiness.Heartbeat(ctx, serviceRegistry, instance, heartbeatInterval) })
+	}
+	if err := service.Run(context.Background()); err != nil {
+		logging.Fatal("server stopped", "error", err)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -1 +1,103 @@
 package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	model "inventory.com/discount/pkg"
+	"inventory.com/pkg/money"
+)
+
+type IDiscountRepository interface {
+	Create(ctx context.Context, data *model.Discount) (*model.Discount, error)
+	GetAll(ctx context.Context) ([]*model.Discount, error)
+	GetByCode(ctx context.Context, code string) (*model.Discount, error)
+	Redeem(ctx context.Context, code string, data *model.Redemption) (*model.Redemption, error)
+	Void(ctx context.Context, id model.RedemptionID) (*model.Redemption, error)
+	VoidByReference(ctx context.Context, code, reference string) (*model.Redemption, error)
+}
+
+type DiscountController struct {
+	repo IDiscountRepository
+}
+
+// NewDiscountController creates a new instance of DiscountController with the provided repository.
+func NewDiscountController(repo IDiscountRepository) *DiscountController {
+	return &DiscountController{repo: repo}
+}
+
+// Create validates and stores a new discount.
+func (c *DiscountController) Create(ctx context.Context, data *model.Discount) (*model.Discount, error) {
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+	return c.repo.Create(ctx, data)
+}
+
+// GetAll returns all discounts.
+func (c *DiscountController) GetAll(ctx context.Context) ([]*model.Discount, error) {
+	return c.repo.GetAll(ctx)
+}
+
+// ForProduct returns the discounts of a product that have redemptions left.
+func (c *DiscountController) ForProduct(ctx context.Context, productID catalogModel.ProductID) ([]*model.Discount, error) {
+	all, err := c.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrDiscountNotFound) {
+		return []*model.Discount{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	usable := []*model.Discount{}
+	for _, d := range all {
+		if d.ProductID == productID && (d.MaxUses == 0 || d.Uses < d.MaxUses) {
+			usable = append(usable, d)
+		}
+	}
+	return usable, nil
+}
+
+// Get returns the discount with the given code.
+func (c *DiscountController) Get(ctx context.Context, code string) (*model.Discount, error) {
+	return c.repo.GetByCode(ctx, code)
+}
+
+// Redeem uses a discount code for one unit price of a product and returns the
+// redemption carrying the discounted price. Redeeming again with the same
+// reference returns the existing redemption.
+func (c *DiscountController) Redeem(ctx context.Context, code string, productID catalogModel.ProductID, unitPrice money.Money, reference string) (*model.Redemption, error) {
+	if reference == "" {
+		return nil, errors.New("reference is required")
+	}
+	discount, err := c.repo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if discount.ProductID != productID {
+		return nil, fmt.Errorf("%w: code=%s, productID=%d", model.ErrProductMismatch, code, productID)
+	}
+	discounted, err := discount.Apply(unitPrice)
+	if err != nil {
+		return nil, err
+	}
+	return c.repo.Redeem(ctx, code, &model.Redemption{
+		ProductID:       productID,
+		Reference:       reference,
+		UnitPrice:       unitPrice,
+		DiscountedPrice: discounted,
+	})
+}
+
+// Void gives back the use of a redemption, e.g. when the sale it was made for failed.
+func (c *DiscountController) Void(ctx context.Context, id model.RedemptionID) (*model.Redemption, error) {
+	return c.repo.Void(ctx, id)
+}
+
+// VoidByReference gives back the use of the discount redeemed under the
+// caller's reference. Unlike Void it needs no redemption ID, so a caller that
+// lost the response of Redeem can still compensate it.
+func (c *DiscountController) VoidByReference(ctx context.Context, code, reference string) (*model.Redemption, error) {
+	return c.repo.VoidByReference(ctx, code, reference)
+}
@@ -1 +1,153 @@
 package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	catalogModel "inventory.com/catalog/pkg/model"
+	model "inventory.com/discount/pkg"
+	"inventory.com/pkg/money"
+)
+
+type IDiscountController interface {
+	Create(ctx context.Context, data *model.Discount) (*model.Discount, error)
+	GetAll(ctx context.Context) ([]*model.Discount, error)
+	ForProduct(ctx context.Context, productID catalogModel.ProductID) ([]*model.Discount, error)
+	Get(ctx context.Context, code string) (*model.Discount, error)
+	Redeem(ctx context.Context, code string, productID catalogModel.ProductID, unitPrice money.Money, reference string) (*model.Redemption, error)
+	Void(ctx context.Context, id model.RedemptionID) (*model.Redemption, error)
+	VoidByReference(ctx context.Context, code, reference string) (*model.Redemption, error)
+}
+
+// RedeemRequest is the body of POST /discounts/:code/redemptions.
+type RedeemRequest struct {
+	ProductID catalogModel.ProductID `json:"productID"`
+	UnitPrice money.Money            `json:"unitPrice"`
+	Reference string                 `json:"reference"` // Idempotency key chosen by the caller
+}
+
+type discountHandler struct {
+	ctrl IDiscountController
+}
+
+// Create handles POST /discounts.
+func (h *discountHandler) Create(ctx *gin.Context) {
+	var data model.Discount
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount data"})
+		return
+	}
+	created, err := h.ctrl.Create(ctx.Request.Context(), &data)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// GetAll handles GET /discounts. With ?productID= only the discounts of that
+// product that have redemptions left are returned.
+func (h *discountHandler) GetAll(ctx *gin.Context) {
+	if raw := ctx.Query("productID"); raw != "" {
+		productID, err := strconv.Atoi(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		discounts, err := h.ctrl.ForProduct(ctx.Request.Context(), catalogModel.ProductID(productID))
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, discounts)
+		return
+	}
+
+	discounts, err := h.ctrl.GetAll(ctx.Request.Context())
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, discounts)
+}
+
+// Get handles GET /discounts/:code.
+func (h *discountHandler) Get(ctx *gin.Context) {
+	discount, err := h.ctrl.Get(ctx.Request.Context(), ctx.Param("code"))
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, discount)
+}
+
+// Redeem handles POST /discounts/:code/redemptions.
+func (h *discountHandler) Redeem(ctx *gin.Context) {
+	var req RedeemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redemption data"})
+		return
+	}
+	redemption, err := h.ctrl.Redeem(ctx.Request.Context(), ctx.Param("code"), req.ProductID, req.UnitPrice, req.Reference)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, redemption)
+}
+
+// Void handles DELETE /redemptions/:id.
+func (h *discountHandler) Void(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redemption ID"})
+		return
+	}
+	redemption, err := h.ctrl.Void(ctx.Request.Context(), model.RedemptionID(id))
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, redemption)
+}
+
+// VoidByReference handles DELETE /discounts/:code/redemptions/:reference.
+func (h *discountHandler) VoidByReference(ctx *gin.Context) {
+	redemption, err := h.ctrl.VoidByReference(ctx.Request.Context(), ctx.Param("code"), ctx.Param("reference"))
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, redemption)
+}
+
+func handleError(ctx *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, model.ErrDiscountNotFound), errors.Is(err, model.ErrRedemptionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, model.ErrDuplicateCode), errors.Is(err, model.ErrDiscountExhausted):
+		status = http.StatusConflict
+	case errors.Is(err, model.ErrInvalidDiscount), errors.Is(err, model.ErrProductMismatch), errors.Is(err, money.ErrInvalid):
+		status = http.StatusBadRequest
+	}
+	ctx.JSON(status, gin.H{"error": err.Error()})
+}
+
+// RegisterDiscountRoutes registers the discount and redemption routes.
+func RegisterDiscountRoutes(router *gin.Engine, ctrl IDiscountController) {
+	h := &discountHandler{ctrl: ctrl}
+
+	discounts := router.Group("/discounts")
+	{
+		discounts.POST("", h.Create)
+		discounts.GET("", h.GetAll)
+		discounts.GET("/:code", h.Get)
+		discounts.POST("/:code/redemptions", h.Redeem)
+		discounts.DELETE("/:code/redemptions/:reference", h.VoidByReference)
+	}
+	router.DELETE("/redemptions/:id", h.Void)
+}
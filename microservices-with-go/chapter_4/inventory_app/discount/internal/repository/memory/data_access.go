@@ -1 +1,195 @@
 package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	model "inventory.com/discount/pkg"
+)
+
+// Discount represents an in-memory repository for discounts and their redemptions.
+type Discount struct {
+	mu              sync.RWMutex
+	data            []*model.Discount
+	redemptions     []*model.Redemption
+	seqID           int
+	redemptionSeqID int
+}
+
+// New returns a new in-memory Discount repository.
+func New() *Discount {
+	return &Discount{
+		data:        make([]*model.Discount, 0),
+		redemptions: make([]*model.Redemption, 0),
+	}
+}
+
+// Ping checks that the repository answers; a repository stuck holding its
+// lock fails a timed check.
+func (repo *Discount) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
+// Create adds a new discount. Returns model.ErrDuplicateCode if the code is taken.
+func (repo *Discount) Create(ctx context.Context, data *model.Discount) (*model.Discount, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.findByCode(data.Code) != nil {
+		return nil, fmt.Errorf("%w: code=%s", model.ErrDuplicateCode, data.Code)
+	}
+	repo.seqID++
+	data.ID = model.DiscountID(repo.seqID)
+	data.Uses = 0
+	repo.data = append(repo.data, copyDiscount(data))
+	return data, nil
+}
+
+// GetAll returns copies of all discounts, so their use counts cannot be
+// changed from outside the repository. Returns model.ErrDiscountNotFound if no discounts exist.
+func (repo *Discount) GetAll(ctx context.Context) ([]*model.Discount, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if len(repo.data) == 0 {
+		return nil, model.ErrDiscountNotFound
+	}
+	all := make([]*model.Discount, len(repo.data))
+	for i, d := range repo.data {
+		all[i] = copyDiscount(d)
+	}
+	return all, nil
+}
+
+// GetByCode returns a copy of the discount with the given code. Returns model.ErrDiscountNotFound if not found.
+func (repo *Discount) GetByCode(ctx context.Context, code string) (*model.Discount, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	discount := repo.findByCode(code)
+	if discount == nil {
+		return nil, fmt.Errorf("%w: code=%s", model.ErrDiscountNotFound, code)
+	}
+	return copyDiscount(discount), nil
+}
+
+// Redeem records a use of the discount with the given code. If an active
+// redemption with the same reference exists it is returned instead, so retried
+// calls do not use the discount twice. Returns model.ErrDiscountExhausted if
+// no uses are left.
+func (repo *Discount) Redeem(ctx context.Context, code string, data *model.Redemption) (*model.Redemption, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	discount := repo.findByCode(code)
+	if discount == nil {
+		return nil, fmt.Errorf("%w: code=%s", model.ErrDiscountNotFound, code)
+	}
+	for _, r := range repo.redemptions {
+		if r.DiscountID == discount.ID && r.Reference == data.Reference && r.VoidedAt == nil {
+			return copyRedemption(r), nil
+		}
+	}
+	if discount.MaxUses > 0 && discount.Uses >= discount.MaxUses {
+		return nil, fmt.Errorf("%w: code=%s", model.ErrDiscountExhausted, code)
+	}
+
+	repo.redemptionSeqID++
+	data.ID = model.RedemptionID(repo.redemptionSeqID)
+	data.DiscountID = discount.ID
+	data.Code = discount.Code
+	data.CreatedAt = time.Now()
+	data.VoidedAt = nil
+	discount.Uses++
+	repo.redemptions = append(repo.redemptions, copyRedemption(data))
+	return data, nil
+}
+
+// Void gives back the use of a redemption. Voiding twice has no further
+// effect. Returns model.ErrRedemptionNotFound if not found.
+func (repo *Discount) Void(ctx context.Context, id model.RedemptionID) (*model.Redemption, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, r := range repo.redemptions {
+		if r.ID == id {
+			repo.void(r)
+			return copyRedemption(r), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id=%d", model.ErrRedemptionNotFound, id)
+}
+
+// VoidByReference gives back the uses of the discount with the given code
+// redeemed under reference, like Void, and returns the most recent one.
+// Returns model.ErrRedemptionNotFound if there are none.
+func (repo *Discount) VoidByReference(ctx context.Context, code, reference string) (*model.Redemption, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var last *model.Redemption
+	for _, r := range repo.redemptions {
+		if r.Code == code && r.Reference == reference {
+			repo.void(r)
+			last = r
+		}
+	}
+	if last == nil {
+		return nil, fmt.Errorf("%w: code=%s, reference=%s", model.ErrRedemptionNotFound, code, reference)
+	}
+	return copyRedemption(last), nil
+}
+
+// void gives back the use of an active redemption. Callers hold the lock.
+func (repo *Discount) void(r *model.Redemption) {
+	if r.VoidedAt != nil {
+		return
+	}
+	now := time.Now()
+	r.VoidedAt = &now
+	if discount := repo.find(r.DiscountID); discount != nil {
+		discount.Uses--
+	}
+}
+
+func (repo *Discount) find(id model.DiscountID) *model.Discount {
+	for _, d := range repo.data {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func (repo *Discount) findByCode(code string) *model.Discount {
+	for _, d := range repo.data {
+		if d.Code == code {
+			return d
+		}
+	}
+	return nil
+}
+
+// copyDiscount returns a copy of a discount that shares no memory with it.
+func copyDiscount(d *model.Discount) *model.Discount {
+	copied := *d
+	if d.FlatOff != nil {
+		flatOff := *d.FlatOff
+		copied.FlatOff = &flatOff
+	}
+	return &copied
+}
+
+// copyRedemption returns a copy of a redemption that shares no memory with it.
+func copyRedemption(r *model.Redemption) *model.Redemption {
+	copied := *r
+	if r.VoidedAt != nil {
+		voidedAt := *r.VoidedAt
+		copied.VoidedAt = &voidedAt
+	}
+	return &copied
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	model "inventory.com/discount/pkg"
+	"inventory.com/pkg/money"
+)
+
+func TestDiscount_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	flatOff := money.Money{Amount: 100, Currency: "USD"}
+	_, err := repo.Create(ctx, &model.Discount{ProductID: 1, Code: "FLAT", FlatOff: &flatOff, MaxUses: 1})
+	require.NoError(t, err)
+
+	got, err := repo.GetByCode(ctx, "FLAT")
+	require.NoError(t, err)
+	got.Uses = 1
+	got.FlatOff.Amount = 1
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	all[0].Uses = 1
+
+	_, err = repo.Redeem(ctx, "FLAT", &model.Redemption{ProductID: 1, Reference: "saga-1"})
+	require.NoError(t, err, "the use count changed by callers is not the stored one")
+	got, err = repo.GetByCode(ctx, "FLAT")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Uses)
+	assert.Equal(t, int64(100), got.FlatOff.Amount)
+}
+
+func TestDiscount_VoidByReference(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	_, err := repo.Create(ctx, &model.Discount{ProductID: 1, Code: "TEN", BasisPoints: 1000, MaxUses: 1})
+	require.NoError(t, err)
+	redemption, err := repo.Redeem(ctx, "TEN", &model.Redemption{ProductID: 1, Reference: "saga-1"})
+	require.NoError(t, err)
+
+	voided, err := repo.VoidByReference(ctx, "TEN", "saga-1")
+	require.NoError(t, err)
+	assert.Equal(t, redemption.ID, voided.ID)
+	assert.NotNil(t, voided.VoidedAt)
+	_, err = repo.VoidByReference(ctx, "TEN", "saga-1")
+	require.NoError(t, err, "voiding twice succeeds")
+
+	discount, err := repo.GetByCode(ctx, "TEN")
+	require.NoError(t, err)
+	assert.Zero(t, discount.Uses, "the use is given back once")
+	_, err = repo.VoidByReference(ctx, "TEN", "saga-2")
+	assert.ErrorIs(t, err, model.ErrRedemptionNotFound)
+}
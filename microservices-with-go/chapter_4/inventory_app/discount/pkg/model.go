@@ -1 +1,106 @@
 package pkg
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/money"
+)
+
+var (
+	// ErrInvalidDiscount is returned when a discount definition is inconsistent.
+	ErrInvalidDiscount = errors.New("invalid discount")
+	// ErrDiscountExhausted is returned when a discount reached its redemption limit.
+	ErrDiscountExhausted = errors.New("discount has no redemptions left")
+	// ErrProductMismatch is returned when a discount is redeemed for another product.
+	ErrProductMismatch = errors.New("discount does not apply to product")
+	// ErrDiscountNotFound is returned when no discount exists for the given code.
+	ErrDiscountNotFound = errors.New("discount not found")
+	// ErrDuplicateCode is returned when a discount code is already taken.
+	ErrDuplicateCode = errors.New("discount code already exists")
+	// ErrRedemptionNotFound is returned when a redemption does not exist.
+	ErrRedemptionNotFound = errors.New("redemption not found")
+)
+
+// DiscountID defines the unique identifier for a discount.
+type DiscountID int
+
+// Discount represents a price reduction for a product. Either a percentage,
+// expressed in basis points (1500 is 15%), or a flat amount off is applied.
+type Discount struct {
+	ID          DiscountID             `json:"id"`
+	ProductID   catalogModel.ProductID `json:"productID"`
+	Code        string                 `json:"code"`
+	BasisPoints int64                  `json:"basisPoints,omitempty"` // Percentage off in 1/100 of a percent
+	FlatOff     *money.Money           `json:"flatOff,omitempty"`     // Fixed amount off per unit
+	MaxUses     int                    `json:"maxUses,omitempty"`     // Redemption limit, unlimited when zero
+	Uses        int                    `json:"uses"`                  // Redemptions that have not been voided
+}
+
+// RedemptionID defines the unique identifier for a redemption.
+type RedemptionID int
+
+// Redemption records one use of a discount code. Reference identifies the
+// caller's operation, e.g. a sale saga, and makes redeeming idempotent.
+type Redemption struct {
+	ID              RedemptionID           `json:"id"`
+	DiscountID      DiscountID             `json:"discountID"`
+	Code            string                 `json:"code"`
+	ProductID       catalogModel.ProductID `json:"productID"`
+	Reference       string                 `json:"reference"`
+	UnitPrice       money.Money            `json:"unitPrice"`       // Price per unit before the discount
+	DiscountedPrice money.Money            `json:"discountedPrice"` // Price per unit after the discount
+	CreatedAt       time.Time              `json:"createdAt"`
+	VoidedAt        *time.Time             `json:"voidedAt,omitempty"` // Set when the use was given back
+}
+
+// Validate checks that the discount has a code and exactly one kind of reduction.
+func (d *Discount) Validate() error {
+	switch {
+	case d.Code == "":
+		return fmt.Errorf("%w: code is required", ErrInvalidDiscount)
+	case d.MaxUses < 0:
+		return fmt.Errorf("%w: maxUses cannot be negative", ErrInvalidDiscount)
+	case d.FlatOff == nil && d.BasisPoints <= 0:
+		return fmt.Errorf("%w: either basisPoints or flatOff is required", ErrInvalidDiscount)
+	case d.FlatOff != nil && d.BasisPoints != 0:
+		return fmt.Errorf("%w: basisPoints and flatOff are mutually exclusive", ErrInvalidDiscount)
+	case d.BasisPoints > 10000:
+		return fmt.Errorf("%w: basisPoints cannot exceed 10000", ErrInvalidDiscount)
+	case d.FlatOff != nil:
+		if err := d.FlatOff.Validate(); err != nil {
+			return err
+		}
+		if !d.FlatOff.IsPositive() {
+			return fmt.Errorf("%w: flatOff must be greater than zero", ErrInvalidDiscount)
+		}
+	}
+	return nil
+}
+
+// Apply returns the discounted price. The result never drops below zero.
+func (d *Discount) Apply(price money.Money) (money.Money, error) {
+	if err := d.Validate(); err != nil {
+		return money.Money{}, err
+	}
+
+	var discounted money.Money
+	var err error
+	if d.FlatOff != nil {
+		discounted, err = price.Sub(*d.FlatOff)
+	} else {
+		var off money.Money
+		if off, err = price.Percent(d.BasisPoints); err == nil {
+			discounted, err = price.Sub(off)
+		}
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+	if discounted.IsNegative() {
+		return money.Money{Currency: price.Currency}, nil
+	}
+	return discounted, nil
+}
@@ -1,28 +1,233 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"inventory.com/inventory_gateway/internal/controller"
 	"inventory.com/inventory_gateway/internal/gateway"
+	"inventory.com/inventory_gateway/internal/graph"
 	"inventory.com/inventory_gateway/internal/handler/ginhandler"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/discovery"
+	"inventory.com/pkg/discovery/registry"
+	"inventory.com/pkg/health"
+	"inventory.com/pkg/lifecycle"
+	"inventory.com/pkg/logging"
+	"inventory.com/pkg/metrics"
+	"inventory.com/pkg/ratelimit"
+	"inventory.com/pkg/requestid"
+	"inventory.com/pkg/resilient"
+	"inventory.com/pkg/tenant"
+	"inventory.com/pkg/tracing"
 )
 
 var (
 	categoryGatewayAddr = "http://0.0.0.0:8081" // Example address, adjust as needed
-	categoryControler   *controller.CategoryController
+	orderGatewayAddr    = "http://0.0.0.0:8082" // Example address, adjust as needed
+	discountGatewayAddr = "http://0.0.0.0:8084" // Example address, adjust as needed
 )
 
-func init() {
-	categoryControler = controller.NewCategoryController(gateway.NewCategoryGateway(categoryGatewayAddr))
+const (
+	catalogCacheSize = 1000
+	catalogCacheTTL  = 30 * time.Second
+	// heartbeatInterval is how often a ready instance reports to the service registry.
+	heartbeatInterval = 2 * time.Second
+	// healthCheckTimeout bounds each readiness check.
+	healthCheckTimeout = time.Second
+)
+
+// advertiseAddr is the address the gateway registers under in the service registry.
+var advertiseAddr = envOr("GATEWAY_ADVERTISE_ADDR", "localhost:8083")
+
+// rateLimitURL optionally points at the gateway instance whose buckets every
+// instance shares, e.g. http://gateway-1:8083; without it each instance
+// enforces the limits on its own.
+var rateLimitURL = os.Getenv("GATEWAY_RATELIMIT_URL")
+
+// rateLimitShared makes this instance the one serving its buckets to the
+// others, under POST /ratelimit/take for admins only.
+var rateLimitShared = os.Getenv("GATEWAY_RATELIMIT_SHARED") == "true"
+
+// serviceAPIKey authenticates the gateway to the instance sharing its rate
+// limit buckets; it needs the admin role.
+var serviceAPIKey = os.Getenv("GATEWAY_SERVICE_API_KEY")
+
+// catalogEventSecret verifies the signature of the catalog events posted to
+// /events/catalog; the route is only served when it is set.
+var catalogEventSecret = os.Getenv("GATEWAY_CATALOG_EVENT_SECRET")
+
+// trustedProxies lists, comma separated, the IPs and CIDRs of the proxies
+// whose X-Forwarded-For header gives the client IP, e.g. of a load balancer.
+// Without them the client IP is the address of the connection.
+var trustedProxies = os.Getenv("GATEWAY_TRUSTED_PROXIES")
+
+// rateLimitRules limit the clients by API key and IP; the first rule
+// matching a request applies. Internal routes are not limited.
+var rateLimitRules = []ratelimit.Rule{
+	{Name: "internal", Prefix: "/ratelimit/"},
+	{Name: "internal", Prefix: "/events/"},
+	{Name: "internal", Prefix: "/metrics"},
+	{Name: "internal", Prefix: "/healthz"},
+	{Name: "internal", Prefix: "/readyz"},
+	{Name: "catalog-writes", Method: http.MethodPost, Prefix: "/categories", PerKey: ratelimit.PerMinute(60, 10), PerIP: ratelimit.PerMinute(30, 10)},
+	{Name: "catalog-writes", Method: http.MethodPut, Prefix: "/categories", PerKey: ratelimit.PerMinute(60, 10), PerIP: ratelimit.PerMinute(30, 10)},
+	{Name: "graphql", Prefix: "/graphql", PerKey: ratelimit.PerSecond(20, 40), PerIP: ratelimit.PerSecond(10, 20)},
+	{Name: "default", Prefix: "/", PerKey: ratelimit.PerSecond(50, 100), PerIP: ratelimit.PerSecond(20, 40)},
+}
+
+// overviewTimeouts bounds how long a product overview waits for each upstream.
+var overviewTimeouts = controller.OverviewTimeouts{
+	Catalog:   time.Second,
+	Orders:    time.Second,
+	Discounts: 500 * time.Millisecond,
 }
+
 func main() {
+	if err := logging.Init(logging.ConfigFromEnv("gateway")); err != nil {
+		logging.Fatal("failed to set up logging", "error", err)
+	}
+	authenticator, err := auth.Load(auth.ConfigFromEnv())
+	if err != nil {
+		logging.Fatal("failed to set up authentication", "error", err)
+	}
+	tenants, err := tenant.FromEnv()
+	if err != nil {
+		logging.Fatal("failed to set up tenants", "error", err)
+	}
+	shutdownTracing, err := tracing.Init(tracing.ConfigFromEnv("gateway"))
+	if err != nil {
+		logging.Fatal("failed to set up tracing", "error", err)
+	}
+	serviceRegistry, err := registry.FromEnv()
+	if err != nil {
+		logging.Fatal("failed to set up the service registry", "error", err)
+	}
+
+	// httpClient is shared by every gateway so that each upstream instance
+	// has a single circuit breaker.
+	httpClient := resilient.New(httpClientConfig())
+	// upstreamClient calls the services with the credentials of the caller.
+	upstreamClient := auth.NewForwardingClient(httpClient, "")
+	// catalogCache is shared by the catalog gateways and invalidated by
+	// their writes and by the catalog change events.
+	catalogCache := gateway.NewCatalogCache(catalogCacheSize, catalogCacheTTL)
+
+	categories := gateway.NewCachedCategoryGateway(gateway.NewCategoryGateway(categoryGatewayAddr, metrics.NewClient("category", upstreamClient)), catalogCache)
+	subCategories := gateway.NewCachedSubCategoryGateway(gateway.NewSubCategoryGateway(categoryGatewayAddr, metrics.NewClient("subcategory", upstreamClient)), catalogCache)
+	products := gateway.NewCachedProductGateway(gateway.NewProductGateway(categoryGatewayAddr, metrics.NewClient("product", upstreamClient)), catalogCache)
+	orders := gateway.NewOrderGateway(orderGatewayAddr, metrics.NewClient("order", upstreamClient))
+
+	categoryController := controller.NewCategoryController(categories)
+	productController := controller.NewProductController(
+		products,
+		orders,
+		gateway.NewDiscountGateway(discountGatewayAddr, metrics.NewClient("discount", upstreamClient)),
+		overviewTimeouts,
+	)
+	graphResolver := graph.NewResolver(
+		categories,
+		subCategories,
+		products,
+		orders,
+	)
+
+	readiness := health.New(healthCheckTimeout)
+	if serviceRegistry != nil {
+		for _, upstream := range []string{"catalog", "order", "discount"} {
+			readiness.Add(upstream, health.Resolvable(serviceRegistry, upstream))
+		}
+	}
+
 	gin.SetMode(gin.DebugMode)
+	localLimits := ratelimit.NewMemoryStore()
+	var limits ratelimit.Store = localLimits
+	if rateLimitURL != "" {
+		limits = ratelimit.NewRemoteStore(rateLimitURL, auth.NewForwardingClient(httpClient, serviceAPIKey))
+	}
+	engine := newEngine(authenticator, tenants, limits, readiness, catalogCache, catalogEventSecret)
+	if err := engine.SetTrustedProxies(splitList(trustedProxies)); err != nil {
+		logging.Fatal("failed to set the trusted proxies", "error", err)
+	}
+
+	ginhandler.RegisterCategoryRoutes(engine, categoryController)
+	ginhandler.RegisterProductRoutes(engine, productController)
+	ginhandler.RegisterGraphQLRoutes(engine, graphResolver)
+	resilient.InitHandler(engine, httpClient)
+	admin := engine.Group("", auth.Require(auth.PermAdmin))
+	if rateLimitShared {
+		ratelimit.InitHandler(admin, localLimits, rateLimitRules)
+	}
+	logging.InitHandler(admin)
+
+	service := lifecycle.New(lifecycle.DefaultConfig(":8083"), engine)
+	readiness.Add("lifecycle", service)
+	if serviceRegistry != nil {
+		instance := health.Instance{ID: discovery.GenerateInstanceID("gateway"), Service: "gateway", HostPort: advertiseAddr}
+		service.Go(func(ctx context.Context) { readiness.Heartbeat(ctx, serviceRegistry, instance, heartbeatInterval) })
+	}
+	service.OnShutdown("tracing", shutdownTracing)
+
+	if err := service.Run(context.Background()); err != nil {
+		logging.Fatal("server stopped", "error", err)
+	}
+}
+
+// httpClientConfig traces the calls to the services, propagating the trace
+// context and request ID of the request being served.
+func httpClientConfig() resilient.Config {
+	cfg := resilient.DefaultConfig()
+	cfg.Transport = tracing.Transport(nil)
+	return cfg
+}
+
+// splitList splits a comma separated list, returning nil when it is empty.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newEngine returns the engine of the gateway. The probes, the metrics and the
+// catalog events are served before the authentication and tenant middleware,
+// so that they answer whatever the configured tenants: the events are
+// authenticated by their signature and carry their own tenant. The routes
+// added afterwards require a known tenant.
+func newEngine(authenticator *auth.Authenticator, tenants []tenant.ID, limits ratelimit.Store, readiness *health.Health, catalogEvents ginhandler.IEventHandler, eventSecret string) *gin.Engine {
 	engine := gin.New()
+	engine.Use(
+		requestid.Middleware(),
+		tracing.Middleware(),
+		logging.Middleware(),
+		metrics.Middleware(),
+		ratelimit.Middleware(limits, rateLimitRules),
+	)
+	health.InitHandler(engine, readiness)
+	metrics.InitHandler(engine)
+	if eventSecret != "" {
+		ginhandler.RegisterCatalogEventRoutes(engine, catalogEvents, eventSecret)
+	} else {
+		slog.Warn("no catalog event secret configured, cached catalog entries expire with their TTL only")
+	}
+	engine.Use(
+		auth.Middleware(authenticator),
+		tenant.Middleware(tenants, auth.MayChooseTenant),
+	)
+	return engine
+}
 
-	ginhandler.RegisterCategoryRoutes(engine, categoryControler)
-	if err := engine.Run(":8083"); err != nil {
-		log.Fatalf("[server] Failed to start server: %v", err)
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
 }
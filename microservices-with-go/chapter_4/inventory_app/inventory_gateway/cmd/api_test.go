@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/health"
+	"inventory.com/pkg/ratelimit"
+	"inventory.com/pkg/tenant"
+	"inventory.com/pkg/webhook"
+)
+
+func TestNewEngine_ProbesAndMetricsWithoutDefaultTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.Load(auth.Config{})
+	require.NoError(t, err)
+	engine := newEngine(authenticator, []tenant.ID{"a", "b"}, ratelimit.NewMemoryStore(), health.New(time.Second), nil, "")
+	engine.GET("/protected", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protected", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code, "the other routes still require a tenant")
+}
+
+type eventRecorder chan *events.Event
+
+func (r eventRecorder) HandleEvent(ctx context.Context, event *events.Event) error {
+	r <- event
+	return nil
+}
+
+func TestNewEngine_CatalogEventsWithoutDefaultTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.Load(auth.Config{})
+	require.NoError(t, err)
+	received := make(eventRecorder, 1)
+	engine := newEngine(authenticator, []tenant.ID{"a", "b"}, ratelimit.NewMemoryStore(), health.New(time.Second), received, "secret")
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	event := &events.Event{ID: "1", Type: "product.updated", AggregateType: "product", AggregateID: "7", TenantID: "b"}
+	sink := events.NewWebhookSink(server.URL+"/events/catalog", time.Second, webhook.Signer("secret"))
+	require.NoError(t, sink.Publish(context.Background(), event), "signed events need no credentials nor tenant header")
+	assert.Equal(t, tenant.ID("b"), (<-received).TenantID)
+
+	unsigned := events.NewWebhookSink(server.URL+"/events/catalog", time.Second, nil)
+	assert.Error(t, unsigned.Publish(context.Background(), event))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/tracing"
 )
 
 type ICategoryGateway interface {
@@ -19,13 +20,22 @@ func NewCategoryController(gateway ICategoryGateway) *CategoryController {
 	return &CategoryController{gateway: gateway}
 }
 func (c *CategoryController) Create(ctx context.Context, data *model.Category) (*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Create")
+	defer span.End()
+
 	return c.gateway.Create(ctx, data)
 }
 
 func (c *CategoryController) Update(ctx context.Context, id model.CategoryID, data *model.Category) (*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Update")
+	defer span.End()
+
 	return c.gateway.Update(ctx, id, data)
 }
 
 func (c *CategoryController) Get(ctx context.Context, id model.CategoryID) (*model.Category, error) {
+	ctx, span := tracing.Start(ctx, "controller.CategoryController.Get")
+	defer span.End()
+
 	return c.gateway.Get(ctx, id)
 }
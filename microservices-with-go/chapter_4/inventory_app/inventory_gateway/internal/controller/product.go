@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	discountModel "inventory.com/discount/pkg"
+	"inventory.com/inventory_gateway/internal/gateway"
+	"inventory.com/inventory_gateway/pkg/model"
+	"inventory.com/pkg/tracing"
+)
+
+type IProductGateway interface {
+	Get(ctx context.Context, id catalogModel.ProductID) (*catalogModel.ProductInformation, error)
+}
+
+type IStockGateway interface {
+	CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error)
+}
+
+type IDiscountGateway interface {
+	ForProduct(ctx context.Context, productID catalogModel.ProductID) ([]*discountModel.Discount, error)
+}
+
+// OverviewTimeouts bounds how long the overview waits for each upstream.
+type OverviewTimeouts struct {
+	Catalog   time.Duration
+	Orders    time.Duration
+	Discounts time.Duration
+}
+
+type ProductController struct {
+	products  IProductGateway
+	stock     IStockGateway
+	discounts IDiscountGateway
+	timeouts  OverviewTimeouts
+}
+
+func NewProductController(products IProductGateway, stock IStockGateway, discounts IDiscountGateway, timeouts OverviewTimeouts) *ProductController {
+	return &ProductController{products: products, stock: stock, discounts: discounts, timeouts: timeouts}
+}
+
+// Overview queries the catalog, order and discount services concurrently and
+// combines their answers. Only a product unknown to the catalog is an error
+// (gateway.ErrNotFound); any other upstream failure leaves its section out and
+// marks it unavailable. Pricing needs both the catalog and the discount service.
+func (c *ProductController) Overview(ctx context.Context, id catalogModel.ProductID) (*model.ProductOverview, error) {
+	ctx, span := tracing.Start(ctx, "controller.ProductController.Overview")
+	defer span.End()
+
+	var (
+		wg           sync.WaitGroup
+		product      *catalogModel.ProductInformation
+		stock        int
+		discounts    []*discountModel.Discount
+		productErr   error
+		stockErr     error
+		discountsErr error
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, c.timeouts.Catalog)
+		defer cancel()
+		product, productErr = c.products.Get(ctx, id)
+	}()
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, c.timeouts.Orders)
+		defer cancel()
+		stock, stockErr = c.stock.CurrentStock(ctx, id)
+	}()
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, c.timeouts.Discounts)
+		defer cancel()
+		discounts, discountsErr = c.discounts.ForProduct(ctx, id)
+	}()
+	wg.Wait()
+
+	if errors.Is(productErr, gateway.ErrNotFound) {
+		return nil, productErr
+	}
+
+	overview := &model.ProductOverview{Unavailable: map[string]string{}}
+	if productErr != nil {
+		overview.Unavailable[model.SectionProduct] = unavailableReason("catalog", productErr)
+	} else {
+		overview.Product = product
+	}
+	if stockErr != nil {
+		overview.Unavailable[model.SectionStock] = unavailableReason("order service", stockErr)
+	} else {
+		overview.Stock = &stock
+	}
+	switch {
+	case productErr != nil:
+		overview.Unavailable[model.SectionPricing] = unavailableReason("catalog", productErr)
+	case discountsErr != nil:
+		overview.Unavailable[model.SectionPricing] = unavailableReason("discount service", discountsErr)
+	default:
+		pricing, err := bestPrice(product, discounts)
+		if err != nil {
+			overview.Unavailable[model.SectionPricing] = err.Error()
+		} else {
+			overview.Pricing = pricing
+		}
+	}
+	if len(overview.Unavailable) == 0 {
+		overview.Unavailable = nil
+	}
+	return overview, nil
+}
+
+// bestPrice applies the discount giving the lowest price to the list price.
+func bestPrice(product *catalogModel.ProductInformation, discounts []*discountModel.Discount) (*model.Pricing, error) {
+	pricing := &model.Pricing{ListPrice: product.ListCost, EffectivePrice: product.ListCost}
+	for _, d := range discounts {
+		price, err := d.Apply(product.ListCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply discount %s: %w", d.Code, err)
+		}
+		if price.Amount < pricing.EffectivePrice.Amount {
+			pricing.EffectivePrice = price
+			pricing.DiscountCode = d.Code
+		}
+	}
+	return pricing, nil
+}
+
+func unavailableReason(upstream string, err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return upstream + " timed out"
+	}
+	return fmt.Sprintf("%s unavailable: %v", upstream, err)
+}
@@ -0,0 +1,162 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	discountModel "inventory.com/discount/pkg"
+	"inventory.com/inventory_gateway/internal/controller"
+	"inventory.com/inventory_gateway/internal/gateway"
+	"inventory.com/inventory_gateway/pkg/model"
+	"inventory.com/pkg/money"
+)
+
+// --- Mocks ---
+type MockProductGateway struct {
+	mock.Mock
+}
+
+func (m *MockProductGateway) Get(ctx context.Context, id catalogModel.ProductID) (*catalogModel.ProductInformation, error) {
+	args := m.Called(ctx, id)
+	product, _ := args.Get(0).(*catalogModel.ProductInformation)
+	return product, args.Error(1)
+}
+
+type MockStockGateway struct {
+	mock.Mock
+}
+
+func (m *MockStockGateway) CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+type MockDiscountGateway struct {
+	mock.Mock
+}
+
+func (m *MockDiscountGateway) ForProduct(ctx context.Context, productID catalogModel.ProductID) ([]*discountModel.Discount, error) {
+	args := m.Called(ctx, productID)
+	discounts, _ := args.Get(0).([]*discountModel.Discount)
+	return discounts, args.Error(1)
+}
+
+// waitForDeadline blocks a mocked call until its context is done, like an
+// upstream that does not answer.
+func waitForDeadline(args mock.Arguments) {
+	<-args.Get(0).(context.Context).Done()
+}
+
+var timeouts = controller.OverviewTimeouts{Catalog: time.Second, Orders: time.Second, Discounts: time.Second}
+
+func newProduct() *catalogModel.ProductInformation {
+	return &catalogModel.ProductInformation{ProductBaseInfo: catalogModel.ProductBaseInfo{ID: 1, Name: "Rake", ListCost: money.Money{Amount: 1000, Currency: "USD"}}}
+}
+
+func TestProductController_Overview(t *testing.T) {
+	products, stock, discounts := new(MockProductGateway), new(MockStockGateway), new(MockDiscountGateway)
+	products.On("Get", mock.Anything, catalogModel.ProductID(1)).Return(newProduct(), nil)
+	stock.On("CurrentStock", mock.Anything, catalogModel.ProductID(1)).Return(7, nil)
+	discounts.On("ForProduct", mock.Anything, catalogModel.ProductID(1)).Return([]*discountModel.Discount{
+		{Code: "TEN", BasisPoints: 1000},
+		{Code: "FLAT", FlatOff: &money.Money{Amount: 50, Currency: "USD"}},
+	}, nil)
+
+	overview, err := controller.NewProductController(products, stock, discounts, timeouts).Overview(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Rake", overview.Product.Name)
+	require.NotNil(t, overview.Stock)
+	assert.Equal(t, 7, *overview.Stock)
+	require.NotNil(t, overview.Pricing)
+	assert.Equal(t, int64(900), overview.Pricing.EffectivePrice.Amount)
+	assert.Equal(t, "TEN", overview.Pricing.DiscountCode)
+	assert.Nil(t, overview.Unavailable)
+}
+
+func TestProductController_Overview_NotFound(t *testing.T) {
+	products, stock, discounts := new(MockProductGateway), new(MockStockGateway), new(MockDiscountGateway)
+	products.On("Get", mock.Anything, catalogModel.ProductID(1)).Return(nil, gateway.ErrNotFound)
+	stock.On("CurrentStock", mock.Anything, catalogModel.ProductID(1)).Return(0, nil)
+	discounts.On("ForProduct", mock.Anything, catalogModel.ProductID(1)).Return([]*discountModel.Discount{}, nil)
+
+	_, err := controller.NewProductController(products, stock, discounts, timeouts).Overview(context.Background(), 1)
+	assert.ErrorIs(t, err, gateway.ErrNotFound)
+}
+
+func TestProductController_Overview_PartialResults(t *testing.T) {
+	failure := errors.New("connection refused")
+	tests := []struct {
+		name        string
+		productErr  error
+		stockErr    error
+		discountErr error
+		unavailable map[string]string
+	}{
+		{
+			name:        "order service down",
+			stockErr:    failure,
+			unavailable: map[string]string{model.SectionStock: "order service unavailable: connection refused"},
+		},
+		{
+			name:        "discount service down",
+			discountErr: failure,
+			unavailable: map[string]string{model.SectionPricing: "discount service unavailable: connection refused"},
+		},
+		{
+			name:       "catalog down",
+			productErr: failure,
+			unavailable: map[string]string{
+				model.SectionProduct: "catalog unavailable: connection refused",
+				model.SectionPricing: "catalog unavailable: connection refused",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, stock, discounts := new(MockProductGateway), new(MockStockGateway), new(MockDiscountGateway)
+			if tt.productErr != nil {
+				products.On("Get", mock.Anything, catalogModel.ProductID(1)).Return(nil, tt.productErr)
+			} else {
+				products.On("Get", mock.Anything, catalogModel.ProductID(1)).Return(newProduct(), nil)
+			}
+			stock.On("CurrentStock", mock.Anything, catalogModel.ProductID(1)).Return(7, tt.stockErr)
+			discounts.On("ForProduct", mock.Anything, catalogModel.ProductID(1)).Return([]*discountModel.Discount{}, tt.discountErr)
+
+			overview, err := controller.NewProductController(products, stock, discounts, timeouts).Overview(context.Background(), 1)
+			require.NoError(t, err, "a failing upstream leaves its section out")
+			assert.Equal(t, tt.unavailable, overview.Unavailable)
+			assert.Equal(t, tt.productErr == nil, overview.Product != nil)
+			assert.Equal(t, tt.stockErr == nil, overview.Stock != nil)
+			assert.Equal(t, tt.productErr == nil && tt.discountErr == nil, overview.Pricing != nil)
+		})
+	}
+}
+
+func TestProductController_Overview_Timeouts(t *testing.T) {
+	products, stock, discounts := new(MockProductGateway), new(MockStockGateway), new(MockDiscountGateway)
+	products.On("Get", mock.Anything, catalogModel.ProductID(1)).Return(newProduct(), nil)
+	stock.On("CurrentStock", mock.Anything, catalogModel.ProductID(1)).Run(waitForDeadline).Return(0, context.DeadlineExceeded)
+	discounts.On("ForProduct", mock.Anything, catalogModel.ProductID(1)).Return([]*discountModel.Discount{}, nil)
+
+	slow := controller.OverviewTimeouts{Catalog: time.Second, Orders: 20 * time.Millisecond, Discounts: time.Second}
+	start := time.Now()
+	overview, err := controller.NewProductController(products, stock, discounts, slow).Overview(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second, "only the slow upstream is waited for, up to its own timeout")
+	assert.Equal(t, map[string]string{model.SectionStock: "order service timed out"}, overview.Unavailable)
+	assert.NotNil(t, overview.Product)
+	assert.NotNil(t, overview.Pricing)
+	assert.Nil(t, overview.Stock)
+
+	// The deadline is per upstream: the others keep theirs.
+	deadline, ok := products.Calls[0].Arguments.Get(0).(context.Context).Deadline()
+	require.True(t, ok)
+	assert.Greater(t, time.Until(deadline), 500*time.Millisecond)
+}
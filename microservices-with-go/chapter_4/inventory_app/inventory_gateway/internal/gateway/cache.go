@@ -0,0 +1,194 @@
+package gateway
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/cache"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/tenant"
+)
+
+// CatalogCache holds the catalog entities read through the cached gateways,
+// separately for every tenant. Subcategories embed their category and
+// products their subcategory, so a change of a parent drops every cached
+// child of the tenant as well.
+type CatalogCache struct {
+	tenants *tenant.Partitions[catalogEntries]
+}
+
+// catalogEntries are the cached entities of one tenant.
+type catalogEntries struct {
+	categories    *cache.LRU[model.CategoryID, *model.Category]
+	subCategories *cache.LRU[model.SubCategoryID, *model.SubCategoryDetails]
+	products      *cache.LRU[model.ProductID, *model.ProductInformation]
+}
+
+// NewCatalogCache keeps up to size entities of each kind per tenant for ttl.
+func NewCatalogCache(size int, ttl time.Duration) *CatalogCache {
+	return &CatalogCache{
+		tenants: tenant.NewPartitions(func() *catalogEntries {
+			return &catalogEntries{
+				categories:    cache.New[model.CategoryID, *model.Category](size, ttl),
+				subCategories: cache.New[model.SubCategoryID, *model.SubCategoryDetails](size, ttl),
+				products:      cache.New[model.ProductID, *model.ProductInformation](size, ttl),
+			}
+		}),
+	}
+}
+
+// InvalidateCategory drops a category of the tenant of ctx and everything embedding it.
+func (c *CatalogCache) InvalidateCategory(ctx context.Context, id model.CategoryID) {
+	e, err := c.tenants.For(ctx)
+	if err != nil {
+		return
+	}
+	e.categories.Delete(id)
+	e.subCategories.Purge()
+	e.products.Purge()
+}
+
+// InvalidateSubCategory drops a subcategory and every product of the tenant of ctx.
+func (c *CatalogCache) InvalidateSubCategory(ctx context.Context, id model.SubCategoryID) {
+	e, err := c.tenants.For(ctx)
+	if err != nil {
+		return
+	}
+	e.subCategories.Delete(id)
+	e.products.Purge()
+}
+
+// InvalidateProduct drops a product of the tenant of ctx.
+func (c *CatalogCache) InvalidateProduct(ctx context.Context, id model.ProductID) {
+	if e, err := c.tenants.For(ctx); err == nil {
+		e.products.Delete(id)
+	}
+}
+
+// HandleEvent invalidates the entity changed by a catalog domain event, so
+// that changes made without going through this gateway are seen before the
+// entries expire. Only the entries of the event's tenant are dropped.
+// Creations change nothing cached.
+func (c *CatalogCache) HandleEvent(ctx context.Context, event *events.Event) error {
+	id, err := strconv.Atoi(event.AggregateID)
+	if err != nil {
+		return nil
+	}
+	ctx = tenant.WithID(ctx, event.TenantID)
+	switch event.Type {
+	case model.EventCategoryUpdated, model.EventCategoryDeleted, model.EventCategoryRestored:
+		c.InvalidateCategory(ctx, model.CategoryID(id))
+	case model.EventSubCategoryUpdated, model.EventSubCategoryDeleted, model.EventSubCategoryRestored:
+		c.InvalidateSubCategory(ctx, model.SubCategoryID(id))
+	case model.EventProductUpdated, model.EventProductDeleted, model.EventProductRestored:
+		c.InvalidateProduct(ctx, model.ProductID(id))
+	}
+	return nil
+}
+
+// CachedCategoryGateway reads categories through a CatalogCache and
+// invalidates it on its own writes.
+type CachedCategoryGateway struct {
+	*CategoryGateway
+	cache *CatalogCache
+}
+
+// NewCachedCategoryGateway wraps a category gateway with a cache.
+func NewCachedCategoryGateway(g *CategoryGateway, c *CatalogCache) *CachedCategoryGateway {
+	return &CachedCategoryGateway{g, c}
+}
+
+// Get returns a cached category or loads it from the catalog.
+func (g *CachedCategoryGateway) Get(ctx context.Context, id model.CategoryID) (*model.Category, error) {
+	e, err := g.cache.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.categories.GetOrLoad(ctx, id, func(ctx context.Context) (*model.Category, error) {
+		return g.CategoryGateway.Get(ctx, id)
+	})
+}
+
+// Update replaces a category and invalidates it.
+func (g *CachedCategoryGateway) Update(ctx context.Context, id model.CategoryID, data *model.Category) (*model.Category, error) {
+	defer g.cache.InvalidateCategory(ctx, id)
+	return g.CategoryGateway.Update(ctx, id, data)
+}
+
+// Delete soft deletes a category and invalidates it.
+func (g *CachedCategoryGateway) Delete(ctx context.Context, id model.CategoryID) error {
+	defer g.cache.InvalidateCategory(ctx, id)
+	return g.CategoryGateway.Delete(ctx, id)
+}
+
+// CachedSubCategoryGateway reads subcategories through a CatalogCache and
+// invalidates it on its own writes.
+type CachedSubCategoryGateway struct {
+	*SubCategoryGateway
+	cache *CatalogCache
+}
+
+// NewCachedSubCategoryGateway wraps a subcategory gateway with a cache.
+func NewCachedSubCategoryGateway(g *SubCategoryGateway, c *CatalogCache) *CachedSubCategoryGateway {
+	return &CachedSubCategoryGateway{g, c}
+}
+
+// Get returns a cached subcategory or loads it from the catalog.
+func (g *CachedSubCategoryGateway) Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryDetails, error) {
+	e, err := g.cache.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.subCategories.GetOrLoad(ctx, id, func(ctx context.Context) (*model.SubCategoryDetails, error) {
+		return g.SubCategoryGateway.Get(ctx, id)
+	})
+}
+
+// Update replaces a subcategory and invalidates it.
+func (g *CachedSubCategoryGateway) Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic) error {
+	defer g.cache.InvalidateSubCategory(ctx, id)
+	return g.SubCategoryGateway.Update(ctx, id, data)
+}
+
+// Delete soft deletes a subcategory and invalidates it.
+func (g *CachedSubCategoryGateway) Delete(ctx context.Context, id model.SubCategoryID) error {
+	defer g.cache.InvalidateSubCategory(ctx, id)
+	return g.SubCategoryGateway.Delete(ctx, id)
+}
+
+// CachedProductGateway reads products through a CatalogCache and
+// invalidates it on its own writes.
+type CachedProductGateway struct {
+	*ProductGateway
+	cache *CatalogCache
+}
+
+// NewCachedProductGateway wraps a product gateway with a cache.
+func NewCachedProductGateway(g *ProductGateway, c *CatalogCache) *CachedProductGateway {
+	return &CachedProductGateway{g, c}
+}
+
+// Get returns a cached product or loads it from the catalog.
+func (g *CachedProductGateway) Get(ctx context.Context, id model.ProductID) (*model.ProductInformation, error) {
+	e, err := g.cache.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.products.GetOrLoad(ctx, id, func(ctx context.Context) (*model.ProductInformation, error) {
+		return g.ProductGateway.Get(ctx, id)
+	})
+}
+
+// Update replaces a product and invalidates it.
+func (g *CachedProductGateway) Update(ctx context.Context, id model.ProductID, data *model.ProductBasic) error {
+	defer g.cache.InvalidateProduct(ctx, id)
+	return g.ProductGateway.Update(ctx, id, data)
+}
+
+// Delete soft deletes a product and invalidates it.
+func (g *CachedProductGateway) Delete(ctx context.Context, id model.ProductID) error {
+	defer g.cache.InvalidateProduct(ctx, id)
+	return g.ProductGateway.Delete(ctx, id)
+}
@@ -0,0 +1,101 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/tenant"
+)
+
+// fakeCatalog serves category 1 and product 1 and counts the reads.
+type fakeCatalog struct {
+	*httptest.Server
+	categoryReads atomic.Int32
+	productReads  atomic.Int32
+	name          atomic.Value
+}
+
+func newFakeCatalog(t *testing.T) *fakeCatalog {
+	t.Helper()
+	f := &fakeCatalog{}
+	f.name.Store("TV")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /categories/1", func(w http.ResponseWriter, r *http.Request) {
+		f.categoryReads.Add(1)
+		json.NewEncoder(w).Encode(model.Category{ID: 1, Name: f.name.Load().(string)})
+	})
+	mux.HandleFunc("PUT /categories/1", func(w http.ResponseWriter, r *http.Request) {
+		var c model.Category
+		json.NewDecoder(r.Body).Decode(&c)
+		f.name.Store(c.Name)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(c)
+	})
+	mux.HandleFunc("GET /products/1", func(w http.ResponseWriter, r *http.Request) {
+		f.productReads.Add(1)
+		io.WriteString(w, `{"id":1,"name":"C3","listCost":{"amount":"100.00","currency":"USD"}}`)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestCachedGateways(t *testing.T) {
+	catalog := newFakeCatalog(t)
+	c := NewCatalogCache(10, time.Minute)
+	categories := NewCachedCategoryGateway(NewCategoryGateway(catalog.URL, http.DefaultClient), c)
+	products := NewCachedProductGateway(NewProductGateway(catalog.URL, http.DefaultClient), c)
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+
+	for range 3 {
+		category, err := categories.Get(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "TV", category.Name)
+		_, err = products.Get(ctx, 1)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, catalog.categoryReads.Load())
+	assert.EqualValues(t, 1, catalog.productReads.Load())
+
+	// A write through the gateway drops the category and the products embedding it.
+	_, err := categories.Update(ctx, 1, &model.Category{Name: "Television"})
+	require.NoError(t, err)
+	category, err := categories.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Television", category.Name)
+	_, err = products.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, catalog.categoryReads.Load())
+	assert.EqualValues(t, 2, catalog.productReads.Load())
+
+	// So does a change event from the catalog.
+	require.NoError(t, c.HandleEvent(ctx, &events.Event{Type: model.EventProductUpdated, AggregateID: "1", TenantID: tenant.Default}))
+	require.NoError(t, c.HandleEvent(ctx, &events.Event{Type: model.EventCategoryCreated, AggregateID: "2", TenantID: tenant.Default}))
+	_, err = products.Get(ctx, 1)
+	require.NoError(t, err)
+	_, err = categories.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, catalog.productReads.Load())
+	assert.EqualValues(t, 2, catalog.categoryReads.Load())
+}
+
+func TestCachedGateways_NotFoundIsNotCached(t *testing.T) {
+	catalog := newFakeCatalog(t)
+	categories := NewCachedCategoryGateway(NewCategoryGateway(catalog.URL, http.DefaultClient), NewCatalogCache(10, time.Minute))
+
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	_, err := categories.Get(ctx, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = categories.Get(ctx, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
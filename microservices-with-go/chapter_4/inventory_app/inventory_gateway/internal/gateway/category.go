@@ -17,12 +17,13 @@ var (
 
 // Gateway defines a movie metadata HTTP gateway.
 type CategoryGateway struct {
-	addr string
+	addr   string
+	client IHTTPClient
 }
 
 // NewCategoryGateway creates a new HTTP gateway for a movie metadata service.
-func NewCategoryGateway(addr string) *CategoryGateway {
-	return &CategoryGateway{addr}
+func NewCategoryGateway(addr string, client IHTTPClient) *CategoryGateway {
+	return &CategoryGateway{addr, client}
 }
 func (g *CategoryGateway) Create(ctx context.Context, data *model.Category) (*model.Category, error) {
 	// Create a new HTTP request to the category creation endpoint
@@ -39,7 +40,7 @@ func (g *CategoryGateway) Create(ctx context.Context, data *model.Category) (*mo
 	}
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -68,14 +69,14 @@ func (g *CategoryGateway) Update(ctx context.Context, id model.CategoryID, data
 	}
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNotFound {
 		return nil, ErrNotFound
-	} else if resp.StatusCode != http.StatusOK {
+	} else if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		return nil, fmt.Errorf("non-2xx response: %v", resp)
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
@@ -92,7 +93,7 @@ func (g *CategoryGateway) Get(ctx context.Context, id model.CategoryID) (*model.
 	}
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -108,5 +109,16 @@ func (g *CategoryGateway) Get(ctx context.Context, id model.CategoryID) (*model.
 	return data, nil
 }
 
-// func (g *CategoryGateway) GetAll(ctx context.Context) ([]*model.Category, error)                    {}
-// func (g *CategoryGateway) Delete(ctx context.Context, id model.CategoryID) (*model.Category, error) {}
+// GetAll returns every category that is not deleted.
+func (g *CategoryGateway) GetAll(ctx context.Context) ([]*model.Category, error) {
+	var data []*model.Category
+	if err := doJSON(ctx, g.client, http.MethodGet, g.addr+"/categories", nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete soft deletes a category.
+func (g *CategoryGateway) Delete(ctx context.Context, id model.CategoryID) error {
+	return doJSON(ctx, g.client, http.MethodDelete, fmt.Sprintf("%s/categories/%d", g.addr, int(id)), nil, http.StatusNoContent, nil)
+}
@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"inventory.com/catalog/pkg/model"
+	discountModel "inventory.com/discount/pkg"
+)
+
+// DiscountGateway defines an HTTP gateway for the discount service.
+type DiscountGateway struct {
+	addr   string
+	client IHTTPClient
+}
+
+// NewDiscountGateway creates a new HTTP gateway for the discount service.
+func NewDiscountGateway(addr string, client IHTTPClient) *DiscountGateway {
+	return &DiscountGateway{addr, client}
+}
+
+// ForProduct returns the discounts of a product that have redemptions left.
+func (g *DiscountGateway) ForProduct(ctx context.Context, productID model.ProductID) ([]*discountModel.Discount, error) {
+	var data []*discountModel.Discount
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/discounts?productID=%d", g.addr, int(productID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-2xx response: %v", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// IHTTPClient sends the requests of the gateways. The services share a
+// resilient.Client so that every upstream instance has a single circuit breaker.
+type IHTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// doJSON sends body, when not nil, as JSON and decodes the response into out,
+// when not nil. Any status other than want fails; 404 fails with ErrNotFound.
+func doJSON(ctx context.Context, client IHTTPClient, method, url string, body any, want int, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	} else if resp.StatusCode != want {
+		return fmt.Errorf("non-2xx response: %v", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
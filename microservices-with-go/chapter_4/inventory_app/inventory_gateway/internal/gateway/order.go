@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"inventory.com/catalog/pkg/model"
+	orderModel "inventory.com/order/pkg/model"
+)
+
+// OrderGateway defines an HTTP gateway for the order service.
+type OrderGateway struct {
+	addr   string
+	client IHTTPClient
+}
+
+// NewOrderGateway creates a new HTTP gateway for the order service.
+func NewOrderGateway(addr string, client IHTTPClient) *OrderGateway {
+	return &OrderGateway{addr, client}
+}
+
+// CurrentStock returns the stock of a product derived from its completed orders.
+func (g *OrderGateway) CurrentStock(ctx context.Context, productID model.ProductID) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/orders/product/%d/stock", g.addr, int(productID)), nil)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("non-2xx response: %v", resp.Status)
+	}
+
+	var data struct {
+		CurrentStock int `json:"currentStock"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return 0, err
+	}
+	return data.CurrentStock, nil
+}
+
+// CurrentStocks returns the stock of many products with one request.
+func (g *OrderGateway) CurrentStocks(ctx context.Context, productIDs []model.ProductID) (map[model.ProductID]int, error) {
+	query := url.Values{}
+	for _, id := range productIDs {
+		query.Add("productID", strconv.Itoa(int(id)))
+	}
+	var data []struct {
+		ProductID    model.ProductID `json:"productID"`
+		CurrentStock int             `json:"currentStock"`
+	}
+	if err := doJSON(ctx, g.client, http.MethodGet, g.addr+"/orders/stock?"+query.Encode(), nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+
+	stocks := make(map[model.ProductID]int, len(data))
+	for _, s := range data {
+		stocks[s.ProductID] = s.CurrentStock
+	}
+	return stocks, nil
+}
+
+// Create places an order.
+func (g *OrderGateway) Create(ctx context.Context, data *orderModel.Order) (*orderModel.Order, error) {
+	var created *orderModel.Order
+	if err := doJSON(ctx, g.client, http.MethodPost, g.addr+"/orders/", data, http.StatusCreated, &created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Get returns an order.
+func (g *OrderGateway) Get(ctx context.Context, id orderModel.OrderID) (*orderModel.Order, error) {
+	var data *orderModel.Order
+	if err := doJSON(ctx, g.client, http.MethodGet, fmt.Sprintf("%s/orders/%d", g.addr, int(id)), nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetAll returns every order. The order service answers 404 when there are
+// none, which is reported as an empty list.
+func (g *OrderGateway) GetAll(ctx context.Context) ([]*orderModel.Order, error) {
+	var data []*orderModel.Order
+	err := doJSON(ctx, g.client, http.MethodGet, g.addr+"/orders/", nil, http.StatusOK, &data)
+	if errors.Is(err, ErrNotFound) {
+		return []*orderModel.Order{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
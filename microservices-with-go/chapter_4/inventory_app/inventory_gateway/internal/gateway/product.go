@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"inventory.com/catalog/pkg/model"
+)
+
+// ProductGateway defines an HTTP gateway for the products of the catalog service.
+type ProductGateway struct {
+	addr   string
+	client IHTTPClient
+}
+
+// NewProductGateway creates a new HTTP gateway for the catalog service products.
+func NewProductGateway(addr string, client IHTTPClient) *ProductGateway {
+	return &ProductGateway{addr, client}
+}
+
+// Get returns a product with its subcategory and category.
+func (g *ProductGateway) Get(ctx context.Context, id model.ProductID) (*model.ProductInformation, error) {
+	var data *model.ProductInformation
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/products/%d", g.addr, int(id)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-2xx response: %v", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetAll returns every product that is not deleted.
+func (g *ProductGateway) GetAll(ctx context.Context) ([]*model.ProductInformation, error) {
+	var data []*model.ProductInformation
+	if err := doJSON(ctx, g.client, http.MethodGet, g.addr+"/products", nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Create adds a product to a subcategory.
+func (g *ProductGateway) Create(ctx context.Context, data *model.ProductBasic) (*model.ProductBasic, error) {
+	var created *model.ProductBasic
+	if err := doJSON(ctx, g.client, http.MethodPost, g.addr+"/products", data, http.StatusCreated, &created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update replaces a product.
+func (g *ProductGateway) Update(ctx context.Context, id model.ProductID, data *model.ProductBasic) error {
+	return doJSON(ctx, g.client, http.MethodPut, fmt.Sprintf("%s/products/%d", g.addr, int(id)), data, http.StatusAccepted, nil)
+}
+
+// Delete soft deletes a product.
+func (g *ProductGateway) Delete(ctx context.Context, id model.ProductID) error {
+	return doJSON(ctx, g.client, http.MethodDelete, fmt.Sprintf("%s/products/%d", g.addr, int(id)), nil, http.StatusNoContent, nil)
+}
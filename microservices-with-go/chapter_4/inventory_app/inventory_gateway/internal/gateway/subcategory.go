@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"inventory.com/catalog/pkg/model"
+)
+
+// SubCategoryGateway defines an HTTP gateway for the subcategories of the catalog service.
+type SubCategoryGateway struct {
+	addr   string
+	client IHTTPClient
+}
+
+// NewSubCategoryGateway creates a new HTTP gateway for the catalog service subcategories.
+func NewSubCategoryGateway(addr string, client IHTTPClient) *SubCategoryGateway {
+	return &SubCategoryGateway{addr, client}
+}
+
+// Create adds a subcategory to a category.
+func (g *SubCategoryGateway) Create(ctx context.Context, data *model.SubCategoryBasic) (*model.SubCategoryBasic, error) {
+	var created *model.SubCategoryBasic
+	if err := doJSON(ctx, g.client, http.MethodPost, g.addr+"/subcategories", data, http.StatusCreated, &created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update replaces a subcategory.
+func (g *SubCategoryGateway) Update(ctx context.Context, id model.SubCategoryID, data *model.SubCategoryBasic) error {
+	return doJSON(ctx, g.client, http.MethodPut, fmt.Sprintf("%s/subcategories/%d", g.addr, int(id)), data, http.StatusAccepted, nil)
+}
+
+// Get returns a subcategory with its category.
+func (g *SubCategoryGateway) Get(ctx context.Context, id model.SubCategoryID) (*model.SubCategoryDetails, error) {
+	var data *model.SubCategoryDetails
+	if err := doJSON(ctx, g.client, http.MethodGet, fmt.Sprintf("%s/subcategories/%d", g.addr, int(id)), nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetAll returns every subcategory that is not deleted.
+func (g *SubCategoryGateway) GetAll(ctx context.Context) ([]*model.SubCategoryDetails, error) {
+	var data []*model.SubCategoryDetails
+	if err := doJSON(ctx, g.client, http.MethodGet, g.addr+"/subcategories", nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete soft deletes a subcategory.
+func (g *SubCategoryGateway) Delete(ctx context.Context, id model.SubCategoryID) error {
+	return doJSON(ctx, g.client, http.MethodDelete, fmt.Sprintf("%s/subcategories/%d", g.addr, int(id)), nil, http.StatusNoContent, nil)
+}
@@ -0,0 +1,125 @@
+// Package graph serves the inventory as a GraphQL schema. Its resolvers call
+// the catalog and order services through the gateway clients and batch the
+// lookups of one request with dataloaders, so that nested selections such as
+// categories { subCategories { products { stock } } } cost one upstream call
+// per level instead of one per item.
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	catalogModel "inventory.com/catalog/pkg/model"
+	orderModel "inventory.com/order/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/dataloader"
+)
+
+type ICategoryGateway interface {
+	Create(ctx context.Context, data *catalogModel.Category) (*catalogModel.Category, error)
+	Update(ctx context.Context, id catalogModel.CategoryID, data *catalogModel.Category) (*catalogModel.Category, error)
+	Get(ctx context.Context, id catalogModel.CategoryID) (*catalogModel.Category, error)
+	GetAll(ctx context.Context) ([]*catalogModel.Category, error)
+	Delete(ctx context.Context, id catalogModel.CategoryID) error
+}
+
+type ISubCategoryGateway interface {
+	Create(ctx context.Context, data *catalogModel.SubCategoryBasic) (*catalogModel.SubCategoryBasic, error)
+	Update(ctx context.Context, id catalogModel.SubCategoryID, data *catalogModel.SubCategoryBasic) error
+	Get(ctx context.Context, id catalogModel.SubCategoryID) (*catalogModel.SubCategoryDetails, error)
+	GetAll(ctx context.Context) ([]*catalogModel.SubCategoryDetails, error)
+	Delete(ctx context.Context, id catalogModel.SubCategoryID) error
+}
+
+type IProductGateway interface {
+	Create(ctx context.Context, data *catalogModel.ProductBasic) (*catalogModel.ProductBasic, error)
+	Update(ctx context.Context, id catalogModel.ProductID, data *catalogModel.ProductBasic) error
+	Get(ctx context.Context, id catalogModel.ProductID) (*catalogModel.ProductInformation, error)
+	GetAll(ctx context.Context) ([]*catalogModel.ProductInformation, error)
+	Delete(ctx context.Context, id catalogModel.ProductID) error
+}
+
+type IOrderGateway interface {
+	Create(ctx context.Context, data *orderModel.Order) (*orderModel.Order, error)
+	Get(ctx context.Context, id orderModel.OrderID) (*orderModel.Order, error)
+	GetAll(ctx context.Context) ([]*orderModel.Order, error)
+	CurrentStocks(ctx context.Context, productIDs []catalogModel.ProductID) (map[catalogModel.ProductID]int, error)
+}
+
+// maxParallelism bounds the resolvers run concurrently for one request. It
+// also bounds how many keys a dataloader batch can collect, as every list item
+// waits for its batch while holding its slot.
+const maxParallelism = 100
+
+// Resolver executes GraphQL requests against the inventory schema. It is the
+// root resolver: its methods resolve the fields of Query and Mutation.
+type Resolver struct {
+	categories    ICategoryGateway
+	subCategories ISubCategoryGateway
+	products      IProductGateway
+	orders        IOrderGateway
+	schema        *graphql.Schema
+	readOnly      *graphql.Schema
+}
+
+func NewResolver(categories ICategoryGateway, subCategories ISubCategoryGateway, products IProductGateway, orders IOrderGateway) *Resolver {
+	r := &Resolver{categories: categories, subCategories: subCategories, products: products, orders: orders}
+	opts := []graphql.SchemaOpt{graphql.UseStringDescriptions(), graphql.MaxParallelism(maxParallelism)}
+	r.schema = graphql.MustParseSchema(querySchema+mutationSchema, r, opts...)
+	r.readOnly = graphql.MustParseSchema(querySchema, r, opts...)
+	return r
+}
+
+// Execute runs a request with its own set of dataloaders.
+func (r *Resolver) Execute(ctx context.Context, query, operationName string, variables map[string]any) *graphql.Response {
+	ctx = context.WithValue(ctx, loadersKey{}, r.newLoaders())
+	return r.schema.Exec(ctx, query, operationName, variables)
+}
+
+// ExecuteQuery runs a request like Execute against the schema without
+// mutations, which rejects them.
+func (r *Resolver) ExecuteQuery(ctx context.Context, query, operationName string, variables map[string]any) *graphql.Response {
+	ctx = context.WithValue(ctx, loadersKey{}, r.newLoaders())
+	return r.readOnly.Exec(ctx, query, operationName, variables)
+}
+
+// SDL describes the schema in the GraphQL schema definition language.
+func (r *Resolver) SDL() string {
+	return querySchema + mutationSchema
+}
+
+// idArg parses an ID argument. Inventory IDs are integers.
+func idArg(id graphql.ID, name string) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, id)
+	}
+	return n, nil
+}
+
+// toID formats an inventory ID as a GraphQL ID.
+func toID[K ~int](id K) graphql.ID {
+	return graphql.ID(strconv.Itoa(int(id)))
+}
+
+// requires resolves a field only for callers granted perm. A denied field is
+// null with an error, like any other failed field.
+func requires[T any](ctx context.Context, perm auth.Permission, resolve func() (T, error)) (T, error) {
+	if err := auth.Check(ctx, perm); err != nil {
+		var zero T
+		return zero, err
+	}
+	return resolve()
+}
+
+// orNull turns a missing key into a null field instead of an error.
+func orNull[V any](v V, err error) (V, error) {
+	if errors.Is(err, dataloader.ErrNotFound) {
+		var zero V
+		return zero, nil
+	}
+	return v, err
+}
@@ -0,0 +1,300 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/inventory_gateway/internal/gateway"
+	"inventory.com/order/pkg/enums"
+	orderModel "inventory.com/order/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/money"
+)
+
+// fakeUpstream implements every gateway interface over fixed data and counts
+// the calls made to it.
+type fakeUpstream struct {
+	mu            sync.Mutex
+	calls         map[string]int
+	categories    []*catalogModel.Category
+	subCategories []*catalogModel.SubCategoryDetails
+	products      []*catalogModel.ProductInformation
+	orders        []*orderModel.Order
+}
+
+func (f *fakeUpstream) count(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[call]++
+}
+
+type fakeCategories struct{ *fakeUpstream }
+
+func (f fakeCategories) Create(ctx context.Context, data *catalogModel.Category) (*catalogModel.Category, error) {
+	f.count("categories.Create")
+	data.ID = catalogModel.CategoryID(len(f.categories) + 1)
+	f.categories = append(f.categories, data)
+	return data, nil
+}
+
+func (f fakeCategories) Update(ctx context.Context, id catalogModel.CategoryID, data *catalogModel.Category) (*catalogModel.Category, error) {
+	return nil, nil
+}
+
+func (f fakeCategories) Get(ctx context.Context, id catalogModel.CategoryID) (*catalogModel.Category, error) {
+	f.count("categories.Get")
+	for _, c := range f.categories {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, gateway.ErrNotFound
+}
+
+func (f fakeCategories) GetAll(ctx context.Context) ([]*catalogModel.Category, error) {
+	f.count("categories.GetAll")
+	return f.categories, nil
+}
+
+func (f fakeCategories) Delete(ctx context.Context, id catalogModel.CategoryID) error { return nil }
+
+type fakeSubCategories struct{ *fakeUpstream }
+
+func (f fakeSubCategories) Create(ctx context.Context, data *catalogModel.SubCategoryBasic) (*catalogModel.SubCategoryBasic, error) {
+	return nil, nil
+}
+
+func (f fakeSubCategories) Update(ctx context.Context, id catalogModel.SubCategoryID, data *catalogModel.SubCategoryBasic) error {
+	return nil
+}
+
+func (f fakeSubCategories) Get(ctx context.Context, id catalogModel.SubCategoryID) (*catalogModel.SubCategoryDetails, error) {
+	f.count("subCategories.Get")
+	return nil, gateway.ErrNotFound
+}
+
+func (f fakeSubCategories) GetAll(ctx context.Context) ([]*catalogModel.SubCategoryDetails, error) {
+	f.count("subCategories.GetAll")
+	return f.subCategories, nil
+}
+
+func (f fakeSubCategories) Delete(ctx context.Context, id catalogModel.SubCategoryID) error {
+	return nil
+}
+
+type fakeProducts struct{ *fakeUpstream }
+
+func (f fakeProducts) Create(ctx context.Context, data *catalogModel.ProductBasic) (*catalogModel.ProductBasic, error) {
+	return nil, nil
+}
+
+func (f fakeProducts) Update(ctx context.Context, id catalogModel.ProductID, data *catalogModel.ProductBasic) error {
+	return nil
+}
+
+func (f fakeProducts) Get(ctx context.Context, id catalogModel.ProductID) (*catalogModel.ProductInformation, error) {
+	f.count("products.Get")
+	for _, p := range f.products {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, gateway.ErrNotFound
+}
+
+func (f fakeProducts) GetAll(ctx context.Context) ([]*catalogModel.ProductInformation, error) {
+	f.count("products.GetAll")
+	return f.products, nil
+}
+
+func (f fakeProducts) Delete(ctx context.Context, id catalogModel.ProductID) error { return nil }
+
+type fakeOrders struct{ *fakeUpstream }
+
+func (f fakeOrders) Create(ctx context.Context, data *orderModel.Order) (*orderModel.Order, error) {
+	f.count("orders.Create")
+	data.ID = orderModel.OrderID(len(f.orders) + 1)
+	f.orders = append(f.orders, data)
+	return data, nil
+}
+
+func (f fakeOrders) Get(ctx context.Context, id orderModel.OrderID) (*orderModel.Order, error) {
+	return nil, gateway.ErrNotFound
+}
+
+func (f fakeOrders) GetAll(ctx context.Context) ([]*orderModel.Order, error) {
+	f.count("orders.GetAll")
+	return f.orders, nil
+}
+
+func (f fakeOrders) CurrentStocks(ctx context.Context, productIDs []catalogModel.ProductID) (map[catalogModel.ProductID]int, error) {
+	f.count("orders.CurrentStocks")
+	stocks := make(map[catalogModel.ProductID]int, len(productIDs))
+	for _, id := range productIDs {
+		stocks[id] = int(id) * 10
+	}
+	return stocks, nil
+}
+
+func newTestResolver(t *testing.T) (*Resolver, *fakeUpstream) {
+	t.Helper()
+	usd := func(amount string) money.Money {
+		m, err := money.Parse(amount, "USD")
+		require.NoError(t, err)
+		return m
+	}
+	tv := &catalogModel.Category{ID: 1, Name: "TV"}
+	audio := &catalogModel.Category{ID: 2, Name: "Audio"}
+	oled := &catalogModel.SubCategoryDetails{SubCategoryBaseInfo: catalogModel.SubCategoryBaseInfo{ID: 1, Name: "OLED"}, Category: tv}
+	lcd := &catalogModel.SubCategoryDetails{SubCategoryBaseInfo: catalogModel.SubCategoryBaseInfo{ID: 2, Name: "LCD"}, Category: tv}
+	speakers := &catalogModel.SubCategoryDetails{SubCategoryBaseInfo: catalogModel.SubCategoryBaseInfo{ID: 3, Name: "Speakers"}, Category: audio}
+	product := func(id int, name string, sub *catalogModel.SubCategoryDetails) *catalogModel.ProductInformation {
+		return &catalogModel.ProductInformation{
+			ProductBaseInfo:    catalogModel.ProductBaseInfo{ID: catalogModel.ProductID(id), Name: name, ListCost: usd("100.00")},
+			SubCategoryDetails: sub,
+		}
+	}
+
+	up := &fakeUpstream{
+		calls:         map[string]int{},
+		categories:    []*catalogModel.Category{tv, audio},
+		subCategories: []*catalogModel.SubCategoryDetails{oled, lcd, speakers},
+		products:      []*catalogModel.ProductInformation{product(1, "C3", oled), product(2, "G4", oled), product(3, "Bravia", lcd)},
+		orders: []*orderModel.Order{
+			{ID: 1, ProductID: 1, Quantity: 2, Price: usd("80.00"), Type: enums.OrderTypeBuy, Status: enums.OrderStatusCompleted},
+			{ID: 2, ProductID: 3, Quantity: 1, Price: usd("90.00"), Type: enums.OrderTypeSale},
+		},
+	}
+	return NewResolver(fakeCategories{up}, fakeSubCategories{up}, fakeProducts{up}, fakeOrders{up}), up
+}
+
+// admin is the caller of the tests unless they say otherwise.
+var admin = &auth.Principal{Subject: "test", Roles: []string{auth.RoleAdmin}}
+
+func run(t *testing.T, r *Resolver, query string, vars map[string]any) string {
+	t.Helper()
+	return runAs(t, r, admin, query, vars)
+}
+
+func runAs(t *testing.T, r *Resolver, caller *auth.Principal, query string, vars map[string]any) string {
+	t.Helper()
+	ctx := context.Background()
+	if caller != nil {
+		ctx = auth.WithPrincipal(ctx, caller)
+	}
+	resp := r.Execute(ctx, query, "", vars)
+	out, err := json.Marshal(resp)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestResolver_NestedQueryIsBatched(t *testing.T) {
+	r, up := newTestResolver(t)
+
+	out := run(t, r, `{
+		categories {
+			name
+			subCategories {
+				name
+				products { id name stock listCost { amount currency } orders { quantity type status } }
+			}
+		}
+	}`, nil)
+
+	assert.JSONEq(t, `{"data":{"categories":[
+		{"name":"TV","subCategories":[
+			{"name":"OLED","products":[
+				{"id":"1","name":"C3","stock":10,"listCost":{"amount":"100.00","currency":"USD"},"orders":[{"quantity":2,"type":"BUY","status":"COMPLETED"}]},
+				{"id":"2","name":"G4","stock":20,"listCost":{"amount":"100.00","currency":"USD"},"orders":[]}]},
+			{"name":"LCD","products":[
+				{"id":"3","name":"Bravia","stock":30,"listCost":{"amount":"100.00","currency":"USD"},"orders":[{"quantity":1,"type":"SALE","status":"PENDING"}]}]}]},
+		{"name":"Audio","subCategories":[{"name":"Speakers","products":[]}]}]}}`, out)
+	assert.Equal(t, map[string]int{
+		"categories.GetAll":    1,
+		"subCategories.GetAll": 1,
+		"products.GetAll":      1,
+		"orders.GetAll":        1,
+		"orders.CurrentStocks": 1,
+	}, up.calls)
+}
+
+func TestResolver_ProductsOfOrdersAreBatched(t *testing.T) {
+	r, up := newTestResolver(t)
+
+	out := run(t, r, `{ orders { id total { amount } product { name subCategory { name category { name } } } } }`, nil)
+
+	assert.JSONEq(t, `{"data":{"orders":[
+		{"id":"1","total":{"amount":"160.00"},"product":{"name":"C3","subCategory":{"name":"OLED","category":{"name":"TV"}}}},
+		{"id":"2","total":{"amount":"90.00"},"product":{"name":"Bravia","subCategory":{"name":"LCD","category":{"name":"TV"}}}}]}}`, out)
+	assert.Equal(t, 1, up.calls["products.GetAll"])
+	assert.Zero(t, up.calls["products.Get"])
+}
+
+func TestResolver_LookupByID(t *testing.T) {
+	r, up := newTestResolver(t)
+
+	out := run(t, r, `query ($id: ID!) { product(id: $id) { name } missing: category(id: 9) { name } }`, map[string]any{"id": "2"})
+
+	assert.JSONEq(t, `{"data":{"product":{"name":"G4"},"missing":null}}`, out)
+	assert.Equal(t, 1, up.calls["products.Get"])
+	assert.Equal(t, 1, up.calls["categories.Get"])
+}
+
+func TestResolver_Mutations(t *testing.T) {
+	r, up := newTestResolver(t)
+
+	out := run(t, r, `mutation {
+		createCategory(input: {name: "Gaming", attributes: [{name: "platform", type: "string"}]}) { id name attributes { name type required } }
+		createOrder(input: {productID: "2", quantity: 5, price: {amount: "70.00", currency: "USD"}, type: BUY}) {
+			id type status price { minorUnits } product { name orders { id } }
+		}
+	}`, nil)
+
+	assert.JSONEq(t, `{"data":{
+		"createCategory":{"id":"3","name":"Gaming","attributes":[{"name":"platform","type":"string","required":false}]},
+		"createOrder":{"id":"3","type":"BUY","status":"PENDING","price":{"minorUnits":7000},"product":{"name":"G4","orders":[{"id":"3"}]}}}}`, out)
+	assert.Equal(t, 1, up.calls["orders.Create"])
+
+	out = run(t, r, `mutation { createOrder(input: {productID: "2", quantity: 1, price: {amount: "1.001", currency: "USD"}, type: SALE}) { id } }`, nil)
+	assert.Contains(t, out, `"data":null`)
+	assert.Contains(t, out, `invalid amount`)
+}
+
+func TestResolver_Permissions(t *testing.T) {
+	r, up := newTestResolver(t)
+	viewer := &auth.Principal{Subject: "v", Roles: []string{auth.RoleViewer}}
+	clerk := &auth.Principal{Subject: "c", Roles: []string{auth.RoleOrderClerk}}
+
+	out := runAs(t, r, nil, `{ categories { name } }`, nil)
+	assert.Contains(t, out, `"data":null`)
+	assert.Contains(t, out, auth.ErrUnauthenticated.Error())
+
+	out = runAs(t, r, viewer, `{ category(id: 1) { name } }`, nil)
+	assert.JSONEq(t, `{"data":{"category":{"name":"TV"}}}`, out)
+
+	out = runAs(t, r, viewer, `mutation { createCategory(input: {name: "Gaming"}) { id } }`, nil)
+	assert.Contains(t, out, auth.ErrForbidden.Error())
+	assert.Zero(t, up.calls["categories.Create"])
+
+	out = runAs(t, r, clerk, `mutation { createOrder(input: {productID: "1", quantity: 1, price: {amount: "1.00", currency: "USD"}, type: BUY}) { id } }`, nil)
+	assert.JSONEq(t, `{"data":{"createOrder":{"id":"3"}}}`, out)
+}
+
+func TestResolver_ExecuteQuery_RejectsMutations(t *testing.T) {
+	r, up := newTestResolver(t)
+	ctx := auth.WithPrincipal(context.Background(), admin)
+
+	resp := r.ExecuteQuery(ctx, `mutation { createCategory(input: {name: "Gaming"}) { id } }`, "", nil)
+	require.NotEmpty(t, resp.Errors)
+	assert.Nil(t, resp.Data)
+	assert.Zero(t, up.calls["categories.Create"])
+
+	resp = r.ExecuteQuery(ctx, `{ category(id: 1) { name } }`, "", nil)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"category":{"name":"TV"}}`, string(resp.Data))
+}
@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/inventory_gateway/internal/gateway"
+	orderModel "inventory.com/order/pkg/model"
+	"inventory.com/pkg/dataloader"
+)
+
+// loaderWait is how long a loader waits for more keys before calling its
+// upstream. Sibling fields are resolved concurrently and request their keys
+// within microseconds of each other.
+const loaderWait = 2 * time.Millisecond
+
+// loaders batch the upstream calls made while resolving one request. Lookups
+// of many IDs fetch the whole collection once instead of one item per ID.
+type loaders struct {
+	categoryByID            *dataloader.Loader[catalogModel.CategoryID, *catalogModel.Category]
+	subCategoryByID         *dataloader.Loader[catalogModel.SubCategoryID, *catalogModel.SubCategoryDetails]
+	subCategoriesByCategory *dataloader.Loader[catalogModel.CategoryID, []*catalogModel.SubCategoryDetails]
+	productByID             *dataloader.Loader[catalogModel.ProductID, *catalogModel.ProductInformation]
+	productsBySubCategory   *dataloader.Loader[catalogModel.SubCategoryID, []*catalogModel.ProductInformation]
+	ordersByProduct         *dataloader.Loader[catalogModel.ProductID, []*orderModel.Order]
+	stockByProduct          *dataloader.Loader[catalogModel.ProductID, int]
+}
+
+func (r *Resolver) newLoaders() *loaders {
+	return &loaders{
+		categoryByID: dataloader.New(byID(r.categories.Get, r.categories.GetAll, func(c *catalogModel.Category) catalogModel.CategoryID {
+			return c.ID
+		}), loaderWait, 0),
+		subCategoryByID: dataloader.New(byID(r.subCategories.Get, r.subCategories.GetAll, func(s *catalogModel.SubCategoryDetails) catalogModel.SubCategoryID {
+			return s.ID
+		}), loaderWait, 0),
+		subCategoriesByCategory: dataloader.New(groupBy(r.subCategories.GetAll, func(s *catalogModel.SubCategoryDetails) catalogModel.CategoryID {
+			if s.Category == nil {
+				return 0
+			}
+			return s.Category.ID
+		}), loaderWait, 0),
+		productByID: dataloader.New(byID(r.products.Get, r.products.GetAll, func(p *catalogModel.ProductInformation) catalogModel.ProductID {
+			return p.ID
+		}), loaderWait, 0),
+		productsBySubCategory: dataloader.New(groupBy(r.products.GetAll, func(p *catalogModel.ProductInformation) catalogModel.SubCategoryID {
+			if p.SubCategoryDetails == nil {
+				return 0
+			}
+			return p.SubCategoryDetails.ID
+		}), loaderWait, 0),
+		ordersByProduct: dataloader.New(groupBy(r.orders.GetAll, func(o *orderModel.Order) catalogModel.ProductID {
+			return o.ProductID
+		}), loaderWait, 0),
+		stockByProduct: dataloader.New(r.orders.CurrentStocks, loaderWait, 0),
+	}
+}
+
+// reset drops everything loaded so far. Mutations call it so that fields
+// selected on their result see the change.
+func (l *loaders) reset() {
+	l.categoryByID.ClearAll()
+	l.subCategoryByID.ClearAll()
+	l.subCategoriesByCategory.ClearAll()
+	l.productByID.ClearAll()
+	l.productsBySubCategory.ClearAll()
+	l.ordersByProduct.ClearAll()
+	l.stockByProduct.ClearAll()
+}
+
+type loadersKey struct{}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// byID loads a single key with get and several keys with one call to getAll.
+func byID[K comparable, V any](get func(context.Context, K) (V, error), getAll func(context.Context) ([]V, error), id func(V) K) dataloader.BatchFunc[K, V] {
+	return func(ctx context.Context, keys []K) (map[K]V, error) {
+		if len(keys) == 1 {
+			v, err := get(ctx, keys[0])
+			if errors.Is(err, gateway.ErrNotFound) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return map[K]V{keys[0]: v}, nil
+		}
+
+		all, err := getAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[K]V, len(all))
+		for _, v := range all {
+			values[id(v)] = v
+		}
+		return values, nil
+	}
+}
+
+// groupBy loads the whole collection once and groups it by key. Keys without
+// items get an empty list.
+func groupBy[K comparable, V any](getAll func(context.Context) ([]V, error), key func(V) K) dataloader.BatchFunc[K, []V] {
+	return func(ctx context.Context, keys []K) (map[K][]V, error) {
+		all, err := getAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		groups := make(map[K][]V, len(keys))
+		for _, k := range keys {
+			groups[k] = []V{}
+		}
+		for _, v := range all {
+			if k := key(v); groups[k] != nil {
+				groups[k] = append(groups[k], v)
+			}
+		}
+		return groups, nil
+	}
+}
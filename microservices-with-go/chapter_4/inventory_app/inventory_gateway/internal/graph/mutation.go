@@ -0,0 +1,347 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	catalogModel "inventory.com/catalog/pkg/model"
+	orderModel "inventory.com/order/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/money"
+)
+
+// mutationSchema declares the mutations, resolved by the methods of Resolver
+// below.
+const mutationSchema = `
+input MoneyInput {
+	amount: String!
+	currency: String!
+}
+
+input AttributeDefinitionInput {
+	name: String!
+	type: String!
+	required: Boolean
+	unit: String
+	values: [String!]
+}
+
+input CategoryInput {
+	name: String!
+	attributes: [AttributeDefinitionInput!]
+}
+
+input SubCategoryInput {
+	name: String!
+	categoryID: ID!
+}
+
+input ProductInput {
+	name: String!
+	description: String
+	manufacturer: String
+	listCost: MoneyInput!
+	attributes: JSON
+	subCategoryID: ID!
+}
+
+input OrderInput {
+	productID: ID!
+	quantity: Int!
+	"""Price per unit."""
+	price: MoneyInput!
+	type: OrderType!
+	customerID: Int
+	metadata: JSON
+}
+
+type Mutation {
+	createCategory(input: CategoryInput!): Category!
+	updateCategory(id: ID!, input: CategoryInput!): Category!
+	deleteCategory(id: ID!): Boolean!
+	createSubCategory(input: SubCategoryInput!): SubCategory!
+	updateSubCategory(id: ID!, input: SubCategoryInput!): SubCategory!
+	deleteSubCategory(id: ID!): Boolean!
+	createProduct(input: ProductInput!): Product!
+	updateProduct(id: ID!, input: ProductInput!): Product!
+	deleteProduct(id: ID!): Boolean!
+	createOrder(input: OrderInput!): Order!
+}
+`
+
+// mutation runs a mutation resolver only for callers granted perm and drops
+// what the request loaded so far, so that the fields selected on its result
+// are loaded after the change rather than from the request cache.
+func mutation[T any](ctx context.Context, perm auth.Permission, resolve func() (T, error)) (T, error) {
+	if err := auth.Check(ctx, perm); err != nil {
+		var zero T
+		return zero, err
+	}
+	result, err := resolve()
+	loadersFrom(ctx).reset()
+	return result, err
+}
+
+type moneyInput struct {
+	Amount   string
+	Currency string
+}
+
+func (in moneyInput) money() (money.Money, error) {
+	return money.Parse(in.Amount, money.Currency(in.Currency))
+}
+
+type attributeDefinitionInput struct {
+	Name     string
+	Type     string
+	Required *bool
+	Unit     *string
+	Values   *[]string
+}
+
+type categoryInput struct {
+	Name       string
+	Attributes *[]attributeDefinitionInput
+}
+
+func (in categoryInput) category() *catalogModel.Category {
+	category := &catalogModel.Category{Name: in.Name}
+	if in.Attributes == nil {
+		return category
+	}
+	for _, a := range *in.Attributes {
+		def := catalogModel.AttributeDefinition{Name: a.Name, Type: catalogModel.AttributeType(a.Type)}
+		if a.Required != nil {
+			def.Required = *a.Required
+		}
+		if a.Unit != nil {
+			def.Unit = *a.Unit
+		}
+		if a.Values != nil {
+			def.Values = *a.Values
+		}
+		category.Attributes = append(category.Attributes, def)
+	}
+	return category
+}
+
+type subCategoryInput struct {
+	Name       string
+	CategoryID graphql.ID
+}
+
+func (in subCategoryInput) basic() (*catalogModel.SubCategoryBasic, error) {
+	categoryID, err := idArg(in.CategoryID, "categoryID")
+	if err != nil {
+		return nil, err
+	}
+	return &catalogModel.SubCategoryBasic{
+		BaseInfo: catalogModel.SubCategoryBaseInfo{Name: in.Name},
+		CatID:    catalogModel.CategoryID(categoryID),
+	}, nil
+}
+
+type productInput struct {
+	Name          string
+	Description   *string
+	Manufacturer  *string
+	ListCost      moneyInput
+	Attributes    *jsonValue
+	SubCategoryID graphql.ID
+}
+
+func (in productInput) basic() (*catalogModel.ProductBasic, error) {
+	subCategoryID, err := idArg(in.SubCategoryID, "subCategoryID")
+	if err != nil {
+		return nil, err
+	}
+	listCost, err := in.ListCost.money()
+	if err != nil {
+		return nil, err
+	}
+	product := &catalogModel.ProductBasic{
+		ProductBaseInfo: catalogModel.ProductBaseInfo{Name: in.Name, ListCost: listCost},
+		SubCatID:        catalogModel.SubCategoryID(subCategoryID),
+	}
+	if in.Description != nil {
+		product.Description = *in.Description
+	}
+	if in.Manufacturer != nil {
+		product.Manufacturer = *in.Manufacturer
+	}
+	if err := in.Attributes.decode(&product.Attributes); err != nil {
+		return nil, fmt.Errorf("invalid attributes: %w", err)
+	}
+	return product, nil
+}
+
+type orderInput struct {
+	ProductID  graphql.ID
+	Quantity   int32
+	Price      moneyInput
+	Type       string
+	CustomerID *int32
+	Metadata   *jsonValue
+}
+
+func (in orderInput) order() (*orderModel.Order, error) {
+	productID, err := idArg(in.ProductID, "productID")
+	if err != nil {
+		return nil, err
+	}
+	price, err := in.Price.money()
+	if err != nil {
+		return nil, err
+	}
+	order := &orderModel.Order{ProductID: catalogModel.ProductID(productID), Quantity: int(in.Quantity), Price: price}
+	for orderType, name := range orderTypes {
+		if name == in.Type {
+			order.Type = orderType
+		}
+	}
+	if in.CustomerID != nil {
+		order.CustomerID = int(*in.CustomerID)
+	}
+	if err := in.Metadata.decode(&order.Metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	return order, nil
+}
+
+func (r *Resolver) CreateCategory(ctx context.Context, args struct{ Input categoryInput }) (*categoryResolver, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (*categoryResolver, error) {
+		created, err := r.categories.Create(ctx, args.Input.category())
+		return wrap(created, newCategoryResolver), err
+	})
+}
+
+func (r *Resolver) UpdateCategory(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input categoryInput
+}) (*categoryResolver, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (*categoryResolver, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.categories.Update(ctx, catalogModel.CategoryID(id), args.Input.category()); err != nil {
+			return nil, err
+		}
+		updated, err := r.categories.Get(ctx, catalogModel.CategoryID(id))
+		return wrap(updated, newCategoryResolver), err
+	})
+}
+
+func (r *Resolver) DeleteCategory(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (bool, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return false, err
+		}
+		return true, r.categories.Delete(ctx, catalogModel.CategoryID(id))
+	})
+}
+
+func (r *Resolver) CreateSubCategory(ctx context.Context, args struct{ Input subCategoryInput }) (*subCategoryResolver, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (*subCategoryResolver, error) {
+		in, err := args.Input.basic()
+		if err != nil {
+			return nil, err
+		}
+		created, err := r.subCategories.Create(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		subCategory, err := r.subCategories.Get(ctx, created.BaseInfo.ID)
+		return wrap(subCategory, newSubCategoryResolver), err
+	})
+}
+
+func (r *Resolver) UpdateSubCategory(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input subCategoryInput
+}) (*subCategoryResolver, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (*subCategoryResolver, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+		in, err := args.Input.basic()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.subCategories.Update(ctx, catalogModel.SubCategoryID(id), in); err != nil {
+			return nil, err
+		}
+		subCategory, err := r.subCategories.Get(ctx, catalogModel.SubCategoryID(id))
+		return wrap(subCategory, newSubCategoryResolver), err
+	})
+}
+
+func (r *Resolver) DeleteSubCategory(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (bool, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return false, err
+		}
+		return true, r.subCategories.Delete(ctx, catalogModel.SubCategoryID(id))
+	})
+}
+
+func (r *Resolver) CreateProduct(ctx context.Context, args struct{ Input productInput }) (*productResolver, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (*productResolver, error) {
+		in, err := args.Input.basic()
+		if err != nil {
+			return nil, err
+		}
+		created, err := r.products.Create(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		product, err := r.products.Get(ctx, created.ID)
+		return wrap(product, newProductResolver), err
+	})
+}
+
+func (r *Resolver) UpdateProduct(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input productInput
+}) (*productResolver, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (*productResolver, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+		in, err := args.Input.basic()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.products.Update(ctx, catalogModel.ProductID(id), in); err != nil {
+			return nil, err
+		}
+		product, err := r.products.Get(ctx, catalogModel.ProductID(id))
+		return wrap(product, newProductResolver), err
+	})
+}
+
+func (r *Resolver) DeleteProduct(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	return mutation(ctx, auth.PermCatalogWrite, func() (bool, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return false, err
+		}
+		return true, r.products.Delete(ctx, catalogModel.ProductID(id))
+	})
+}
+
+func (r *Resolver) CreateOrder(ctx context.Context, args struct{ Input orderInput }) (*orderResolver, error) {
+	return mutation(ctx, auth.PermOrdersWrite, func() (*orderResolver, error) {
+		in, err := args.Input.order()
+		if err != nil {
+			return nil, err
+		}
+		created, err := r.orders.Create(ctx, in)
+		return wrap(created, newOrderResolver), err
+	})
+}
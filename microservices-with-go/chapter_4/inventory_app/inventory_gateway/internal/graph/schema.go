@@ -0,0 +1,418 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/inventory_gateway/internal/gateway"
+	"inventory.com/order/pkg/enums"
+	orderModel "inventory.com/order/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/money"
+)
+
+// querySchema declares the types and queries of the inventory. The methods of
+// Resolver and of the *Resolver types below resolve its fields.
+const querySchema = `
+"""Any JSON value."""
+scalar JSON
+
+enum OrderType { SALE BUY RETURN }
+
+enum OrderStatus { PENDING COMPLETED CANCELLED }
+
+type Money {
+	"""Decimal amount, e.g. "19.99"."""
+	amount: String!
+	currency: String!
+	minorUnits: Int!
+}
+
+type AttributeDefinition {
+	name: String!
+	type: String!
+	required: Boolean!
+	unit: String
+	values: [String!]
+}
+
+type Category {
+	id: ID!
+	name: String!
+	version: Int!
+	attributes: [AttributeDefinition!]
+	subCategories: [SubCategory!]!
+}
+
+type SubCategory {
+	id: ID!
+	name: String!
+	version: Int!
+	category: Category
+	products: [Product!]!
+}
+
+type Product {
+	id: ID!
+	name: String!
+	description: String!
+	manufacturer: String!
+	listCost: Money!
+	attributes: JSON
+	version: Int!
+	subCategory: SubCategory
+	"""Units in stock according to the completed orders."""
+	stock: Int
+	orders: [Order!]!
+}
+
+type Order {
+	id: ID!
+	productID: ID!
+	product: Product
+	quantity: Int!
+	"""Price per unit."""
+	price: Money!
+	total: Money
+	type: OrderType!
+	status: OrderStatus!
+	customerID: Int!
+	metadata: JSON
+	createdAt: String!
+	updatedAt: String!
+}
+
+type Query {
+	categories: [Category!]!
+	category(id: ID!): Category
+	subCategories: [SubCategory!]!
+	subCategory(id: ID!): SubCategory
+	products: [Product!]!
+	product(id: ID!): Product
+	orders: [Order!]!
+	order(id: ID!): Order
+	stock(productID: ID!): Int!
+}
+`
+
+// orderTypes and orderStatuses name the order enums in the schema.
+var (
+	orderTypes = map[enums.OrderType]string{
+		enums.OrderTypeSale:   "SALE",
+		enums.OrderTypeBuy:    "BUY",
+		enums.OrderTypeReturn: "RETURN",
+	}
+	orderStatuses = map[enums.OrderStatus]string{
+		enums.OrderStatusPending:   "PENDING",
+		enums.OrderStatusCompleted: "COMPLETED",
+		enums.OrderStatusCancelled: "CANCELLED",
+	}
+)
+
+// jsonValue is the JSON scalar. It passes free-form values, such as product
+// attributes and order metadata, through unchanged.
+type jsonValue struct{ value any }
+
+func (jsonValue) ImplementsGraphQLType(name string) bool { return name == "JSON" }
+
+func (v *jsonValue) UnmarshalGraphQL(input any) error {
+	v.value = input
+	return nil
+}
+
+func (v jsonValue) MarshalJSON() ([]byte, error) { return json.Marshal(v.value) }
+
+// decode converts the value to target through its JSON encoding.
+func (v *jsonValue) decode(target any) error {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v.value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// toJSON returns the JSON scalar of v, or null when v is empty.
+func toJSON[M ~map[string]V, V any](v M) *jsonValue {
+	if v == nil {
+		return nil
+	}
+	return &jsonValue{v}
+}
+
+// int32Of converts a number to a GraphQL Int, which is 32 bits wide.
+func int32Of[N ~int | ~int64](n N) (int32, error) {
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("%d does not fit in an Int", n)
+	}
+	return int32(n), nil
+}
+
+type moneyResolver struct{ m money.Money }
+
+func (r *moneyResolver) Amount() string   { return r.m.Decimal() }
+func (r *moneyResolver) Currency() string { return string(r.m.Currency) }
+
+func (r *moneyResolver) MinorUnits() (int32, error) { return int32Of(r.m.Amount) }
+
+type attributeDefinitionResolver struct {
+	d catalogModel.AttributeDefinition
+}
+
+func (r *attributeDefinitionResolver) Name() string   { return r.d.Name }
+func (r *attributeDefinitionResolver) Type() string   { return string(r.d.Type) }
+func (r *attributeDefinitionResolver) Required() bool { return r.d.Required }
+
+func (r *attributeDefinitionResolver) Unit() *string {
+	if r.d.Unit == "" {
+		return nil
+	}
+	return &r.d.Unit
+}
+
+func (r *attributeDefinitionResolver) Values() *[]string {
+	if r.d.Values == nil {
+		return nil
+	}
+	return &r.d.Values
+}
+
+type categoryResolver struct{ c *catalogModel.Category }
+
+func newCategoryResolver(c *catalogModel.Category) *categoryResolver {
+	return &categoryResolver{c}
+}
+
+func (r *categoryResolver) ID() graphql.ID { return toID(r.c.ID) }
+func (r *categoryResolver) Name() string   { return r.c.Name }
+func (r *categoryResolver) Version() int32 { return int32(r.c.Version) }
+
+func (r *categoryResolver) Attributes() *[]*attributeDefinitionResolver {
+	if r.c.Attributes == nil {
+		return nil
+	}
+	defs := make([]*attributeDefinitionResolver, len(r.c.Attributes))
+	for i, d := range r.c.Attributes {
+		defs[i] = &attributeDefinitionResolver{d}
+	}
+	return &defs
+}
+
+func (r *categoryResolver) SubCategories(ctx context.Context) ([]*subCategoryResolver, error) {
+	subCategories, err := loadersFrom(ctx).subCategoriesByCategory.Load(ctx, r.c.ID)
+	return wrapAll(subCategories, newSubCategoryResolver), err
+}
+
+type subCategoryResolver struct {
+	s *catalogModel.SubCategoryDetails
+}
+
+func newSubCategoryResolver(s *catalogModel.SubCategoryDetails) *subCategoryResolver {
+	return &subCategoryResolver{s}
+}
+
+func (r *subCategoryResolver) ID() graphql.ID { return toID(r.s.ID) }
+func (r *subCategoryResolver) Name() string   { return r.s.Name }
+func (r *subCategoryResolver) Version() int32 { return int32(r.s.Version) }
+
+func (r *subCategoryResolver) Category() *categoryResolver {
+	return wrap(r.s.Category, newCategoryResolver)
+}
+
+func (r *subCategoryResolver) Products(ctx context.Context) ([]*productResolver, error) {
+	products, err := loadersFrom(ctx).productsBySubCategory.Load(ctx, r.s.ID)
+	return wrapAll(products, newProductResolver), err
+}
+
+type productResolver struct {
+	p *catalogModel.ProductInformation
+}
+
+func newProductResolver(p *catalogModel.ProductInformation) *productResolver {
+	return &productResolver{p}
+}
+
+func (r *productResolver) ID() graphql.ID           { return toID(r.p.ID) }
+func (r *productResolver) Name() string             { return r.p.Name }
+func (r *productResolver) Description() string      { return r.p.Description }
+func (r *productResolver) Manufacturer() string     { return r.p.Manufacturer }
+func (r *productResolver) ListCost() *moneyResolver { return &moneyResolver{r.p.ListCost} }
+func (r *productResolver) Attributes() *jsonValue   { return toJSON(r.p.Attributes) }
+func (r *productResolver) Version() int32           { return int32(r.p.Version) }
+
+func (r *productResolver) SubCategory() *subCategoryResolver {
+	return wrap(r.p.SubCategoryDetails, newSubCategoryResolver)
+}
+
+func (r *productResolver) Stock(ctx context.Context) (*int32, error) {
+	return requires(ctx, auth.PermOrdersRead, func() (*int32, error) {
+		stock, err := loadersFrom(ctx).stockByProduct.Load(ctx, r.p.ID)
+		if err != nil {
+			return nil, err
+		}
+		n, err := int32Of(stock)
+		return &n, err
+	})
+}
+
+func (r *productResolver) Orders(ctx context.Context) ([]*orderResolver, error) {
+	return requires(ctx, auth.PermOrdersRead, func() ([]*orderResolver, error) {
+		orders, err := loadersFrom(ctx).ordersByProduct.Load(ctx, r.p.ID)
+		return wrapAll(orders, newOrderResolver), err
+	})
+}
+
+type orderResolver struct{ o *orderModel.Order }
+
+func newOrderResolver(o *orderModel.Order) *orderResolver {
+	return &orderResolver{o}
+}
+
+func (r *orderResolver) ID() graphql.ID        { return toID(r.o.ID) }
+func (r *orderResolver) ProductID() graphql.ID { return toID(r.o.ProductID) }
+func (r *orderResolver) Quantity() int32       { return int32(r.o.Quantity) }
+func (r *orderResolver) Price() *moneyResolver { return &moneyResolver{r.o.Price} }
+func (r *orderResolver) Type() string          { return orderTypes[r.o.Type] }
+func (r *orderResolver) Status() string        { return orderStatuses[r.o.Status] }
+func (r *orderResolver) CustomerID() int32     { return int32(r.o.CustomerID) }
+func (r *orderResolver) Metadata() *jsonValue  { return toJSON(r.o.Metadata) }
+func (r *orderResolver) CreatedAt() string     { return r.o.CreatedAt.Format(time.RFC3339Nano) }
+func (r *orderResolver) UpdatedAt() string     { return r.o.UpdatedAt.Format(time.RFC3339Nano) }
+
+func (r *orderResolver) Total() (*moneyResolver, error) {
+	total, err := r.o.Total()
+	if err != nil {
+		return nil, err
+	}
+	return &moneyResolver{total}, nil
+}
+
+func (r *orderResolver) Product(ctx context.Context) (*productResolver, error) {
+	return requires(ctx, auth.PermCatalogRead, func() (*productResolver, error) {
+		product, err := orNull(loadersFrom(ctx).productByID.Load(ctx, r.o.ProductID))
+		return wrap(product, newProductResolver), err
+	})
+}
+
+// wrap returns the resolver of v, or nil for a null v.
+func wrap[V any, R any](v *V, resolver func(*V) *R) *R {
+	if v == nil {
+		return nil
+	}
+	return resolver(v)
+}
+
+// wrapAll returns the resolvers of a list.
+func wrapAll[V any, R any](values []*V, resolver func(*V) *R) []*R {
+	resolvers := make([]*R, len(values))
+	for i, v := range values {
+		resolvers[i] = resolver(v)
+	}
+	return resolvers
+}
+
+func (r *Resolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	return requires(ctx, auth.PermCatalogRead, func() ([]*categoryResolver, error) {
+		all, err := r.categories.GetAll(ctx)
+		for _, c := range all {
+			loadersFrom(ctx).categoryByID.Prime(c.ID, c)
+		}
+		return wrapAll(all, newCategoryResolver), err
+	})
+}
+
+func (r *Resolver) Category(ctx context.Context, args struct{ ID graphql.ID }) (*categoryResolver, error) {
+	return requires(ctx, auth.PermCatalogRead, func() (*categoryResolver, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+		category, err := orNull(loadersFrom(ctx).categoryByID.Load(ctx, catalogModel.CategoryID(id)))
+		return wrap(category, newCategoryResolver), err
+	})
+}
+
+func (r *Resolver) SubCategories(ctx context.Context) ([]*subCategoryResolver, error) {
+	return requires(ctx, auth.PermCatalogRead, func() ([]*subCategoryResolver, error) {
+		all, err := r.subCategories.GetAll(ctx)
+		for _, s := range all {
+			loadersFrom(ctx).subCategoryByID.Prime(s.ID, s)
+		}
+		return wrapAll(all, newSubCategoryResolver), err
+	})
+}
+
+func (r *Resolver) SubCategory(ctx context.Context, args struct{ ID graphql.ID }) (*subCategoryResolver, error) {
+	return requires(ctx, auth.PermCatalogRead, func() (*subCategoryResolver, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+		subCategory, err := orNull(loadersFrom(ctx).subCategoryByID.Load(ctx, catalogModel.SubCategoryID(id)))
+		return wrap(subCategory, newSubCategoryResolver), err
+	})
+}
+
+func (r *Resolver) Products(ctx context.Context) ([]*productResolver, error) {
+	return requires(ctx, auth.PermCatalogRead, func() ([]*productResolver, error) {
+		all, err := r.products.GetAll(ctx)
+		for _, p := range all {
+			loadersFrom(ctx).productByID.Prime(p.ID, p)
+		}
+		return wrapAll(all, newProductResolver), err
+	})
+}
+
+func (r *Resolver) Product(ctx context.Context, args struct{ ID graphql.ID }) (*productResolver, error) {
+	return requires(ctx, auth.PermCatalogRead, func() (*productResolver, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+		product, err := orNull(loadersFrom(ctx).productByID.Load(ctx, catalogModel.ProductID(id)))
+		return wrap(product, newProductResolver), err
+	})
+}
+
+func (r *Resolver) Orders(ctx context.Context) ([]*orderResolver, error) {
+	return requires(ctx, auth.PermOrdersRead, func() ([]*orderResolver, error) {
+		orders, err := r.orders.GetAll(ctx)
+		return wrapAll(orders, newOrderResolver), err
+	})
+}
+
+func (r *Resolver) Order(ctx context.Context, args struct{ ID graphql.ID }) (*orderResolver, error) {
+	return requires(ctx, auth.PermOrdersRead, func() (*orderResolver, error) {
+		id, err := idArg(args.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+		order, err := r.orders.Get(ctx, orderModel.OrderID(id))
+		if errors.Is(err, gateway.ErrNotFound) {
+			return nil, nil
+		}
+		return wrap(order, newOrderResolver), err
+	})
+}
+
+func (r *Resolver) Stock(ctx context.Context, args struct{ ProductID graphql.ID }) (int32, error) {
+	return requires(ctx, auth.PermOrdersRead, func() (int32, error) {
+		id, err := idArg(args.ProductID, "productID")
+		if err != nil {
+			return 0, err
+		}
+		stock, err := loadersFrom(ctx).stockByProduct.Load(ctx, catalogModel.ProductID(id))
+		if err != nil {
+			return 0, err
+		}
+		return int32Of(stock)
+	})
+}
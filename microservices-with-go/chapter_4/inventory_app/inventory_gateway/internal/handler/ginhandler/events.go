@@ -0,0 +1,48 @@
+package ginhandler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/webhook"
+)
+
+type IEventHandler interface {
+	HandleEvent(ctx context.Context, event *events.Event) error
+}
+
+type EventHandler struct {
+	handler IEventHandler
+}
+
+func NewEventHandler(handler IEventHandler) *EventHandler {
+	return &EventHandler{handler: handler}
+}
+
+// Receive accepts a domain event delivered by a service's webhook sink.
+func (h *EventHandler) Receive(ctx *gin.Context) {
+	var event events.Event
+	if err := ctx.ShouldBindJSON(&event); err != nil || event.Type == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event"})
+		return
+	}
+	if err := h.handler.HandleEvent(ctx.Request.Context(), &event); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// signatureTolerance is how old the signature of a received event may be.
+const signatureTolerance = 5 * time.Minute
+
+// RegisterCatalogEventRoutes receives the catalog domain events signed with
+// secret, e.g. with the catalog started with
+// CATALOG_EVENT_WEBHOOK_URL=http://<gateway>/events/catalog and the same
+// CATALOG_EVENT_WEBHOOK_SECRET.
+func RegisterCatalogEventRoutes(engine *gin.Engine, handler IEventHandler, secret string) {
+	engine.POST("/events/catalog", webhook.RequireSignature(secret, signatureTolerance), NewEventHandler(handler).Receive)
+}
@@ -0,0 +1,81 @@
+package ginhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+)
+
+type IGraphQLResolver interface {
+	Execute(ctx context.Context, query, operationName string, variables map[string]any) *graphql.Response
+	ExecuteQuery(ctx context.Context, query, operationName string, variables map[string]any) *graphql.Response
+	SDL() string
+}
+
+// graphQLRequest is a GraphQL request as sent over HTTP.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+type GraphQLHandler struct {
+	resolver IGraphQLResolver
+}
+
+func NewGraphQLHandler(resolver IGraphQLResolver) *GraphQLHandler {
+	return &GraphQLHandler{resolver: resolver}
+}
+
+// Post executes a request sent as a JSON body with query, operationName and
+// variables.
+func (h *GraphQLHandler) Post(ctx *gin.Context) {
+	var req graphQLRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Query == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "Invalid GraphQL request"}}})
+		return
+	}
+	h.respond(ctx, h.resolver.Execute(ctx.Request.Context(), req.Query, req.OperationName, req.Variables))
+}
+
+// Get executes a query sent as URL parameters, with variables as a JSON object.
+// Mutations must be posted: they are not part of the schema served over GET.
+func (h *GraphQLHandler) Get(ctx *gin.Context) {
+	req := graphQLRequest{Query: ctx.Query("query"), OperationName: ctx.Query("operationName")}
+	if raw := ctx.Query("variables"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "Invalid variables"}}})
+			return
+		}
+	}
+	if req.Query == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "Invalid GraphQL request"}}})
+		return
+	}
+	h.respond(ctx, h.resolver.ExecuteQuery(ctx.Request.Context(), req.Query, req.OperationName, req.Variables))
+}
+
+// Schema returns the schema in the GraphQL schema definition language.
+func (h *GraphQLHandler) Schema(ctx *gin.Context) {
+	ctx.String(http.StatusOK, h.resolver.SDL())
+}
+
+// respond answers 200 whenever the request was executed, even partially;
+// requests that could not be executed at all get 400.
+func (h *GraphQLHandler) respond(ctx *gin.Context, resp *graphql.Response) {
+	if resp.Data == nil && len(resp.Errors) > 0 {
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func RegisterGraphQLRoutes(engine *gin.Engine, resolver IGraphQLResolver) {
+	handler := NewGraphQLHandler(resolver)
+	engine.POST("/graphql", handler.Post)
+	engine.GET("/graphql", handler.Get)
+	engine.GET("/graphql/schema", handler.Schema)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/auth"
 )
 
 type ICategoryControler interface {
@@ -63,7 +64,7 @@ func RegisterCategoryRoutes(engine *gin.Engine, ctrl ICategoryControler) {
 	handler := NewCategoryHandler(ctrl)
 	categoryRouter := engine.Group("/categories")
 	{
-		categoryRouter.POST("/", handler.Create)
-		categoryRouter.PUT("/:id", handler.Update)
+		categoryRouter.POST("/", auth.Require(auth.PermCatalogWrite), handler.Create)
+		categoryRouter.PUT("/:id", auth.Require(auth.PermCatalogWrite), handler.Update)
 	}
 }
@@ -0,0 +1,55 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/inventory_gateway/internal/gateway"
+	"inventory.com/inventory_gateway/pkg/model"
+	"inventory.com/pkg/auth"
+)
+
+type IProductController interface {
+	Overview(ctx context.Context, id catalogModel.ProductID) (*model.ProductOverview, error)
+}
+
+type ProductHandler struct {
+	controller IProductController
+}
+
+func NewProductHandler(controller IProductController) *ProductHandler {
+	return &ProductHandler{controller: controller}
+}
+
+// Overview returns the product, its stock and its effective price in one
+// response. Sections that could not be loaded are listed under "unavailable".
+func (h *ProductHandler) Overview(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	overview, err := h.controller.Overview(ctx.Request.Context(), catalogModel.ProductID(id))
+	if errors.Is(err, gateway.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, overview)
+}
+
+func RegisterProductRoutes(engine *gin.Engine, ctrl IProductController) {
+	handler := NewProductHandler(ctrl)
+	productRouter := engine.Group("/products")
+	{
+		productRouter.GET("/:id/overview", auth.Require(auth.PermCatalogRead), handler.Overview)
+	}
+}
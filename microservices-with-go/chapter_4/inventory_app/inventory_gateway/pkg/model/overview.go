@@ -0,0 +1,30 @@
+package model
+
+import (
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/money"
+)
+
+// Sections of a product overview, used as keys of ProductOverview.Unavailable.
+const (
+	SectionProduct = "product"
+	SectionStock   = "stock"
+	SectionPricing = "pricing"
+)
+
+// Pricing is the price a customer pays for one unit of a product today.
+type Pricing struct {
+	ListPrice      money.Money `json:"listPrice"`
+	EffectivePrice money.Money `json:"effectivePrice"`         // List price minus the best discount
+	DiscountCode   string      `json:"discountCode,omitempty"` // Code of the best discount, if any
+}
+
+// ProductOverview combines what the catalog, order and discount services know
+// about a product. Sections whose upstream failed or timed out are omitted and
+// listed in Unavailable with the reason.
+type ProductOverview struct {
+	Product     *catalogModel.ProductInformation `json:"product,omitempty"`
+	Stock       *int                             `json:"stock,omitempty"`
+	Pricing     *Pricing                         `json:"pricing,omitempty"`
+	Unavailable map[string]string                `json:"unavailable,omitempty"`
+}
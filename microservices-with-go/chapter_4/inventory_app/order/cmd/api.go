@@ -1,34 +1,255 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"inventory.com/order/internal/controller"
+	"inventory.com/order/internal/gateway"
 	"inventory.com/order/internal/handler/ginhandler"
+	"inventory.com/order/internal/repository/file"
+	"inventory.com/order/internal/repository/instrumented"
 	"inventory.com/order/internal/repository/memory"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/discovery"
+	"inventory.com/pkg/discovery/registry"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/health"
+	"inventory.com/pkg/lifecycle"
+	"inventory.com/pkg/logging"
+	"inventory.com/pkg/metrics"
+	"inventory.com/pkg/requestid"
+	"inventory.com/pkg/resilient"
+	"inventory.com/pkg/tenant"
+	"inventory.com/pkg/tracing"
+	"inventory.com/pkg/webhook"
 )
 
-var repo *memory.Order
-var ctrl *controller.OrderController
+// eventRelayInterval is how often pending domain events are delivered from the outbox.
+const eventRelayInterval = time.Second
+
+const (
+	// heartbeatInterval is how often a ready instance reports to the service registry.
+	heartbeatInterval = 2 * time.Second
+	// healthCheckTimeout bounds each readiness check.
+	healthCheckTimeout = time.Second
+	// webhookLogSize is how many finished deliveries are kept per webhook subscription.
+	webhookLogSize = 100
+)
+
+var catalogAddr = "http://0.0.0.0:8081"  // Example address, adjust as needed
+var discountAddr = "http://0.0.0.0:8084" // Example address, adjust as needed
+
+// sagaDir is where sale saga state is persisted, so unfinished sagas resume after a restart.
+var sagaDir = envOr("ORDER_SAGA_DIR", filepath.Join(os.TempDir(), "inventory-order-sagas"))
+
+// advertiseAddr is the address the order service registers under in the service registry.
+var advertiseAddr = envOr("ORDER_ADVERTISE_ADDR", "localhost:8082")
+
+// eventWebhookURL optionally receives every order domain event as a JSON POST.
+var eventWebhookURL = os.Getenv("ORDER_EVENT_WEBHOOK_URL")
+
+// eventWebhookSecret signs the requests of the webhook sink, like the
+// deliveries to webhook subscriptions, when set.
+var eventWebhookSecret = os.Getenv("ORDER_EVENT_WEBHOOK_SECRET")
+
+// repositoryKind selects the order repository; "eventsourced" stores every
+// order as a stream of events, anything else uses the plain in-memory store.
+var repositoryKind = os.Getenv("ORDER_REPOSITORY")
+
+// snapshotEvery is how many events of an order stream are folded into a snapshot.
+const snapshotEvery = 20
+
+// serviceAPIKey authenticates the calls to the catalog made outside of a
+// request, e.g. by sagas resumed at startup.
+var serviceAPIKey = os.Getenv("ORDER_SERVICE_API_KEY")
+
+type repositories struct {
+	orders       instrumented.OrderRepository
+	audit        *audit.MemoryStore
+	outbox       *events.MemoryOutbox
+	sagas        *instrumented.Saga
+	reservations *instrumented.Reservation
+}
 
 func main() {
+	if err := logging.Init(logging.ConfigFromEnv("order")); err != nil {
+		logging.Fatal("failed to set up logging", "error", err)
+	}
+	authenticator, err := auth.Load(auth.ConfigFromEnv())
+	if err != nil {
+		logging.Fatal("failed to set up authentication", "error", err)
+	}
+	tenants, err := tenant.FromEnv()
+	if err != nil {
+		logging.Fatal("failed to set up tenants", "error", err)
+	}
+	shutdownTracing, err := tracing.Init(tracing.ConfigFromEnv("order"))
+	if err != nil {
+		logging.Fatal("failed to set up tracing", "error", err)
+	}
+	serviceRegistry, err := registry.FromEnv()
+	if err != nil {
+		logging.Fatal("failed to set up the service registry", "error", err)
+	}
+	repos, err := newRepositories()
+	if err != nil {
+		logging.Fatal("failed to set up the saga repository", "error", err)
+	}
+
+	httpClient := resilient.New(httpClientConfig())
+	client := auth.NewForwardingClient(httpClient, serviceAPIKey)
+	catalog := gateway.NewCatalogGateway(catalogAddr, metrics.NewClient("catalog", client))
+	discounts := gateway.NewDiscountGateway(discountAddr, metrics.NewClient("discount", client))
+	ctrl := controller.NewOrderController(repos.orders, catalog, audit.NewRecorder(repos.audit), repos.outbox)
+	sagaCtrl := controller.NewSagaController(repos.sagas, repos.reservations, catalog, discounts, ctrl)
+
+	webhooks := webhook.NewDispatcher(5*time.Second, 8, time.Second, 10*time.Minute, webhookLogSize)
+	eventRelay := newEventRelay(repos.outbox, webhooks)
+	registerMetrics(ctrl, tenants)
+	readiness := newReadiness(repos, serviceRegistry)
+
 	gin.SetMode(gin.DebugMode)
-	engine := gin.New()
+	engine := newEngine(authenticator, tenants, readiness)
 
 	ginhandler.RegisterOrderRoutes(engine, ctrl)
-	if err := engine.Run(":8082"); err != nil {
-		log.Fatalf("[server] Failed to start server: %v", err)
+	ginhandler.RegisterSagaRoutes(engine, sagaCtrl)
+	admin := engine.Group("", auth.Require(auth.PermAdmin))
+	audit.InitHandler(admin, repos.audit)
+	webhook.InitHandler(admin, webhooks)
+	logging.InitHandler(admin)
+	resilient.InitHandler(engine, httpClient)
+
+	for _, id := range tenants {
+		ctx := tenant.WithID(context.Background(), id)
+		if err := sagaCtrl.Resume(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to resume sagas", "error", err)
+		}
+	}
+
+	service := lifecycle.New(lifecycle.DefaultConfig(":8082"), engine)
+	readiness.Add("lifecycle", service)
+	service.Go(func(ctx context.Context) { eventRelay.Run(ctx, eventRelayInterval) })
+	service.Go(func(ctx context.Context) { webhooks.Run(ctx, eventRelayInterval) })
+	if serviceRegistry != nil {
+		instance := health.Instance{ID: discovery.GenerateInstanceID("order"), Service: "order", HostPort: advertiseAddr}
+		service.Go(func(ctx context.Context) { readiness.Heartbeat(ctx, serviceRegistry, instance, heartbeatInterval) })
+	}
+	// The events of the last requests are relayed, and handed to the
+	// webhook subscribers, before exiting.
+	service.OnShutdown("events", func(ctx context.Context) error {
+		_, err := eventRelay.Flush(ctx, time.Now())
+		return err
+	})
+	service.OnShutdown("webhooks", func(ctx context.Context) error {
+		webhooks.Flush(ctx, time.Now())
+		return nil
+	})
+	service.OnShutdown("tracing", shutdownTracing)
+
+	if err := service.Run(context.Background()); err != nil {
+		logging.Fatal("server stopped", "error", err)
+	}
+}
+
+// newRepositories returns the repositories, traced and timed.
+func newRepositories() (*repositories, error) {
+	sagas, err := file.NewSaga(sagaDir)
+	if err != nil {
+		return nil, err
+	}
+	repos := &repositories{
+		audit:        audit.NewMemoryStore(),
+		outbox:       events.NewMemoryOutbox(),
+		sagas:        instrumented.NewSaga(sagas),
+		reservations: instrumented.NewReservation(memory.NewReservation()),
+	}
+	if repositoryKind == "eventsourced" {
+		repos.orders = instrumented.NewOrder(memory.NewEventSourced(snapshotEvery))
+	} else {
+		repos.orders = instrumented.NewOrder(memory.New())
+	}
+	return repos, nil
+}
+
+// newEventRelay delivers the order events to the in-process bus, the
+// webhook subscribers and, when configured, the webhook sink.
+func newEventRelay(outbox *events.MemoryOutbox, webhooks *webhook.Dispatcher) *events.Relay {
+	bus := events.MultiBus{events.NewInProcessBus(), webhooks}
+	if eventWebhookURL != "" {
+		var sign events.Signer
+		if eventWebhookSecret != "" {
+			sign = webhook.Signer(eventWebhookSecret)
+		}
+		bus = append(bus, events.NewWebhookSink(eventWebhookURL, 5*time.Second, sign))
 	}
+	return events.NewRelay(outbox, bus, 100, time.Second, 5*time.Minute)
+}
+
+// registerMetrics registers the gauges of the orders and stock of every tenant.
+// The product_id label of product_stock has one value per product with orders,
+// so the gauge has up to tenants × products series: it suits catalogs of a few
+// thousand products, larger ones should read the stock from the API instead.
+func registerMetrics(ctrl *controller.OrderController, tenants []tenant.ID) {
+	metrics.RegisterTenantGauge("orders_pending", "Orders neither completed nor cancelled.", nil, tenants,
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			pending, err := ctrl.PendingOrders(ctx)
+			return metrics.Count(pending), err
+		})
+	metrics.RegisterTenantGauge("product_stock", "Current stock of a product, from its completed orders.", []string{"product_id"}, tenants,
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			levels, err := ctrl.StockLevels(ctx)
+			samples := make([]metrics.Sample, 0, len(levels))
+			for id, stock := range levels {
+				samples = append(samples, metrics.Sample{Labels: []string{strconv.Itoa(int(id))}, Value: float64(stock)})
+			}
+			return samples, err
+		})
 }
 
-func initRepository() {
-	repo = memory.New()
+// newReadiness checks that the repositories answer and, with discovery, that
+// the catalog and discount services can be resolved.
+func newReadiness(repos *repositories, serviceRegistry discovery.Registry) *health.Health {
+	readiness := health.New(healthCheckTimeout)
+	readiness.Add("orders", health.CheckerFunc(repos.orders.Ping))
+	readiness.Add("reservations", health.CheckerFunc(repos.reservations.Ping))
+	readiness.Add("sagas", health.CheckerFunc(repos.sagas.Ping))
+	if serviceRegistry != nil {
+		readiness.Add("catalog", health.Resolvable(serviceRegistry, "catalog"))
+		readiness.Add("discount", health.Resolvable(serviceRegistry, "discount"))
+	}
+	return readiness
 }
-func initController() {
-	ctrl = controller.NewOrderController(repo)
+
+// httpClientConfig traces the calls to the catalog and discount services.
+func httpClientConfig() resilient.Config {
+	cfg := resilient.DefaultConfig()
+	cfg.Transport = tracing.Transport(nil)
+	return cfg
 }
-func init() {
-	initRepository()
-	initController()
+
+// newEngine returns the engine of the service. The probes and the metrics are
+// served before the authentication and tenant middleware, so that they answer
+// whatever the configured tenants; the routes added afterwards require a known
+// tenant.
+func newEngine(authenticator *auth.Authenticator, tenants []tenant.ID, readiness *health.Health) *gin.Engine {
+	engine := gin.New()
+	engine.Use(requestid.Middleware(), tracing.Middleware(), logging.Middleware(), metrics.Middleware())
+	health.InitHandler(engine, readiness)
+	metrics.InitHandler(engine)
+	engine.Use(auth.Middleware(authenticator), tenant.Middleware(tenants, auth.MayChooseTenant))
+	return engine
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
 }
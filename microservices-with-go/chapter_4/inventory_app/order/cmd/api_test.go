@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/health"
+	"inventory.com/pkg/tenant"
+)
+
+func TestNewEngine_ProbesAndMetricsWithoutDefaultTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.Load(auth.Config{})
+	require.NoError(t, err)
+	engine := newEngine(authenticator, []tenant.ID{"a", "b"}, health.New(time.Second))
+	engine.GET("/protected", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protected", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code, "the other routes still require a tenant")
+}
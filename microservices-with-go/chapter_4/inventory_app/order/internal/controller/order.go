@@ -3,79 +3,189 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	catalogModel "inventory.com/catalog/pkg/model"
 	"inventory.com/order/pkg/enums"
 	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tracing"
 )
 
+// IOrderRepository stores orders. Its write methods call commit with the order
+// before and after the change and the resulting stock of its product while the
+// change is applied, and only keep the change if commit returns nil.
 type IOrderRepository interface {
-	Create(ctx context.Context, orderRecord *model.Order) (*model.Order, error)
+	Create(ctx context.Context, orderRecord *model.Order, commit func(before, after *model.Order, stock int) error) (*model.Order, error)
 	GetAll(ctx context.Context) ([]*model.Order, error)
 	GetByProductID(ctx context.Context, productID catalogModel.ProductID) ([]*model.Order, error)
-	UpdateStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus) error
+	UpdateStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus, commit func(before, after *model.Order, stock int) error) error
+	UpdateMetadata(ctx context.Context, orderID model.OrderID, metadata *model.OrderMetadata, commit func(before, after *model.Order, stock int) error) error
 	Get(ctx context.Context, orderID model.OrderID) (*model.Order, error)
 }
+
+// IStockProjection is implemented by repositories that maintain current stock
+// per product themselves instead of having it computed from the order list.
+type IStockProjection interface {
+	CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error)
+	// StockLevels returns the current stock of every product with orders.
+	StockLevels(ctx context.Context) (map[catalogModel.ProductID]int, error)
+}
+
+// IPriceLookup resolves the catalog list price of a product at a point in time.
+type IPriceLookup interface {
+	PriceAt(ctx context.Context, productID catalogModel.ProductID, at time.Time) (money.Money, error)
+}
+
+// IAuditRecorder records order mutations in the audit log.
+type IAuditRecorder interface {
+	Record(ctx context.Context, entityType string, entityID any, op audit.Operation, before, after any) error
+}
+
+// IEventOutbox stores domain events together with the changes that cause them.
+type IEventOutbox interface {
+	Transact(ctx context.Context, fn func(tx *events.Tx) error) error
+}
+
+const (
+	// entityOrder is the entity type under which order mutations are audited and published.
+	entityOrder = "order"
+	// entityProduct is the aggregate type of the stock changes of a product.
+	entityProduct = "product"
+)
+
 type OrderController struct {
-	repo IOrderRepository
+	repo   IOrderRepository
+	prices IPriceLookup
+	audit  IAuditRecorder
+	outbox IEventOutbox
 }
 
-// NewOrderController creates a new instance of OrderController with the provided repository.
-func NewOrderController(repo IOrderRepository) *OrderController {
+// NewOrderController creates a new instance of OrderController with the provided repository,
+// catalog price lookup, audit recorder and event outbox.
+func NewOrderController(repo IOrderRepository, prices IPriceLookup, audit IAuditRecorder, outbox IEventOutbox) *OrderController {
 	return &OrderController{
-		repo: repo,
+		repo:   repo,
+		prices: prices,
+		audit:  audit,
+		outbox: outbox,
 	}
 }
 
 // CreateOrder handles the creation of a new order.
 // It accepts an Order model, validates it, and then calls the repository to save it.
 func (c *OrderController) CreateOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.CreateOrder")
+	defer span.End()
+
 	if order == nil {
-		return nil, errors.New("order cannot be nil")
+		return nil, fmt.Errorf("%w: order cannot be nil", model.ErrInvalidOrder)
 	}
 	if order.Quantity <= 0 {
-		return nil, errors.New("quantity must be greater than zero")
+		return nil, fmt.Errorf("%w: quantity must be greater than zero", model.ErrInvalidOrder)
 	}
-	if order.Price <= 0 {
-		return nil, errors.New("price must be greater than zero")
+	if err := order.Price.Validate(); err != nil {
+		return nil, err
+	}
+	if !order.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: price must be greater than zero", model.ErrInvalidOrder)
+	}
+	if _, err := order.Total(); err != nil {
+		return nil, err
+	}
+	if err := c.validateHistoricalPrice(ctx, order); err != nil {
+		return nil, err
 	}
 
 	// Set initial status to PENDING
 	order.Status = enums.OrderStatusPending
 
-	return c.repo.Create(ctx, order)
+	return c.repo.Create(ctx, order, c.commit(ctx, audit.OperationCreate))
+}
+
+// commit returns the repository hook that publishes the events of an order
+// change and records it in the audit log as op, so both are written in the
+// critical section of the change itself.
+func (c *OrderController) commit(ctx context.Context, op audit.Operation) func(before, after *model.Order, stock int) error {
+	return func(before, after *model.Order, stock int) error {
+		return c.outbox.Transact(ctx, func(tx *events.Tx) error {
+			if err := emitChange(tx, before, after, stock); err != nil {
+				return err
+			}
+			return c.recordAudit(ctx, after.ID, op, before, after)
+		})
+	}
+}
+
+// recordAudit records a mutation of an order in the audit log.
+func (c *OrderController) recordAudit(ctx context.Context, orderID model.OrderID, op audit.Operation, before, after *model.Order) error {
+	if err := c.audit.Record(ctx, entityOrder, orderID, op, before, after); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// validateHistoricalPrice checks backdated sale orders, i.e. orders created with
+// an explicit CreatedAt in the past, against the catalog price as of that date.
+func (c *OrderController) validateHistoricalPrice(ctx context.Context, order *model.Order) error {
+	if order.Type != enums.OrderTypeSale || order.CreatedAt.IsZero() || !order.CreatedAt.Before(time.Now()) {
+		return nil
+	}
+	listPrice, err := c.prices.PriceAt(ctx, order.ProductID, order.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to look up catalog price: %w", err)
+	}
+	if listPrice != order.Price {
+		return fmt.Errorf("%w: ordered at %s, catalog price was %s", model.ErrPriceMismatch, order.Price, listPrice)
+	}
+	return nil
 }
 
-// GetAllOrders retrieves all orders from the repository.
+// GetAllOrders retrieves all orders from the repository. It returns an empty
+// list if there are no orders.
 func (c *OrderController) GetAllOrders(ctx context.Context) ([]*model.Order, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.GetAllOrders")
+	defer span.End()
+
 	orders, err := c.repo.GetAll(ctx)
+	if errors.Is(err, model.ErrOrderNotFound) {
+		return []*model.Order{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	if len(orders) == 0 {
-		return nil, errors.New("no orders found")
-	}
 	return orders, nil
 }
 
-// GetOrdersByProductID retrieves all orders for a specific product ID.
+// GetOrdersByProductID retrieves all orders for a specific product ID. It
+// returns an empty list if the product has no orders.
 func (c *OrderController) GetOrdersByProductID(ctx context.Context, productID catalogModel.ProductID) ([]*model.Order, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.GetOrdersByProductID")
+	defer span.End()
+
 	if productID <= 0 {
 		return nil, errors.New("invalid product ID")
 	}
 
 	orders, err := c.repo.GetByProductID(ctx, productID)
+	if errors.Is(err, model.ErrOrderNotFound) {
+		return []*model.Order{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	if len(orders) == 0 {
-		return nil, errors.New("no orders found for the specified product")
-	}
 	return orders, nil
 }
 
 // UpdateOrderStatus updates the status of an existing order by its ID.
 func (c *OrderController) UpdateOrderStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus) error {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.UpdateOrderStatus")
+	defer span.End()
+
 	if orderID <= 0 {
 		return errors.New("invalid order ID")
 	}
@@ -83,11 +193,76 @@ func (c *OrderController) UpdateOrderStatus(ctx context.Context, orderID model.O
 		return errors.New("invalid order status")
 	}
 
-	return c.repo.UpdateStatus(ctx, orderID, status)
+	return c.repo.UpdateStatus(ctx, orderID, status, c.commit(ctx, audit.OperationUpdate))
+}
+
+// emitChange emits the event matching an order change and, when the order
+// started or stopped counting towards the stock, a StockChanged event on the
+// product carrying its stock after the change. before is nil for a new order.
+func emitChange(tx *events.Tx, before, after *model.Order, stock int) error {
+	if before == nil {
+		return tx.Emit(model.EventOrderCreated, entityOrder, after.ID, after)
+	}
+
+	eventType := model.EventOrderUpdated
+	if before.Status != after.Status {
+		switch after.Status {
+		case enums.OrderStatusCompleted:
+			eventType = model.EventOrderCompleted
+		case enums.OrderStatusCancelled:
+			eventType = model.EventOrderCancelled
+		}
+	}
+	if err := tx.Emit(eventType, entityOrder, after.ID, after); err != nil {
+		return err
+	}
+
+	wasCounted := before.Status == enums.OrderStatusCompleted
+	isCounted := after.Status == enums.OrderStatusCompleted
+	if wasCounted == isCounted {
+		return nil
+	}
+	delta := after.StockEffect()
+	if wasCounted {
+		delta = -before.StockEffect()
+	}
+	return tx.Emit(model.EventStockChanged, entityProduct, after.ProductID, &model.StockChange{
+		ProductID:    after.ProductID,
+		OrderID:      after.ID,
+		Delta:        delta,
+		CurrentStock: stock,
+	})
+}
+
+// PatchOrderMetadata applies a merge patch or JSON patch to the customer and
+// metadata of an existing order. Other order fields cannot be patched.
+func (c *OrderController) PatchOrderMetadata(ctx context.Context, orderID model.OrderID, patch jsonpatch.Func) (*model.Order, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.PatchOrderMetadata")
+	defer span.End()
+
+	order, err := c.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := jsonpatch.ApplyTo(&model.OrderMetadata{CustomerID: order.CustomerID, Metadata: order.Metadata}, patch)
+	if err != nil {
+		return nil, err
+	}
+	if patched.CustomerID < 0 {
+		return nil, fmt.Errorf("%w: customerID cannot be negative", jsonpatch.ErrCannotApply)
+	}
+	if err := c.repo.UpdateMetadata(ctx, orderID, patched, c.commit(ctx, audit.OperationUpdate)); err != nil {
+		return nil, err
+	}
+	return c.repo.Get(ctx, orderID)
 }
 
 // GetOrder retrieves a specific order by its ID.
 func (c *OrderController) GetOrder(ctx context.Context, orderID model.OrderID) (*model.Order, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.GetOrder")
+	defer span.End()
+
 	if orderID <= 0 {
 		return nil, errors.New("invalid order ID")
 	}
@@ -102,26 +277,81 @@ func (c *OrderController) GetOrder(ctx context.Context, orderID model.OrderID) (
 	return order, nil
 }
 
-// CurrentStock calculates the current stock for a product based on its orders.
+// CurrentStock calculates the current stock for a product based on its orders,
+// or reads it from the repository when it keeps a stock projection. A product
+// without orders has no stock.
 func (c *OrderController) CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.CurrentStock")
+	defer span.End()
+
+	if projection, ok := c.repo.(IStockProjection); ok {
+		stock, err := projection.CurrentStock(ctx, productID)
+		if errors.Is(err, model.ErrOrderNotFound) {
+			return 0, nil
+		}
+		return stock, err
+	}
+
 	orders, err := c.repo.GetByProductID(ctx, productID)
+	if errors.Is(err, model.ErrOrderNotFound) {
+		return 0, nil
+	}
 	if err != nil {
 		return 0, err
 	}
 
 	totalQuantity := 0
 	for _, order := range orders {
-		if order.Status != enums.OrderStatusCompleted {
-			continue
-		}
-		if order.Type == enums.OrderTypeBuy || order.Type == enums.OrderTypeReturn {
-			totalQuantity += order.Quantity
-		}
-
-		if order.Type == enums.OrderTypeSale {
-			totalQuantity -= order.Quantity
-		}
+		totalQuantity += stockEffect(order)
 	}
 
 	return totalQuantity, nil
 }
+
+// StockLevels returns the current stock of every product with orders, like
+// CurrentStock does for one product.
+func (c *OrderController) StockLevels(ctx context.Context) (map[catalogModel.ProductID]int, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.StockLevels")
+	defer span.End()
+
+	if projection, ok := c.repo.(IStockProjection); ok {
+		return projection.StockLevels(ctx)
+	}
+
+	orders, err := c.GetAllOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	levels := make(map[catalogModel.ProductID]int)
+	for _, order := range orders {
+		levels[order.ProductID] += stockEffect(order)
+	}
+	return levels, nil
+}
+
+// stockEffect returns how an order changes the stock of its product: only
+// completed orders count.
+func stockEffect(order *model.Order) int {
+	if order.Status != enums.OrderStatusCompleted {
+		return 0
+	}
+	return order.StockEffect()
+}
+
+// PendingOrders counts the orders neither completed nor cancelled yet.
+func (c *OrderController) PendingOrders(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "controller.OrderController.PendingOrders")
+	defer span.End()
+
+	orders, err := c.GetAllOrders(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, order := range orders {
+		if order.Status == enums.OrderStatusPending {
+			pending++
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/order/internal/repository/memory"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/money"
+)
+
+func newOrderController() *OrderController {
+	price := money.Money{Amount: 1000, Currency: "USD"}
+	return NewOrderController(memory.New(), fixedPrice(price), audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+}
+
+func TestOrderController_GetAllOrders_Empty(t *testing.T) {
+	orders, err := newOrderController().GetAllOrders(defaultTenant())
+	assert.NoError(t, err)
+	assert.NotNil(t, orders)
+	assert.Empty(t, orders)
+}
+
+func TestOrderController_GetOrdersByProductID_Empty(t *testing.T) {
+	ctx := defaultTenant()
+	ctrl := newOrderController()
+	_, err := ctrl.CreateOrder(ctx, &model.Order{ProductID: 1, Quantity: 1, Price: money.Money{Amount: 1000, Currency: "USD"}, Type: enums.OrderTypeBuy})
+	assert.NoError(t, err)
+
+	orders, err := ctrl.GetOrdersByProductID(ctx, 2)
+	assert.NoError(t, err)
+	assert.NotNil(t, orders)
+	assert.Empty(t, orders)
+
+	orders, err = ctrl.GetOrdersByProductID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+}
+
+func TestOrderController_CurrentStock_NoOrders(t *testing.T) {
+	price := money.Money{Amount: 1000, Currency: "USD"}
+	repos := map[string]IOrderRepository{"plain": memory.New(), "eventsourced": memory.NewEventSourced(20)}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			ctx := defaultTenant()
+			ctrl := NewOrderController(repo, fixedPrice(price), audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+
+			stock, err := ctrl.CurrentStock(ctx, 1)
+			require.NoError(t, err, "a product without orders is not an error")
+			assert.Zero(t, stock)
+
+			order, err := ctrl.CreateOrder(ctx, &model.Order{ProductID: 1, Quantity: 3, Price: price, Type: enums.OrderTypeBuy})
+			require.NoError(t, err)
+			require.NoError(t, ctrl.UpdateOrderStatus(ctx, order.ID, enums.OrderStatusCompleted))
+			stock, err = ctrl.CurrentStock(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, 3, stock)
+			stock, err = ctrl.CurrentStock(ctx, 2)
+			require.NoError(t, err)
+			assert.Zero(t, stock)
+		})
+	}
+}
+
+func TestOrderController_StockLevels(t *testing.T) {
+	price := money.Money{Amount: 1000, Currency: "USD"}
+	repos := map[string]IOrderRepository{"plain": memory.New(), "eventsourced": memory.NewEventSourced(20)}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			ctx := defaultTenant()
+			ctrl := NewOrderController(repo, fixedPrice(price), audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+
+			levels, err := ctrl.StockLevels(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, levels)
+
+			order, err := ctrl.CreateOrder(ctx, &model.Order{ProductID: 1, Quantity: 3, Price: price, Type: enums.OrderTypeBuy})
+			require.NoError(t, err)
+			require.NoError(t, ctrl.UpdateOrderStatus(ctx, order.ID, enums.OrderStatusCompleted))
+			_, err = ctrl.CreateOrder(ctx, &model.Order{ProductID: 2, Quantity: 5, Price: price, Type: enums.OrderTypeBuy})
+			require.NoError(t, err)
+
+			levels, err = ctrl.StockLevels(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[catalogModel.ProductID]int{1: 3, 2: 0}, levels, "a product with only pending orders has no stock yet")
+		})
+	}
+}
+
+func TestOrderController_UpdateOrderStatus_PublishesStockOnProduct(t *testing.T) {
+	ctx := defaultTenant()
+	outbox := events.NewMemoryOutbox()
+	store := audit.NewMemoryStore()
+	price := money.Money{Amount: 1000, Currency: "USD"}
+	ctrl := NewOrderController(memory.New(), fixedPrice(price), audit.NewRecorder(store), outbox)
+
+	order, err := ctrl.CreateOrder(ctx, &model.Order{ProductID: 5, Quantity: 4, Price: price, Type: enums.OrderTypeBuy})
+	require.NoError(t, err)
+	require.NoError(t, ctrl.UpdateOrderStatus(ctx, order.ID, enums.OrderStatusCompleted))
+
+	pending, err := outbox.Pending(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	assert.Equal(t, model.EventOrderCreated, pending[0].Event.Type)
+	assert.Equal(t, model.EventOrderCompleted, pending[1].Event.Type)
+	stock := pending[2].Event
+	assert.Equal(t, model.EventStockChanged, stock.Type)
+	assert.Equal(t, "product", stock.AggregateType)
+	assert.Equal(t, "5", stock.AggregateID)
+	var change model.StockChange
+	require.NoError(t, json.Unmarshal(stock.Payload, &change))
+	assert.Equal(t, order.ID, change.OrderID)
+	assert.Equal(t, 4, change.Delta)
+	assert.Equal(t, 4, change.CurrentStock)
+
+	entries, err := store.Find(ctx, audit.Query{EntityType: entityOrder})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "the audit entries are written with the changes")
+}
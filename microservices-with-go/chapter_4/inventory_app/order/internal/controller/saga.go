@@ -0,0 +1,391 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	discountModel "inventory.com/discount/pkg"
+	"inventory.com/order/internal/gateway"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tracing"
+)
+
+// ISagaRepository persists saga state so sagas can be resumed after a restart.
+type ISagaRepository interface {
+	Save(ctx context.Context, saga *model.Saga) error
+	Get(ctx context.Context, id model.SagaID) (*model.Saga, error)
+	GetByOrderID(ctx context.Context, orderID model.OrderID) (*model.Saga, error)
+	GetAll(ctx context.Context) ([]*model.Saga, error)
+}
+
+// IReservationRepository holds stock for sales that are not orders yet.
+type IReservationRepository interface {
+	Create(ctx context.Context, data *model.Reservation) (*model.Reservation, error)
+	Release(ctx context.Context, reference string) error
+	Held(ctx context.Context, productID catalogModel.ProductID) (int, error)
+}
+
+// IDiscountGateway redeems and voids discount codes in the discount service.
+type IDiscountGateway interface {
+	Redeem(ctx context.Context, code string, productID catalogModel.ProductID, unitPrice money.Money, reference string) (*discountModel.Redemption, error)
+	VoidByReference(ctx context.Context, code, reference string) error
+}
+
+// ISaleOrders places the order at the end of a sale saga.
+type ISaleOrders interface {
+	CreateOrder(ctx context.Context, order *model.Order) (*model.Order, error)
+	GetOrdersByProductID(ctx context.Context, productID catalogModel.ProductID) ([]*model.Order, error)
+	CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error)
+}
+
+// sagaMetadataKey is the order metadata entry naming the saga that placed it.
+const sagaMetadataKey = "saga"
+
+// sagaStep is one step of the sale saga. compensate undoes a completed step
+// and is nil for steps without side effects. Both must be safe to repeat,
+// since a saga interrupted by a crash repeats the step it was in. Side
+// effects are found by the saga ID, never by IDs handed out by other
+// repositories, which do not survive a restart. resume re-establishes the
+// in-memory side effects of a completed step when a running saga is resumed
+// after a restart, and is nil for steps with durable side effects.
+type sagaStep struct {
+	name       string
+	run        func(ctx context.Context, saga *model.Saga) error
+	compensate func(ctx context.Context, saga *model.Saga) error
+	resume     func(ctx context.Context, saga *model.Saga) error
+}
+
+// SagaController orchestrates sales across the catalog, discount and order
+// services: it checks the product, reserves stock, redeems the discount and
+// places the order. When a step fails the completed steps are compensated in
+// reverse order. Progress is saved after every step.
+type SagaController struct {
+	repo         ISagaRepository
+	reservations IReservationRepository
+	prices       IPriceLookup
+	discounts    IDiscountGateway
+	orders       ISaleOrders
+	steps        []sagaStep
+	stockMu      sync.Mutex // Serializes the stock check and the reservation
+}
+
+// NewSagaController creates a new instance of SagaController.
+func NewSagaController(repo ISagaRepository, reservations IReservationRepository, prices IPriceLookup, discounts IDiscountGateway, orders ISaleOrders) *SagaController {
+	c := &SagaController{
+		repo:         repo,
+		reservations: reservations,
+		prices:       prices,
+		discounts:    discounts,
+		orders:       orders,
+	}
+	c.steps = []sagaStep{
+		{name: "checkProduct", run: c.checkProduct},
+		{name: "reserveStock", run: c.reserveStock, compensate: c.releaseStock, resume: c.holdStock},
+		{name: "applyDiscount", run: c.applyDiscount, compensate: c.voidDiscount},
+		{name: "createOrder", run: c.createOrder},
+	}
+	return c
+}
+
+// StartSale runs a new sale saga to its end and returns its final state. A
+// saga that failed and was compensated is not an error; the reason is in
+// saga.Error. An error is returned only if the saga could not be driven to a
+// final state, in which case Resume picks it up again.
+func (c *SagaController) StartSale(ctx context.Context, req *model.SaleRequest) (*model.Saga, error) {
+	ctx, span := tracing.Start(ctx, "controller.SagaController.StartSale")
+	defer span.End()
+
+	if req.ProductID <= 0 {
+		return nil, fmt.Errorf("%w: invalid product ID", model.ErrInvalidSale)
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than zero", model.ErrInvalidSale)
+	}
+
+	now := time.Now()
+	saga := &model.Saga{
+		ID:        newSagaID(),
+		Status:    model.SagaRunning,
+		Request:   *req,
+		Steps:     []model.SagaStep{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := c.repo.Save(ctx, saga); err != nil {
+		return nil, err
+	}
+	return saga, c.drive(ctx, saga)
+}
+
+// Get returns the state of a saga.
+func (c *SagaController) Get(ctx context.Context, id model.SagaID) (*model.Saga, error) {
+	ctx, span := tracing.Start(ctx, "controller.SagaController.Get")
+	defer span.End()
+
+	return c.repo.Get(ctx, id)
+}
+
+// GetByOrderID returns the saga that placed an order.
+func (c *SagaController) GetByOrderID(ctx context.Context, orderID model.OrderID) (*model.Saga, error) {
+	ctx, span := tracing.Start(ctx, "controller.SagaController.GetByOrderID")
+	defer span.End()
+
+	return c.repo.GetByOrderID(ctx, orderID)
+}
+
+// Resume drives every saga that has not reached a final state, e.g. because
+// the service stopped while it was running. It returns the joined errors of
+// the sagas that still could not be finished.
+func (c *SagaController) Resume(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "controller.SagaController.Resume")
+	defer span.End()
+
+	sagas, err := c.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, saga := range sagas {
+		if saga.Finished() {
+			continue
+		}
+		slog.InfoContext(ctx, "resuming saga", "saga_id", saga.ID, "status", saga.Status)
+		if err := c.resume(ctx, saga); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := c.drive(ctx, saga); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resume re-establishes the side effects of the completed steps of a running
+// saga that were lost with the previous process.
+func (c *SagaController) resume(ctx context.Context, saga *model.Saga) error {
+	if saga.Status != model.SagaRunning {
+		return nil // compensations find nothing to undo for lost side effects
+	}
+	for i, done := range saga.Steps {
+		step := c.steps[i]
+		if done.Status != model.SagaStepDone || step.resume == nil {
+			continue
+		}
+		if err := step.resume(ctx, saga); err != nil {
+			return fmt.Errorf("failed to resume step %s of saga %s: %w", step.name, saga.ID, err)
+		}
+	}
+	return nil
+}
+
+// drive runs the remaining steps of a saga, or its remaining compensations
+// once a step has failed.
+func (c *SagaController) drive(ctx context.Context, saga *model.Saga) error {
+	for saga.Status == model.SagaRunning && len(saga.Steps) < len(c.steps) {
+		step := c.steps[len(saga.Steps)]
+		if err := step.run(ctx, saga); err != nil {
+			slog.WarnContext(ctx, "saga step failed, compensating", "saga_id", saga.ID, "step", step.name, "error", err)
+			saga.Status = model.SagaCompensating
+			saga.Error = fmt.Sprintf("%s: %v", step.name, err)
+		} else {
+			saga.Steps = append(saga.Steps, model.SagaStep{Name: step.name, Status: model.SagaStepDone, At: time.Now()})
+		}
+		if err := c.save(ctx, saga); err != nil {
+			return err
+		}
+	}
+	if saga.Status == model.SagaRunning {
+		saga.Status = model.SagaCompleted
+		return c.save(ctx, saga)
+	}
+
+	for i := len(saga.Steps) - 1; i >= 0; i-- {
+		done := &saga.Steps[i]
+		step := c.steps[i]
+		if done.Status != model.SagaStepDone || step.compensate == nil {
+			continue
+		}
+		if err := step.compensate(ctx, saga); err != nil {
+			return fmt.Errorf("failed to compensate step %s of saga %s: %w", step.name, saga.ID, err)
+		}
+		done.Status = model.SagaStepCompensated
+		done.At = time.Now()
+		if err := c.save(ctx, saga); err != nil {
+			return err
+		}
+	}
+	saga.Status = model.SagaCompensated
+	return c.save(ctx, saga)
+}
+
+func (c *SagaController) save(ctx context.Context, saga *model.Saga) error {
+	saga.UpdatedAt = time.Now()
+	if err := c.repo.Save(ctx, saga); err != nil {
+		return fmt.Errorf("failed to save saga %s: %w", saga.ID, err)
+	}
+	return nil
+}
+
+// checkProduct looks up the current catalog price, which also verifies that the product exists.
+func (c *SagaController) checkProduct(ctx context.Context, saga *model.Saga) error {
+	price, err := c.prices.PriceAt(ctx, saga.Request.ProductID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to look up product: %w", err)
+	}
+	saga.UnitPrice = &price
+	return nil
+}
+
+// reserveStock holds the requested quantity if it is available, i.e. not
+// already taken by completed orders, pending sales or other reservations.
+func (c *SagaController) reserveStock(ctx context.Context, saga *model.Saga) error {
+	c.stockMu.Lock()
+	defer c.stockMu.Unlock()
+
+	productID := saga.Request.ProductID
+	stock, err := c.orders.CurrentStock(ctx, productID)
+	if err != nil {
+		return err
+	}
+	held, err := c.reservations.Held(ctx, productID)
+	if err != nil {
+		return err
+	}
+	pending, err := c.pendingSales(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if available := stock - held - pending; available < saga.Request.Quantity {
+		return fmt.Errorf("%w: %d available, %d requested", model.ErrInsufficientStock, available, saga.Request.Quantity)
+	}
+	return c.holdStock(ctx, saga)
+}
+
+// holdStock reserves the requested quantity under the saga ID. The stock was
+// checked when the step first ran, so a resumed saga holds it again without
+// checking; an existing reservation of the saga is kept.
+func (c *SagaController) holdStock(ctx context.Context, saga *model.Saga) error {
+	reservation, err := c.reservations.Create(ctx, &model.Reservation{
+		ProductID: saga.Request.ProductID,
+		Quantity:  saga.Request.Quantity,
+		Reference: string(saga.ID),
+	})
+	if err != nil {
+		return err
+	}
+	saga.ReservationID = reservation.ID
+	return nil
+}
+
+// releaseStock releases the reservation of the saga, found by the saga ID.
+func (c *SagaController) releaseStock(ctx context.Context, saga *model.Saga) error {
+	err := c.reservations.Release(ctx, string(saga.ID))
+	if errors.Is(err, model.ErrReservationNotFound) {
+		return nil
+	}
+	return err
+}
+
+// applyDiscount redeems the discount code of the sale, if any.
+func (c *SagaController) applyDiscount(ctx context.Context, saga *model.Saga) error {
+	if saga.Request.DiscountCode == "" {
+		return nil
+	}
+	redemption, err := c.discounts.Redeem(ctx, saga.Request.DiscountCode, saga.Request.ProductID, *saga.UnitPrice, string(saga.ID))
+	if err != nil {
+		return fmt.Errorf("failed to redeem discount: %w", err)
+	}
+	saga.RedemptionID = int(redemption.ID)
+	saga.DiscountedPrice = &redemption.DiscountedPrice
+	return nil
+}
+
+// voidDiscount gives back the discount use redeemed under the saga ID. It
+// also finds a redemption whose response was lost, e.g. by a crash before the
+// saga was saved, which RedemptionID would miss.
+func (c *SagaController) voidDiscount(ctx context.Context, saga *model.Saga) error {
+	if saga.Request.DiscountCode == "" {
+		return nil
+	}
+	err := c.discounts.VoidByReference(ctx, saga.Request.DiscountCode, string(saga.ID))
+	if errors.Is(err, gateway.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// createOrder places the pending sale order and hands the reserved stock over to it.
+func (c *SagaController) createOrder(ctx context.Context, saga *model.Saga) error {
+	order, err := c.sagaOrder(ctx, saga)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		price := *saga.UnitPrice
+		if saga.DiscountedPrice != nil {
+			price = *saga.DiscountedPrice
+		}
+		metadata := map[string]string{sagaMetadataKey: string(saga.ID)}
+		if saga.Request.DiscountCode != "" {
+			metadata["discountCode"] = saga.Request.DiscountCode
+		}
+		order, err = c.orders.CreateOrder(ctx, &model.Order{
+			ProductID:  saga.Request.ProductID,
+			Quantity:   saga.Request.Quantity,
+			Price:      price,
+			Type:       enums.OrderTypeSale,
+			CustomerID: saga.Request.CustomerID,
+			Metadata:   metadata,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	saga.OrderID = order.ID
+	return c.releaseStock(ctx, saga)
+}
+
+// sagaOrder returns the order a previous run of the saga already placed, if any.
+func (c *SagaController) sagaOrder(ctx context.Context, saga *model.Saga) (*model.Order, error) {
+	orders, err := c.orders.GetOrdersByProductID(ctx, saga.Request.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.Metadata[sagaMetadataKey] == string(saga.ID) {
+			return order, nil
+		}
+	}
+	return nil, nil
+}
+
+// pendingSales returns the quantity of a product sold by orders that are not completed yet.
+func (c *SagaController) pendingSales(ctx context.Context, productID catalogModel.ProductID) (int, error) {
+	orders, err := c.orders.GetOrdersByProductID(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, order := range orders {
+		if order.Type == enums.OrderTypeSale && order.Status == enums.OrderStatusPending {
+			pending += order.Quantity
+		}
+	}
+	return pending, nil
+}
+
+func newSagaID() model.SagaID {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return model.SagaID(hex.EncodeToString(b))
+}
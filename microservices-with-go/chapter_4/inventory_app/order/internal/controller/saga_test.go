@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	catalogModel "inventory.com/catalog/pkg/model"
+	discountModel "inventory.com/discount/pkg"
+	"inventory.com/order/internal/repository/file"
+	"inventory.com/order/internal/repository/memory"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tenant"
+)
+
+// defaultTenant returns a context bound to tenant.Default, like the ones
+// the tenant middleware hands to the handlers.
+func defaultTenant() context.Context {
+	return tenant.WithID(context.Background(), tenant.Default)
+}
+
+type fixedPrice money.Money
+
+func (p fixedPrice) PriceAt(ctx context.Context, productID catalogModel.ProductID, at time.Time) (money.Money, error) {
+	return money.Money(p), nil
+}
+
+type fakeDiscounts struct {
+	redeemErr error
+	voided    []string // References of the voided redemptions
+}
+
+func (f *fakeDiscounts) Redeem(ctx context.Context, code string, productID catalogModel.ProductID, unitPrice money.Money, reference string) (*discountModel.Redemption, error) {
+	if f.redeemErr != nil {
+		return nil, f.redeemErr
+	}
+	discounted, _ := (&discountModel.Discount{Code: code, BasisPoints: 1000}).Apply(unitPrice)
+	return &discountModel.Redemption{ID: 1, Code: code, UnitPrice: unitPrice, DiscountedPrice: discounted}, nil
+}
+
+func (f *fakeDiscounts) VoidByReference(ctx context.Context, code, reference string) error {
+	f.voided = append(f.voided, reference)
+	return nil
+}
+
+func newSagaFixture(t *testing.T, stock int) (*SagaController, *OrderController, *memory.Reservation, *fakeDiscounts) {
+	price := money.Money{Amount: 1000, Currency: "USD"}
+	orders := NewOrderController(memory.New(), fixedPrice(price), audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+	if stock > 0 {
+		ctx := defaultTenant()
+		buy, err := orders.CreateOrder(ctx, &model.Order{ProductID: 1, Quantity: stock, Price: price, Type: enums.OrderTypeBuy})
+		assert.NoError(t, err)
+		assert.NoError(t, orders.UpdateOrderStatus(ctx, buy.ID, enums.OrderStatusCompleted))
+	}
+
+	sagas, err := file.NewSaga(t.TempDir())
+	assert.NoError(t, err)
+	reservations := memory.NewReservation()
+	discounts := &fakeDiscounts{}
+	return NewSagaController(sagas, reservations, fixedPrice(price), discounts, orders), orders, reservations, discounts
+}
+
+func TestSagaController_StartSale(t *testing.T) {
+	ctx := defaultTenant()
+	sagas, orders, reservations, _ := newSagaFixture(t, 10)
+
+	saga, err := sagas.StartSale(ctx, &model.SaleRequest{ProductID: 1, Quantity: 4, DiscountCode: "TEN"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.SagaCompleted, saga.Status)
+	assert.Len(t, saga.Steps, 4)
+
+	order, err := orders.GetOrder(ctx, saga.OrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(900), order.Price.Amount)
+	held, _ := reservations.Held(ctx, 1)
+	assert.Zero(t, held, "the reservation is handed over to the order")
+
+	byOrder, err := sagas.GetByOrderID(ctx, saga.OrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, saga.ID, byOrder.ID)
+
+	// The pending sale keeps its stock, so 7 more units are not available.
+	saga, err = sagas.StartSale(ctx, &model.SaleRequest{ProductID: 1, Quantity: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, model.SagaCompensated, saga.Status)
+	assert.Contains(t, saga.Error, model.ErrInsufficientStock.Error())
+}
+
+func TestSagaController_CompensatesFailedStep(t *testing.T) {
+	ctx := defaultTenant()
+	sagas, _, reservations, discounts := newSagaFixture(t, 10)
+	discounts.redeemErr = errors.New("discount has no redemptions left")
+
+	saga, err := sagas.StartSale(ctx, &model.SaleRequest{ProductID: 1, Quantity: 4, DiscountCode: "TEN"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.SagaCompensated, saga.Status)
+	assert.Equal(t, model.SagaStepCompensated, saga.Steps[1].Status)
+	assert.Empty(t, discounts.voided, "a failed redemption is not voided")
+	held, _ := reservations.Held(ctx, 1)
+	assert.Zero(t, held)
+	assert.Zero(t, saga.OrderID)
+}
+
+func TestSagaController_Resume(t *testing.T) {
+	ctx := defaultTenant()
+	sagas, _, _, discounts := newSagaFixture(t, 10)
+
+	// A saga that stopped while compensating after its discount was redeemed.
+	interrupted := &model.Saga{
+		ID:      "interrupted",
+		Status:  model.SagaCompensating,
+		Request: model.SaleRequest{ProductID: 1, Quantity: 1, DiscountCode: "TEN"},
+		Steps: []model.SagaStep{
+			{Name: "checkProduct", Status: model.SagaStepDone},
+			{Name: "reserveStock", Status: model.SagaStepDone},
+			{Name: "applyDiscount", Status: model.SagaStepDone},
+		},
+	}
+	assert.NoError(t, sagas.repo.Save(ctx, interrupted))
+
+	assert.NoError(t, sagas.Resume(ctx))
+	saga, err := sagas.Get(ctx, "interrupted")
+	assert.NoError(t, err)
+	assert.Equal(t, model.SagaCompensated, saga.Status)
+	assert.Equal(t, []string{"interrupted"}, discounts.voided)
+}
+
+func TestSagaController_Resume_HoldsStockAgain(t *testing.T) {
+	ctx := defaultTenant()
+	sagas, _, reservations, _ := newSagaFixture(t, 10)
+
+	// A saga that stopped after reserving stock, whose in-memory reservation
+	// was lost with the process. Another saga's reservation got ID 1 since.
+	running := &model.Saga{
+		ID:            "running",
+		Status:        model.SagaRunning,
+		Request:       model.SaleRequest{ProductID: 1, Quantity: 3},
+		ReservationID: 1,
+		Steps: []model.SagaStep{
+			{Name: "checkProduct", Status: model.SagaStepDone},
+			{Name: "reserveStock", Status: model.SagaStepDone},
+		},
+	}
+	_, err := reservations.Create(ctx, &model.Reservation{ProductID: 1, Quantity: 2, Reference: "other"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, sagas.resume(ctx, running))
+	held, _ := reservations.Held(ctx, 1)
+	assert.Equal(t, 5, held, "the resumed saga holds its stock again")
+
+	assert.NoError(t, sagas.releaseStock(ctx, running))
+	held, _ = reservations.Held(ctx, 1)
+	assert.Equal(t, 2, held, "releasing by the saga ID leaves the other reservation")
+}
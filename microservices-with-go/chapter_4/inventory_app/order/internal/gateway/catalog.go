@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/money"
+)
+
+var (
+	// ErrNotFound is returned when the requested resource is not found.
+	ErrNotFound = fmt.Errorf("resource not found")
+)
+
+// IHTTPClient sends the requests of the gateways.
+type IHTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// CatalogGateway defines an HTTP gateway for the catalog service.
+type CatalogGateway struct {
+	addr   string
+	client IHTTPClient
+}
+
+// NewCatalogGateway creates a new HTTP gateway for the catalog service.
+func NewCatalogGateway(addr string, client IHTTPClient) *CatalogGateway {
+	return &CatalogGateway{addr, client}
+}
+
+// PriceAt returns the list price of a product that was in effect at the given instant.
+func (g *CatalogGateway) PriceAt(ctx context.Context, productID catalogModel.ProductID, at time.Time) (money.Money, error) {
+	endpoint := fmt.Sprintf("%s/products/%d/prices?at=%s", g.addr, int(productID), url.QueryEscape(at.Format(time.RFC3339Nano)))
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return money.Money{}, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return money.Money{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return money.Money{}, ErrNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return money.Money{}, fmt.Errorf("non-2xx response: %v", resp)
+	}
+
+	var change catalogModel.PriceChange
+	if err := json.NewDecoder(resp.Body).Decode(&change); err != nil {
+		return money.Money{}, err
+	}
+	return change.Price, nil
+}
@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	discountModel "inventory.com/discount/pkg"
+	"inventory.com/pkg/money"
+)
+
+// DiscountGateway defines an HTTP gateway for the discount service.
+type DiscountGateway struct {
+	addr   string
+	client IHTTPClient
+}
+
+// NewDiscountGateway creates a new HTTP gateway for the discount service.
+func NewDiscountGateway(addr string, client IHTTPClient) *DiscountGateway {
+	return &DiscountGateway{addr, client}
+}
+
+// Redeem uses a discount code for a product sold at unitPrice. The reference
+// makes the call idempotent: redeeming again with it returns the same redemption.
+func (g *DiscountGateway) Redeem(ctx context.Context, code string, productID catalogModel.ProductID, unitPrice money.Money, reference string) (*discountModel.Redemption, error) {
+	body, err := json.Marshal(map[string]any{
+		"productID": productID,
+		"unitPrice": unitPrice,
+		"reference": reference,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/discounts/%s/redemptions", g.addr, url.PathEscape(code)), bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	} else if resp.StatusCode != http.StatusCreated {
+		return nil, responseError(resp)
+	}
+
+	var redemption discountModel.Redemption
+	if err := json.NewDecoder(resp.Body).Decode(&redemption); err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// VoidByReference gives back the redemption of a discount code made with the
+// given reference. Voiding a redemption that is already voided succeeds.
+func (g *DiscountGateway) VoidByReference(ctx context.Context, code, reference string) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/discounts/%s/redemptions/%s", g.addr, url.PathEscape(code), url.PathEscape(reference)), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// responseError turns a non-2xx response into an error carrying the
+// service's error message, if it sent one.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("non-2xx response: %s", resp.Status)
+	}
+	return errors.New(body.Error)
+}
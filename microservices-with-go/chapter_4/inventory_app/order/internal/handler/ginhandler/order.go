@@ -2,6 +2,8 @@ package ginhandler
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -9,6 +11,9 @@ import (
 	catalogModel "inventory.com/catalog/pkg/model"
 	"inventory.com/order/pkg/enums"
 	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/money"
 )
 
 // IOrderController defines the interface for order operations.
@@ -18,6 +23,7 @@ type IOrderController interface {
 	GetOrdersByProductID(ctx context.Context, productID catalogModel.ProductID) ([]*model.Order, error)
 	UpdateOrderStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus) error
 	GetOrder(ctx context.Context, orderID model.OrderID) (*model.Order, error)
+	PatchOrderMetadata(ctx context.Context, orderID model.OrderID, patch jsonpatch.Func) (*model.Order, error)
 	CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error)
 }
 
@@ -42,6 +48,10 @@ func (h *orderHandler) CreateOrder(ctx *gin.Context) {
 	}
 
 	createdOrder, err := h.ctrl.CreateOrder(ctx.Request.Context(), order)
+	if errors.Is(err, money.ErrInvalid) || errors.Is(err, model.ErrInvalidOrder) || errors.Is(err, model.ErrPriceMismatch) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -131,6 +141,48 @@ func (h *orderHandler) GetOrder(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, order)
 }
+
+// PatchOrderMetadata applies a merge patch (application/merge-patch+json) or
+// JSON patch (application/json-patch+json) to the customer and metadata of an order.
+func (h *orderHandler) PatchOrderMetadata(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("orderID"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patch"})
+		return
+	}
+	patch, err := jsonpatch.Parse(ctx.GetHeader("Content-Type"), body)
+	if errors.Is(err, jsonpatch.ErrUnsupportedMediaType) {
+		ctx.Header("Accept-Patch", jsonpatch.MediaTypeMergePatch+", "+jsonpatch.MediaTypeJSONPatch)
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.ctrl.PatchOrderMetadata(ctx.Request.Context(), model.OrderID(orderID), patch)
+	if errors.Is(err, model.ErrOrderNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, jsonpatch.ErrCannotApply) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
 func (h *orderHandler) CurrentStock(ctx *gin.Context) {
 	productID, err := strconv.Atoi(ctx.Param("productID"))
 	if err != nil {
@@ -145,17 +197,44 @@ func (h *orderHandler) CurrentStock(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, gin.H{"currentStock": stock})
 }
+
+// CurrentStocks returns the stock of every product given as a productID query
+// parameter, so callers needing many products avoid one request per product.
+func (h *orderHandler) CurrentStocks(ctx *gin.Context) {
+	type productStock struct {
+		ProductID    catalogModel.ProductID `json:"productID"`
+		CurrentStock int                    `json:"currentStock"`
+	}
+
+	stocks := []productStock{}
+	for _, raw := range ctx.QueryArray("productID") {
+		productID, err := strconv.Atoi(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		stock, err := h.ctrl.CurrentStock(ctx.Request.Context(), catalogModel.ProductID(productID))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		stocks = append(stocks, productStock{ProductID: catalogModel.ProductID(productID), CurrentStock: stock})
+	}
+	ctx.JSON(http.StatusOK, stocks)
+}
 func RegisterOrderRoutes(router *gin.Engine, ctrl IOrderController) {
 	handler := newOrderHandler(ctrl)
 
 	orderGroup := router.Group("/orders")
 	{
-		orderGroup.POST("/", handler.CreateOrder)
-		orderGroup.GET("/", handler.GetAllOrders)
-		orderGroup.GET("/product/:productID", handler.GetOrdersByProductID)
-		orderGroup.PUT("/:orderID/status/completed", handler.UpdateOrderStatusCompleted)
-		orderGroup.PUT("/:orderID/status/cancelled", handler.UpdateOrderStatusCancelled)
-		orderGroup.GET("/:orderID", handler.GetOrder)
-		orderGroup.GET("/product/:productID/stock", handler.CurrentStock)
+		orderGroup.POST("/", auth.Require(auth.PermOrdersWrite), handler.CreateOrder)
+		orderGroup.GET("/", auth.Require(auth.PermOrdersRead), handler.GetAllOrders)
+		orderGroup.GET("/product/:productID", auth.Require(auth.PermOrdersRead), handler.GetOrdersByProductID)
+		orderGroup.PUT("/:orderID/status/completed", auth.Require(auth.PermOrdersWrite), handler.UpdateOrderStatusCompleted)
+		orderGroup.PUT("/:orderID/status/cancelled", auth.Require(auth.PermOrdersWrite), handler.UpdateOrderStatusCancelled)
+		orderGroup.GET("/:orderID", auth.Require(auth.PermOrdersRead), handler.GetOrder)
+		orderGroup.PATCH("/:orderID", auth.Require(auth.PermOrdersWrite), handler.PatchOrderMetadata)
+		orderGroup.GET("/product/:productID/stock", auth.Require(auth.PermOrdersRead), handler.CurrentStock)
+		orderGroup.GET("/stock", auth.Require(auth.PermOrdersRead), handler.CurrentStocks)
 	}
 }
@@ -0,0 +1,57 @@
+package ginhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/order/internal/controller"
+	"inventory.com/order/internal/repository/memory"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/audit"
+	"inventory.com/pkg/auth"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/jsonpatch"
+	"inventory.com/pkg/money"
+	"inventory.com/pkg/tenant"
+)
+
+var unitPrice = money.Money{Amount: 1000, Currency: "USD"}
+
+type fixedPrice struct{}
+
+func (fixedPrice) PriceAt(ctx context.Context, productID catalogModel.ProductID, at time.Time) (money.Money, error) {
+	return unitPrice, nil
+}
+
+func TestPatchOrderMetadata(t *testing.T) {
+	ctrl := controller.NewOrderController(memory.New(), fixedPrice{}, audit.NewRecorder(audit.NewMemoryStore()), events.NewMemoryOutbox())
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	_, err := ctrl.CreateOrder(ctx, &model.Order{ProductID: 1, Quantity: 1, Price: unitPrice, Type: enums.OrderTypeBuy})
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		clerk := auth.WithPrincipal(c.Request.Context(), &auth.Principal{Subject: "clerk", Roles: []string{auth.RoleOrderClerk}})
+		c.Request = c.Request.WithContext(tenant.WithID(clerk, tenant.Default))
+	})
+	RegisterOrderRoutes(engine, ctrl)
+
+	patch := func(path string) int {
+		req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(`{"customerID":7}`))
+		req.Header.Set("Content-Type", jsonpatch.MediaTypeMergePatch)
+		resp := httptest.NewRecorder()
+		engine.ServeHTTP(resp, req)
+		return resp.Code
+	}
+	assert.Equal(t, http.StatusOK, patch("/orders/1"))
+	assert.Equal(t, http.StatusNotFound, patch("/orders/2"))
+}
@@ -0,0 +1,86 @@
+package ginhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/auth"
+)
+
+// ISagaController defines the interface for sale saga operations.
+type ISagaController interface {
+	StartSale(ctx context.Context, req *model.SaleRequest) (*model.Saga, error)
+	Get(ctx context.Context, id model.SagaID) (*model.Saga, error)
+	GetByOrderID(ctx context.Context, orderID model.OrderID) (*model.Saga, error)
+}
+
+type sagaHandler struct {
+	ctrl ISagaController
+}
+
+// StartSale runs a sale saga. A completed saga is answered with 201 and a
+// compensated one with 409, both carrying the saga. If the saga could not be
+// finished it is resumed later and 500 is returned.
+func (h *sagaHandler) StartSale(ctx *gin.Context) {
+	var req model.SaleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sale data"})
+		return
+	}
+
+	saga, err := h.ctrl.StartSale(ctx.Request.Context(), &req)
+	switch {
+	case errors.Is(err, model.ErrInvalidSale):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case saga == nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "saga": saga})
+	case saga.Status == model.SagaCompleted:
+		ctx.JSON(http.StatusCreated, saga)
+	default:
+		ctx.JSON(http.StatusConflict, saga)
+	}
+}
+
+// GetSaga returns the state of a saga.
+func (h *sagaHandler) GetSaga(ctx *gin.Context) {
+	saga, err := h.ctrl.Get(ctx.Request.Context(), model.SagaID(ctx.Param("sagaID")))
+	h.respond(ctx, saga, err)
+}
+
+// GetOrderSaga returns the state of the saga that placed an order.
+func (h *sagaHandler) GetOrderSaga(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("orderID"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	saga, err := h.ctrl.GetByOrderID(ctx.Request.Context(), model.OrderID(orderID))
+	h.respond(ctx, saga, err)
+}
+
+func (h *sagaHandler) respond(ctx *gin.Context, saga *model.Saga, err error) {
+	if errors.Is(err, model.ErrSagaNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, saga)
+}
+
+// RegisterSagaRoutes registers the routes starting sales and reporting saga status.
+func RegisterSagaRoutes(router *gin.Engine, ctrl ISagaController) {
+	h := &sagaHandler{ctrl: ctrl}
+
+	router.POST("/sales", auth.Require(auth.PermOrdersWrite), h.StartSale)
+	router.GET("/sagas/:sagaID", auth.Require(auth.PermOrdersRead), h.GetSaga)
+	router.GET("/orders/:orderID/saga", auth.Require(auth.PermOrdersRead), h.GetOrderSaga)
+}
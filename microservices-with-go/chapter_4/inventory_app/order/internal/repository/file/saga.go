@@ -0,0 +1,174 @@
+// Package file implements order repositories that persist to the local file
+// system, for state that has to survive a restart of the service.
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+// Saga stores every saga as <id>.json in a directory per tenant: the sagas
+// of tenant.Default in the directory itself, those of any other tenant in a
+// subdirectory named after it. Files are replaced atomically, so a crash
+// never leaves a partially written saga behind.
+type Saga struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+// NewSaga returns a saga repository persisting to dir, creating it if needed.
+func NewSaga(dir string) (*Saga, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create saga directory: %w", err)
+	}
+	return &Saga{dir: dir}, nil
+}
+
+// Ping checks that sagas can still be written, by creating and removing a
+// temporary file in the saga directory.
+func (repo *Saga) Ping(ctx context.Context) error {
+	tmp, err := os.CreateTemp(repo.dir, "ping-*.tmp")
+	if err != nil {
+		return fmt.Errorf("saga directory is not writable: %w", err)
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// Save creates or replaces a saga.
+func (repo *Saga) Save(ctx context.Context, saga *model.Saga) error {
+	data, err := json.MarshalIndent(saga, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	dir, err := repo.tenantDir(ctx)
+	if err != nil {
+		return err
+	}
+	path, err := repo.path(ctx, saga.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create saga directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "saga-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get returns a saga. Returns model.ErrSagaNotFound if not found.
+func (repo *Saga) Get(ctx context.Context, id model.SagaID) (*model.Saga, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	path, err := repo.path(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return repo.read(path)
+}
+
+// GetByOrderID returns the saga that created an order. Returns
+// model.ErrSagaNotFound if the order was not placed by a saga.
+func (repo *Saga) GetByOrderID(ctx context.Context, orderID model.OrderID) (*model.Saga, error) {
+	sagas, err := repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, saga := range sagas {
+		if saga.OrderID == orderID {
+			return saga, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: orderID=%d", model.ErrSagaNotFound, orderID)
+}
+
+// GetAll returns all stored sagas, oldest first.
+func (repo *Saga) GetAll(ctx context.Context) ([]*model.Saga, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	dir, err := repo.tenantDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sagas := make([]*model.Saga, 0, len(paths))
+	for _, path := range paths {
+		saga, err := repo.read(path)
+		if err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, saga)
+	}
+	slices.SortFunc(sagas, func(a, b *model.Saga) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return sagas, nil
+}
+
+func (repo *Saga) read(path string) (*model.Saga, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: id=%s", model.ErrSagaNotFound, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saga model.Saga
+	if err := json.Unmarshal(data, &saga); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return &saga, nil
+}
+
+func (repo *Saga) path(ctx context.Context, id model.SagaID) (string, error) {
+	dir, err := repo.tenantDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(string(id))+".json"), nil
+}
+
+// tenantDir returns the directory of the tenant ctx is bound to. Returns
+// tenant.ErrNoTenant if ctx is not bound to a tenant.
+func (repo *Saga) tenantDir(ctx context.Context) (string, error) {
+	id, ok := tenant.Lookup(ctx)
+	if !ok {
+		return "", tenant.ErrNoTenant
+	}
+	if id == tenant.Default {
+		return repo.dir, nil
+	}
+	return filepath.Join(repo.dir, filepath.Base(string(id))), nil
+}
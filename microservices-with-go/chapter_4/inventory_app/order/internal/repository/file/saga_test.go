@@ -0,0 +1,48 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+func TestSaga_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	dir := t.TempDir()
+	repo, err := NewSaga(dir)
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, repo.Save(acme, &model.Saga{ID: "saga-1", Status: model.SagaCompleted, OrderID: 1, CreatedAt: now}))
+	require.NoError(t, repo.Save(globex, &model.Saga{ID: "saga-1", Status: model.SagaRunning, OrderID: 1, CreatedAt: now}))
+
+	got, err := repo.Get(acme, "saga-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.SagaCompleted, got.Status)
+	got, err = repo.Get(globex, "saga-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.SagaRunning, got.Status, "the same ID of another tenant is a different saga")
+	got, err = repo.GetByOrderID(globex, 1)
+	require.NoError(t, err)
+	assert.Equal(t, model.SagaRunning, got.Status)
+
+	_, err = repo.Get(tenant.WithID(context.Background(), tenant.Default), "saga-1")
+	assert.ErrorIs(t, err, model.ErrSagaNotFound)
+	sagas, err := repo.GetAll(tenant.WithID(context.Background(), tenant.Default))
+	require.NoError(t, err)
+	assert.Empty(t, sagas, "the directory of the default tenant lists only its own sagas")
+	_, err = os.Stat(filepath.Join(dir, "acme", "saga-1.json"))
+	assert.NoError(t, err)
+
+	_, err = repo.GetAll(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+	assert.ErrorIs(t, repo.Save(context.Background(), &model.Saga{ID: "saga-2"}), tenant.ErrNoTenant)
+}
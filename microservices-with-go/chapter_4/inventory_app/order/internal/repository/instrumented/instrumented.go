@@ -0,0 +1,34 @@
+// Package instrumented decorates the order repositories with a span and a
+// duration metric around every call. The decorators are applied when the
+// service is wired, so the repositories themselves know nothing about
+// tracing or metrics.
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"inventory.com/pkg/metrics"
+	"inventory.com/pkg/tracing"
+)
+
+// repository names a decorated repository after its implementation, e.g.
+// "memory.Order", and starts the observation of its operations.
+type repository string
+
+func nameOf(next any) repository {
+	return repository(strings.TrimPrefix(fmt.Sprintf("%T", next), "*"))
+}
+
+// start starts a span for operation and returns the function ending it and
+// recording the duration of the operation.
+func (r repository) start(ctx context.Context, operation string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, string(r)+"."+operation)
+	start := time.Now()
+	return ctx, func() {
+		span.End()
+		metrics.ObserveRepository(string(r), operation, start)
+	}
+}
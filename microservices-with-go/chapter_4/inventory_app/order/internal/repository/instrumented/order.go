@@ -0,0 +1,92 @@
+package instrumented
+
+import (
+	"context"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/order/internal/controller"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+)
+
+// OrderRepository is the order repository being decorated.
+type OrderRepository interface {
+	controller.IOrderRepository
+	Ping(ctx context.Context) error
+}
+
+// Order instruments an order repository.
+type Order struct {
+	repository
+	next OrderRepository
+}
+
+// projectedOrder instruments an order repository keeping a stock projection.
+type projectedOrder struct {
+	*Order
+	projection controller.IStockProjection
+}
+
+// NewOrder instruments next. When next keeps a stock projection the
+// returned repository keeps exposing it as a controller.IStockProjection.
+func NewOrder(next OrderRepository) OrderRepository {
+	order := &Order{repository: nameOf(next), next: next}
+	if projection, ok := next.(controller.IStockProjection); ok {
+		return &projectedOrder{Order: order, projection: projection}
+	}
+	return order
+}
+
+func (r *Order) Ping(ctx context.Context) error {
+	ctx, end := r.start(ctx, "Ping")
+	defer end()
+	return r.next.Ping(ctx)
+}
+
+func (r *Order) Create(ctx context.Context, orderRecord *model.Order, commit func(before, after *model.Order, stock int) error) (*model.Order, error) {
+	ctx, end := r.start(ctx, "Create")
+	defer end()
+	return r.next.Create(ctx, orderRecord, commit)
+}
+
+func (r *Order) GetAll(ctx context.Context) ([]*model.Order, error) {
+	ctx, end := r.start(ctx, "GetAll")
+	defer end()
+	return r.next.GetAll(ctx)
+}
+
+func (r *Order) GetByProductID(ctx context.Context, productID catalogModel.ProductID) ([]*model.Order, error) {
+	ctx, end := r.start(ctx, "GetByProductID")
+	defer end()
+	return r.next.GetByProductID(ctx, productID)
+}
+
+func (r *Order) UpdateStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus, commit func(before, after *model.Order, stock int) error) error {
+	ctx, end := r.start(ctx, "UpdateStatus")
+	defer end()
+	return r.next.UpdateStatus(ctx, orderID, status, commit)
+}
+
+func (r *Order) UpdateMetadata(ctx context.Context, orderID model.OrderID, metadata *model.OrderMetadata, commit func(before, after *model.Order, stock int) error) error {
+	ctx, end := r.start(ctx, "UpdateMetadata")
+	defer end()
+	return r.next.UpdateMetadata(ctx, orderID, metadata, commit)
+}
+
+func (r *Order) Get(ctx context.Context, orderID model.OrderID) (*model.Order, error) {
+	ctx, end := r.start(ctx, "Get")
+	defer end()
+	return r.next.Get(ctx, orderID)
+}
+
+func (r *projectedOrder) CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error) {
+	ctx, end := r.start(ctx, "CurrentStock")
+	defer end()
+	return r.projection.CurrentStock(ctx, productID)
+}
+
+func (r *projectedOrder) StockLevels(ctx context.Context) (map[catalogModel.ProductID]int, error) {
+	ctx, end := r.start(ctx, "StockLevels")
+	defer end()
+	return r.projection.StockLevels(ctx)
+}
@@ -0,0 +1,21 @@
+package instrumented
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"inventory.com/order/internal/controller"
+	"inventory.com/order/internal/repository/memory"
+)
+
+func TestNewOrder_KeepsStockProjection(t *testing.T) {
+	_, ok := NewOrder(memory.NewEventSourced(10)).(controller.IStockProjection)
+	assert.True(t, ok, "the event sourced repository keeps its stock projection")
+
+	_, ok = NewOrder(memory.New()).(controller.IStockProjection)
+	assert.False(t, ok)
+}
+
+func TestNameOf(t *testing.T) {
+	assert.Equal(t, repository("memory.Order"), nameOf(memory.New()))
+}
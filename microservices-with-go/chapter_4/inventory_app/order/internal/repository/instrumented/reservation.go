@@ -0,0 +1,49 @@
+package instrumented
+
+import (
+	"context"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/order/internal/controller"
+	"inventory.com/order/pkg/model"
+)
+
+// ReservationRepository is the reservation repository being decorated.
+type ReservationRepository interface {
+	controller.IReservationRepository
+	Ping(ctx context.Context) error
+}
+
+// Reservation instruments a reservation repository.
+type Reservation struct {
+	repository
+	next ReservationRepository
+}
+
+func NewReservation(next ReservationRepository) *Reservation {
+	return &Reservation{repository: nameOf(next), next: next}
+}
+
+func (r *Reservation) Ping(ctx context.Context) error {
+	ctx, end := r.start(ctx, "Ping")
+	defer end()
+	return r.next.Ping(ctx)
+}
+
+func (r *Reservation) Create(ctx context.Context, data *model.Reservation) (*model.Reservation, error) {
+	ctx, end := r.start(ctx, "Create")
+	defer end()
+	return r.next.Create(ctx, data)
+}
+
+func (r *Reservation) Release(ctx context.Context, reference string) error {
+	ctx, end := r.start(ctx, "Release")
+	defer end()
+	return r.next.Release(ctx, reference)
+}
+
+func (r *Reservation) Held(ctx context.Context, productID catalogModel.ProductID) (int, error) {
+	ctx, end := r.start(ctx, "Held")
+	defer end()
+	return r.next.Held(ctx, productID)
+}
@@ -0,0 +1,54 @@
+package instrumented
+
+import (
+	"context"
+
+	"inventory.com/order/internal/controller"
+	"inventory.com/order/pkg/model"
+)
+
+// SagaRepository is the saga repository being decorated.
+type SagaRepository interface {
+	controller.ISagaRepository
+	Ping(ctx context.Context) error
+}
+
+// Saga instruments a saga repository.
+type Saga struct {
+	repository
+	next SagaRepository
+}
+
+func NewSaga(next SagaRepository) *Saga {
+	return &Saga{repository: nameOf(next), next: next}
+}
+
+func (r *Saga) Ping(ctx context.Context) error {
+	ctx, end := r.start(ctx, "Ping")
+	defer end()
+	return r.next.Ping(ctx)
+}
+
+func (r *Saga) Save(ctx context.Context, saga *model.Saga) error {
+	ctx, end := r.start(ctx, "Save")
+	defer end()
+	return r.next.Save(ctx, saga)
+}
+
+func (r *Saga) Get(ctx context.Context, id model.SagaID) (*model.Saga, error) {
+	ctx, end := r.start(ctx, "Get")
+	defer end()
+	return r.next.Get(ctx, id)
+}
+
+func (r *Saga) GetByOrderID(ctx context.Context, orderID model.OrderID) (*model.Saga, error) {
+	ctx, end := r.start(ctx, "GetByOrderID")
+	defer end()
+	return r.next.GetByOrderID(ctx, orderID)
+}
+
+func (r *Saga) GetAll(ctx context.Context) ([]*model.Saga, error) {
+	ctx, end := r.start(ctx, "GetAll")
+	defer end()
+	return r.next.GetAll(ctx)
+}
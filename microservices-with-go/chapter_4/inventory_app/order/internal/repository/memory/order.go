@@ -2,7 +2,6 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -10,103 +9,182 @@ import (
 	catalogModel "inventory.com/catalog/pkg/model"
 	"inventory.com/order/pkg/enums"
 	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
 )
 
-var (
-	ErrOrderNotFound = errors.New("order not found")
-)
-
+// Order keeps orders in memory. Orders and their ID sequence are partitioned
+// per tenant; every method only sees the partition of the tenant its context
+// is bound to.
 type Order struct {
-	mu     sync.RWMutex
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[orderPartition]
+}
+
+// orderPartition holds the orders and ID sequence of one tenant.
+type orderPartition struct {
 	orders map[catalogModel.ProductID][]*model.Order
 	seqID  int
 }
 
 func New() *Order {
 	return &Order{
-		orders: make(map[catalogModel.ProductID][]*model.Order),
-		seqID:  0,
+		tenants: tenant.NewPartitions(func() *orderPartition {
+			return &orderPartition{orders: make(map[catalogModel.ProductID][]*model.Order)}
+		}),
 	}
 }
 
+// Ping checks that the repository answers. It waits for the lock like any
+// read, so a repository stuck holding its lock fails a timed check. It needs
+// no tenant and creates no partition.
+func (repo *Order) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
 // Create adds a new order to the in-memory store.
-// It automatically assigns a unique ID and initializes timestamps.
+// It automatically assigns a unique ID and initializes timestamps; a CreatedAt
+// supplied by the caller (e.g. for historical orders) is preserved.
 // Returns the created order record.
-func (repo *Order) Create(ctx context.Context, orderRecord *model.Order) (*model.Order, error) {
+//
+// Every write calls commit with the order before and after the change and the
+// stock of its product once the change is applied, while the repository is
+// locked; before is nil for a new order. The change is only stored if commit
+// returns nil, so whatever commit writes alongside it, e.g. outbox events, is
+// never observed without it.
+func (repo *Order) Create(ctx context.Context, orderRecord *model.Order, commit func(before, after *model.Order, stock int) error) (*model.Order, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
-	repo.seqID++
-	orderRecord.ID = model.OrderID(repo.seqID)
-	if _, exists := repo.orders[orderRecord.ProductID]; !exists {
-		repo.orders[orderRecord.ProductID] = []*model.Order{}
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	created := *orderRecord
+	created.ID = model.OrderID(part.seqID + 1)
+	if created.CreatedAt.IsZero() {
+		created.CreatedAt = time.Now()
+	}
+	created.UpdatedAt = time.Now()
+	if err := commit(nil, &created, part.stock(created.ProductID)+countedStock(&created)); err != nil {
+		return nil, err
 	}
-	repo.orders[orderRecord.ProductID] = append(repo.orders[orderRecord.ProductID], orderRecord)
-	orderRecord.CreatedAt = time.Now()
-	orderRecord.UpdatedAt = orderRecord.CreatedAt
 
+	part.seqID++
+	*orderRecord = created
+	part.orders[orderRecord.ProductID] = append(part.orders[orderRecord.ProductID], orderRecord)
 	return orderRecord, nil
 }
 
 // GetAll retrieves all orders across all products.
-// Returns ErrOrderNotFound if no orders exist.
+// Returns model.ErrOrderNotFound if no orders exist.
 func (repo *Order) GetAll(ctx context.Context) ([]*model.Order, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var allOrders []*model.Order
-	for _, orders := range repo.orders {
+	for _, orders := range part.orders {
 		allOrders = append(allOrders, orders...)
 	}
 
 	if len(allOrders) == 0 {
-		return nil, ErrOrderNotFound
+		return nil, model.ErrOrderNotFound
 	}
 	return allOrders, nil
 }
 
 // GetByProductID retrieves all orders for a specific product ID.
-// Returns ErrOrderNotFound if no orders exist for that product.
+// Returns model.ErrOrderNotFound if no orders exist for that product.
 func (repo *Order) GetByProductID(ctx context.Context, productID catalogModel.ProductID) ([]*model.Order, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	orders := repo.orders[productID]
+	orders := part.orders[productID]
 	if len(orders) == 0 {
-		return nil, fmt.Errorf("%w, productId:%d", ErrOrderNotFound, productID)
+		return nil, fmt.Errorf("%w, productId:%d", model.ErrOrderNotFound, productID)
 	}
 	return orders, nil
 }
 
-// UpdateStatus updates the status of an order by its ID.
-// Returns ErrOrderNotFound if the order does not exist.
-func (repo *Order) UpdateStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus) error {
+// UpdateStatus updates the status of an order by its ID, committing the
+// change like Create. Returns model.ErrOrderNotFound if the order does not exist.
+func (repo *Order) UpdateStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus, commit func(before, after *model.Order, stock int) error) error {
+	return repo.update(ctx, orderID, commit, func(order *model.Order) {
+		order.Status = status
+	})
+}
+
+// UpdateMetadata replaces the customer and metadata of an order by its ID,
+// committing the change like Create. Returns model.ErrOrderNotFound if the
+// order does not exist.
+func (repo *Order) UpdateMetadata(ctx context.Context, orderID model.OrderID, metadata *model.OrderMetadata, commit func(before, after *model.Order, stock int) error) error {
+	return repo.update(ctx, orderID, commit, func(order *model.Order) {
+		order.CustomerID = metadata.CustomerID
+		order.Metadata = metadata.Metadata
+	})
+}
+
+// update applies change to a copy of an order and stores it if commit accepts it.
+func (repo *Order) update(ctx context.Context, orderID model.OrderID, commit func(before, after *model.Order, stock int) error, change func(order *model.Order)) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
 
-	for _, orders := range repo.orders {
+	for _, orders := range part.orders {
 		for _, order := range orders {
-			if order.ID == orderID {
-				order.Status = status
-				// Update the timestamp to reflect the change
-				order.UpdatedAt = time.Now()
-				return nil
+			if order.ID != orderID {
+				continue
 			}
+			updated := *order
+			change(&updated)
+			// Update the timestamp to reflect the change
+			updated.UpdatedAt = time.Now()
+			stock := part.stock(order.ProductID) - countedStock(order) + countedStock(&updated)
+			if err := commit(order, &updated, stock); err != nil {
+				return err
+			}
+			*order = updated
+			return nil
 		}
 	}
-	return fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
+	return fmt.Errorf("%w: id=%d", model.ErrOrderNotFound, orderID)
 }
 
-// Get retrieves an order by its ID. Returns ErrOrderNotFound if not found.
+// Get retrieves an order by its ID. Returns model.ErrOrderNotFound if not found.
 func (repo *Order) Get(ctx context.Context, orderID model.OrderID) (*model.Order, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, orders := range repo.orders {
+	for _, orders := range part.orders {
 		for _, order := range orders {
 			if order.ID == orderID {
 				return order, nil // Order found
 			}
 		}
 	}
-	return nil, fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
+	return nil, fmt.Errorf("%w: id=%d", model.ErrOrderNotFound, orderID)
+}
+
+// stock returns the current stock of a product, counted from its completed orders.
+func (part *orderPartition) stock(productID catalogModel.ProductID) int {
+	stock := 0
+	for _, order := range part.orders[productID] {
+		stock += countedStock(order)
+	}
+	return stock
 }
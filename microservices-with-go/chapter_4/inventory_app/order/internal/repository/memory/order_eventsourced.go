@@ -0,0 +1,320 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
+	"maps"
+)
+
+// orderEventType identifies a change in the event stream of an order.
+type orderEventType string
+
+const (
+	orderCreated         orderEventType = "Created"
+	orderCompleted       orderEventType = "Completed"
+	orderCancelled       orderEventType = "Cancelled"
+	orderReopened        orderEventType = "Reopened"
+	orderMetadataChanged orderEventType = "MetadataChanged"
+)
+
+// orderEvent is a single entry of an order's event stream.
+type orderEvent struct {
+	Type       orderEventType
+	Version    int // Position in the stream, starting at 1
+	OccurredAt time.Time
+	Created    *model.Order         // Set for orderCreated
+	Metadata   *model.OrderMetadata // Set for orderMetadataChanged
+}
+
+// orderSnapshot is the folded state of an order up to and including Version.
+type orderSnapshot struct {
+	Version int
+	Order   model.Order
+}
+
+// EventSourcedOrder is an order repository that stores every order as a
+// stream of events instead of mutating it in place. The current state is
+// folded from the latest snapshot and the events after it; a snapshot is
+// taken every snapshotEvery events. Current stock per product is kept as a
+// projection updated with every appended event. Streams, projections and
+// order IDs are partitioned per tenant like those of Order.
+type EventSourcedOrder struct {
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[orderStreams]
+}
+
+// orderStreams holds the order streams of one tenant.
+type orderStreams struct {
+	streams       map[model.OrderID][]orderEvent
+	snapshots     map[model.OrderID]orderSnapshot
+	byProduct     map[catalogModel.ProductID][]model.OrderID
+	stock         map[catalogModel.ProductID]int
+	snapshotEvery int
+	seqID         int
+}
+
+// NewEventSourced returns an empty event-sourced order repository taking a
+// snapshot every snapshotEvery events.
+func NewEventSourced(snapshotEvery int) *EventSourcedOrder {
+	return &EventSourcedOrder{
+		tenants: tenant.NewPartitions(func() *orderStreams {
+			return &orderStreams{
+				streams:       make(map[model.OrderID][]orderEvent),
+				snapshots:     make(map[model.OrderID]orderSnapshot),
+				byProduct:     make(map[catalogModel.ProductID][]model.OrderID),
+				stock:         make(map[catalogModel.ProductID]int),
+				snapshotEvery: snapshotEvery,
+			}
+		}),
+	}
+}
+
+// Ping checks that the repository answers, like Order.Ping.
+func (repo *EventSourcedOrder) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
+// Create starts the event stream of a new order. Like Order.Create it assigns
+// the ID and timestamps, preserving a CreatedAt supplied by the caller, and
+// commits the change.
+func (repo *EventSourcedOrder) Create(ctx context.Context, orderRecord *model.Order, commit func(before, after *model.Order, stock int) error) (*model.Order, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	created := *orderRecord
+	created.ID = model.OrderID(part.seqID + 1)
+	if created.CreatedAt.IsZero() {
+		created.CreatedAt = now
+	}
+	created.UpdatedAt = now
+
+	if err := part.append(created.ID, orderEvent{Type: orderCreated, OccurredAt: now, Created: &created}, commit); err != nil {
+		return nil, err
+	}
+	part.seqID++
+	part.byProduct[created.ProductID] = append(part.byProduct[created.ProductID], created.ID)
+	*orderRecord = created
+	return orderRecord, nil
+}
+
+// GetAll folds every order stream. Returns model.ErrOrderNotFound if no orders exist.
+func (repo *EventSourcedOrder) GetAll(ctx context.Context) ([]*model.Order, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(part.streams) == 0 {
+		return nil, model.ErrOrderNotFound
+	}
+	ids := make([]model.OrderID, 0, len(part.streams))
+	for id := range part.streams {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return part.loadAll(ids)
+}
+
+// GetByProductID folds the streams of all orders for a product.
+// Returns model.ErrOrderNotFound if no orders exist for that product.
+func (repo *EventSourcedOrder) GetByProductID(ctx context.Context, productID catalogModel.ProductID) ([]*model.Order, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := part.byProduct[productID]
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w, productId:%d", model.ErrOrderNotFound, productID)
+	}
+	return part.loadAll(ids)
+}
+
+// UpdateStatus appends the event moving the order to the given status,
+// committing it like Order.UpdateStatus. Returns model.ErrOrderNotFound if the
+// order does not exist.
+func (repo *EventSourcedOrder) UpdateStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus, commit func(before, after *model.Order, stock int) error) error {
+	var eventType orderEventType
+	switch status {
+	case enums.OrderStatusCompleted:
+		eventType = orderCompleted
+	case enums.OrderStatusCancelled:
+		eventType = orderCancelled
+	case enums.OrderStatusPending:
+		eventType = orderReopened
+	default:
+		return fmt.Errorf("unknown order status %d", status)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
+	return part.append(orderID, orderEvent{Type: eventType, OccurredAt: time.Now()}, commit)
+}
+
+// UpdateMetadata appends an event replacing the customer and metadata of an
+// order, committing it like Order.UpdateMetadata. Returns
+// model.ErrOrderNotFound if the order does not exist.
+func (repo *EventSourcedOrder) UpdateMetadata(ctx context.Context, orderID model.OrderID, metadata *model.OrderMetadata, commit func(before, after *model.Order, stock int) error) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
+
+	copied := *metadata
+	return part.append(orderID, orderEvent{Type: orderMetadataChanged, OccurredAt: time.Now(), Metadata: &copied}, commit)
+}
+
+// Get folds the current state of an order. Returns model.ErrOrderNotFound if not found.
+func (repo *EventSourcedOrder) Get(ctx context.Context, orderID model.OrderID) (*model.Order, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return part.load(orderID)
+}
+
+// CurrentStock returns the stock of a product from the projection over all
+// order streams, without folding the orders.
+func (repo *EventSourcedOrder) CurrentStock(ctx context.Context, productID catalogModel.ProductID) (int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(part.byProduct[productID]) == 0 {
+		return 0, fmt.Errorf("%w, productId:%d", model.ErrOrderNotFound, productID)
+	}
+	return part.stock[productID], nil
+}
+
+// StockLevels returns the stock of every product with orders from the
+// projection, like CurrentStock.
+func (repo *EventSourcedOrder) StockLevels(ctx context.Context) (map[catalogModel.ProductID]int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return maps.Clone(part.stock), nil
+}
+
+// append adds an event to the stream of an order, updates the stock
+// projection and takes a snapshot when one is due, unless commit rejects the
+// change. Callers hold the write lock.
+func (part *orderStreams) append(orderID model.OrderID, e orderEvent, commit func(before, after *model.Order, stock int) error) error {
+	var before *model.Order
+	if e.Type != orderCreated {
+		var err error
+		if before, err = part.load(orderID); err != nil {
+			return err
+		}
+	}
+
+	e.Version = len(part.streams[orderID]) + 1
+	after := applyOrderEvent(before, e)
+	stock := part.stock[after.ProductID] + countedStock(after) - countedStock(before)
+	if err := commit(before, after, stock); err != nil {
+		return err
+	}
+	part.streams[orderID] = append(part.streams[orderID], e)
+
+	part.stock[after.ProductID] = stock
+	if part.snapshotEvery > 0 && e.Version%part.snapshotEvery == 0 {
+		part.snapshots[orderID] = orderSnapshot{Version: e.Version, Order: *after}
+	}
+	return nil
+}
+
+// load folds the state of an order from its latest snapshot and the events after it.
+func (part *orderStreams) load(orderID model.OrderID) (*model.Order, error) {
+	stream, ok := part.streams[orderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: id=%d", model.ErrOrderNotFound, orderID)
+	}
+
+	var state *model.Order
+	from := 0
+	if snap, ok := part.snapshots[orderID]; ok {
+		order := snap.Order
+		state, from = &order, snap.Version
+	}
+	for _, e := range stream[from:] {
+		state = applyOrderEvent(state, e)
+	}
+	return state, nil
+}
+
+func (part *orderStreams) loadAll(ids []model.OrderID) ([]*model.Order, error) {
+	orders := make([]*model.Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := part.load(id)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// applyOrderEvent returns the state after applying e to state, leaving state unchanged.
+func applyOrderEvent(state *model.Order, e orderEvent) *model.Order {
+	if e.Type == orderCreated {
+		created := *e.Created
+		return &created
+	}
+
+	next := *state
+	switch e.Type {
+	case orderCompleted:
+		next.Status = enums.OrderStatusCompleted
+	case orderCancelled:
+		next.Status = enums.OrderStatusCancelled
+	case orderReopened:
+		next.Status = enums.OrderStatusPending
+	case orderMetadataChanged:
+		next.CustomerID = e.Metadata.CustomerID
+		next.Metadata = e.Metadata.Metadata
+	}
+	next.UpdatedAt = e.OccurredAt
+	return &next
+}
+
+// countedStock returns the contribution of an order to the stock projection.
+func countedStock(order *model.Order) int {
+	if order == nil || order.Status != enums.OrderStatusCompleted {
+		return 0
+	}
+	return order.StockEffect()
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+func TestEventSourcedOrder_FoldsAcrossSnapshots(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	repo := NewEventSourced(2)
+
+	order, err := repo.Create(ctx, &model.Order{ProductID: 1, Quantity: 10, Type: enums.OrderTypeBuy}, noCommit)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.UpdateStatus(ctx, order.ID, enums.OrderStatusCompleted, noCommit))
+	assert.NoError(t, repo.UpdateMetadata(ctx, order.ID, &model.OrderMetadata{CustomerID: 7}, noCommit))
+
+	// The snapshot taken at version 2 must be combined with the metadata event after it.
+	got, err := repo.Get(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, enums.OrderStatusCompleted, got.Status)
+	assert.Equal(t, 7, got.CustomerID)
+	part, err := repo.tenants.For(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, part.streams[order.ID], 3)
+	assert.Equal(t, 2, part.snapshots[order.ID].Version)
+
+	_, err = repo.Get(ctx, 404)
+	assert.ErrorIs(t, err, model.ErrOrderNotFound)
+}
+
+func TestEventSourcedOrder_CurrentStock(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	repo := NewEventSourced(10)
+
+	buy, _ := repo.Create(ctx, &model.Order{ProductID: 1, Quantity: 10, Type: enums.OrderTypeBuy}, noCommit)
+	sale, _ := repo.Create(ctx, &model.Order{ProductID: 1, Quantity: 3, Type: enums.OrderTypeSale}, noCommit)
+	assert.NoError(t, repo.UpdateStatus(ctx, buy.ID, enums.OrderStatusCompleted, noCommit))
+	assert.NoError(t, repo.UpdateStatus(ctx, sale.ID, enums.OrderStatusCompleted, noCommit))
+
+	stock, err := repo.CurrentStock(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, stock)
+
+	// Cancelling a completed sale puts its quantity back.
+	assert.NoError(t, repo.UpdateStatus(ctx, sale.ID, enums.OrderStatusCancelled, noCommit))
+	stock, err = repo.CurrentStock(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, stock)
+
+	_, err = repo.CurrentStock(ctx, 2)
+	assert.ErrorIs(t, err, model.ErrOrderNotFound)
+}
+
+func TestEventSourcedOrder_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	repo := NewEventSourced(10)
+
+	first, _ := repo.Create(acme, &model.Order{ProductID: 1, Quantity: 10, Type: enums.OrderTypeBuy}, noCommit)
+	other, _ := repo.Create(globex, &model.Order{ProductID: 1, Quantity: 4, Type: enums.OrderTypeBuy}, noCommit)
+	assert.Equal(t, model.OrderID(1), first.ID)
+	assert.Equal(t, model.OrderID(1), other.ID, "every tenant has its own ID sequence")
+	assert.NoError(t, repo.UpdateStatus(acme, first.ID, enums.OrderStatusCompleted, noCommit))
+
+	got, err := repo.Get(globex, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, got.Quantity)
+	assert.Equal(t, enums.OrderStatusPending, got.Status)
+	_, err = repo.CurrentStock(globex, 1)
+	assert.NoError(t, err)
+	stock, _ := repo.CurrentStock(acme, 1)
+	assert.Equal(t, 10, stock)
+
+	_, err = repo.GetAll(tenant.WithID(context.Background(), tenant.Default))
+	assert.ErrorIs(t, err, model.ErrOrderNotFound, "the default tenant sees neither")
+	_, err = repo.GetAll(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+}
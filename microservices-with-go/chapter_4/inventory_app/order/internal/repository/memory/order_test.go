@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/order/pkg/enums"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+// noCommit is a commit hook accepting every change.
+func noCommit(before, after *model.Order, stock int) error { return nil }
+
+func TestOrder_CommitReportsStock(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	failure := errors.New("outbox unavailable")
+	fail := func(before, after *model.Order, stock int) error { return failure }
+
+	for name, repo := range map[string]interface {
+		Create(ctx context.Context, orderRecord *model.Order, commit func(before, after *model.Order, stock int) error) (*model.Order, error)
+		UpdateStatus(ctx context.Context, orderID model.OrderID, status enums.OrderStatus, commit func(before, after *model.Order, stock int) error) error
+		Get(ctx context.Context, orderID model.OrderID) (*model.Order, error)
+	}{"memory": New(), "event sourced": NewEventSourced(2)} {
+		t.Run(name, func(t *testing.T) {
+			_, err := repo.Create(ctx, &model.Order{ProductID: 1, Quantity: 10, Type: enums.OrderTypeBuy}, fail)
+			assert.ErrorIs(t, err, failure)
+
+			buy, err := repo.Create(ctx, &model.Order{ProductID: 1, Quantity: 10, Type: enums.OrderTypeBuy}, noCommit)
+			require.NoError(t, err)
+			assert.Equal(t, model.OrderID(1), buy.ID, "the sequence is not advanced by a failed commit")
+			sale, err := repo.Create(ctx, &model.Order{ProductID: 1, Quantity: 3, Type: enums.OrderTypeSale}, noCommit)
+			require.NoError(t, err)
+
+			require.NoError(t, repo.UpdateStatus(ctx, buy.ID, enums.OrderStatusCompleted, func(before, after *model.Order, stock int) error {
+				assert.Equal(t, enums.OrderStatusPending, before.Status)
+				assert.Equal(t, enums.OrderStatusCompleted, after.Status)
+				assert.Equal(t, 10, stock)
+				return nil
+			}))
+			assert.ErrorIs(t, repo.UpdateStatus(ctx, sale.ID, enums.OrderStatusCompleted, fail), failure)
+			got, err := repo.Get(ctx, sale.ID)
+			require.NoError(t, err)
+			assert.Equal(t, enums.OrderStatusPending, got.Status, "a failed commit keeps the order")
+
+			require.NoError(t, repo.UpdateStatus(ctx, sale.ID, enums.OrderStatusCompleted, func(before, after *model.Order, stock int) error {
+				assert.Equal(t, 7, stock, "the failed commit did not count the sale")
+				return nil
+			}))
+		})
+	}
+}
+
+func TestOrder_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	repo := New()
+
+	first, err := repo.Create(acme, &model.Order{ProductID: 1, Quantity: 10, Type: enums.OrderTypeBuy}, noCommit)
+	require.NoError(t, err)
+	second, err := repo.Create(acme, &model.Order{ProductID: 1, Quantity: 2, Type: enums.OrderTypeSale}, noCommit)
+	require.NoError(t, err)
+	other, err := repo.Create(globex, &model.Order{ProductID: 1, Quantity: 4, Type: enums.OrderTypeBuy}, noCommit)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderID(1), first.ID)
+	assert.Equal(t, model.OrderID(2), second.ID)
+	assert.Equal(t, model.OrderID(1), other.ID, "every tenant has its own ID sequence")
+
+	require.NoError(t, repo.UpdateStatus(acme, 1, enums.OrderStatusCompleted, noCommit))
+	got, err := repo.Get(globex, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, got.Quantity)
+	assert.Equal(t, enums.OrderStatusPending, got.Status, "the same ID of another tenant is not touched")
+	_, err = repo.Get(globex, 2)
+	assert.ErrorIs(t, err, model.ErrOrderNotFound)
+
+	orders, err := repo.GetByProductID(globex, 1)
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	_, err = repo.GetAll(context.Background())
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+}
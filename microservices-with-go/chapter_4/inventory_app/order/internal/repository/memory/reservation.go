@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+// Reservation represents an in-memory repository for stock reservations,
+// partitioned per tenant like Order.
+type Reservation struct {
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[reservationPartition]
+}
+
+// reservationPartition holds the reservations and ID sequence of one tenant.
+type reservationPartition struct {
+	data  []*model.Reservation
+	seqID int
+}
+
+// NewReservation returns a new in-memory Reservation repository.
+func NewReservation() *Reservation {
+	return &Reservation{
+		tenants: tenant.NewPartitions(func() *reservationPartition {
+			return &reservationPartition{data: make([]*model.Reservation, 0)}
+		}),
+	}
+}
+
+// Ping checks that the repository answers, like Order.Ping.
+func (repo *Reservation) Ping(ctx context.Context) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return nil
+}
+
+// Create stores a reservation. If one with the same reference exists it is
+// returned instead, so a retried saga step does not hold stock twice.
+func (repo *Reservation) Create(ctx context.Context, data *model.Reservation) (*model.Reservation, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range part.data {
+		if r.Reference == data.Reference {
+			return r, nil
+		}
+	}
+	part.seqID++
+	data.ID = model.ReservationID(part.seqID)
+	data.CreatedAt = time.Now()
+	part.data = append(part.data, data)
+	return data, nil
+}
+
+// Release removes the reservation with the given reference. Reservation IDs
+// restart with the process, so callers keep the reference instead. Returns
+// model.ErrReservationNotFound if not found.
+func (repo *Reservation) Release(ctx context.Context, reference string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(part.data, func(r *model.Reservation) bool { return r.Reference == reference })
+	if i < 0 {
+		return fmt.Errorf("%w: reference=%s", model.ErrReservationNotFound, reference)
+	}
+	part.data = slices.Delete(part.data, i, i+1)
+	return nil
+}
+
+// Held returns the quantity of a product held by reservations.
+func (repo *Reservation) Held(ctx context.Context, productID catalogModel.ProductID) (int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	part, err := repo.tenants.For(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	held := 0
+	for _, r := range part.data {
+		if r.ProductID == productID {
+			held += r.Quantity
+		}
+	}
+	return held, nil
+}
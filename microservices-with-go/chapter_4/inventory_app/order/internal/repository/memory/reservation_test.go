@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/order/pkg/model"
+	"inventory.com/pkg/tenant"
+)
+
+func TestReservation_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	repo := NewReservation()
+
+	first, err := repo.Create(acme, &model.Reservation{ProductID: 1, Quantity: 3, Reference: "saga-1"})
+	require.NoError(t, err)
+	second, err := repo.Create(acme, &model.Reservation{ProductID: 1, Quantity: 2, Reference: "saga-2"})
+	require.NoError(t, err)
+	other, err := repo.Create(globex, &model.Reservation{ProductID: 1, Quantity: 5, Reference: "saga-1"})
+	require.NoError(t, err)
+	assert.Equal(t, model.ReservationID(1), first.ID)
+	assert.Equal(t, model.ReservationID(2), second.ID)
+	assert.Equal(t, model.ReservationID(1), other.ID, "every tenant has its own ID sequence")
+	assert.Equal(t, 5, other.Quantity, "the same reference of another tenant is a different reservation")
+
+	require.NoError(t, repo.Release(acme, "saga-1"))
+	held, err := repo.Held(globex, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, held, "releasing the same reference of another tenant leaves this one")
+	held, err = repo.Held(acme, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, held)
+	assert.ErrorIs(t, repo.Release(globex, "saga-2"), model.ErrReservationNotFound)
+
+	_, err = repo.Held(context.Background(), 1)
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+}
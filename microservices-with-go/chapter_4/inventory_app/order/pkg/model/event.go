@@ -0,0 +1,21 @@
+package model
+
+import catalogModel "inventory.com/catalog/pkg/model"
+
+// Domain event types published by the order service. Order events carry the
+// order after the change, StockChanged carries a StockChange.
+const (
+	EventOrderCreated   = "OrderCreated"
+	EventOrderUpdated   = "OrderUpdated"
+	EventOrderCompleted = "OrderCompleted"
+	EventOrderCancelled = "OrderCancelled"
+	EventStockChanged   = "StockChanged"
+)
+
+// StockChange is the payload of a StockChanged event.
+type StockChange struct {
+	ProductID    catalogModel.ProductID `json:"productID"`
+	OrderID      OrderID                `json:"orderID"`
+	Delta        int                    `json:"delta"`
+	CurrentStock int                    `json:"currentStock"`
+}
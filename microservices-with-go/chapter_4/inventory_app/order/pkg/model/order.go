@@ -2,10 +2,22 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	catalogModel "inventory.com/catalog/pkg/model"
 	"inventory.com/order/pkg/enums"
+	"inventory.com/pkg/money"
+)
+
+var (
+	// ErrPriceMismatch is returned when a historical sale order does not match
+	// the catalog list price that was in effect when the order was placed.
+	ErrPriceMismatch = errors.New("order price does not match catalog price")
+	// ErrInvalidOrder is returned when an order is missing a quantity or price.
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrOrderNotFound is returned when an order does not exist.
+	ErrOrderNotFound = errors.New("order not found")
 )
 
 // OrderID represents the unique identifier for an Order.
@@ -18,20 +30,46 @@ type OrderID int
 //   - ID: Unique identifier for the order.
 //   - ProductID: Unique identifier of the product from the Catalog service.
 //   - Quantity: Number of units being ordered.
-//   - Price: Price per unit (or total, depending on how billing is handled).
+//   - Price: Price per unit, in minor units of an ISO 4217 currency.
 //   - Type: Specifies the nature of the order (e.g., PURCHASE, SALE).
 //   - CustomerID: Identifier of the customer placing the order (optional).
+//   - Metadata: Free-form key/value annotations, e.g. a purchase order reference.
 //   - CreatedAt: Timestamp of when the order was created.
 //   - UpdatedAt: Timestamp of the last update made to the order.
 //   - Status: Current status of the order (e.g., PENDING, COMPLETED, CANCELLED).
 type Order struct {
 	ID         OrderID                `json:"id"`
 	ProductID  catalogModel.ProductID `json:"productID"`
-	Quantity   int                    `json:"quantity"`   // Number of items
-	Price      float64                `json:"price"`      // Price per unit or total, depending on use case
-	Type       enums.OrderType        `json:"type"`       // e.g. PURCHASE, SALE or RETURN
-	CustomerID int                    `json:"customerID"` // Optional: if you're supporting customer data
-	CreatedAt  time.Time              `json:"createdAt"`  // Timestamp for auditing
-	UpdatedAt  time.Time              `json:"updatedAt"`  // Useful for updates or tracking
-	Status     enums.OrderStatus      `json:"status"`     // e.g. PENDING, COMPLETED, CANCELLED
+	Quantity   int                    `json:"quantity"`           // Number of items
+	Price      money.Money            `json:"price"`              // Price per unit
+	Type       enums.OrderType        `json:"type"`               // e.g. PURCHASE, SALE or RETURN
+	CustomerID int                    `json:"customerID"`         // Optional: if you're supporting customer data
+	Metadata   map[string]string      `json:"metadata,omitempty"` // Free-form annotations, e.g. a purchase order reference
+	CreatedAt  time.Time              `json:"createdAt"`          // Timestamp for auditing
+	UpdatedAt  time.Time              `json:"updatedAt"`          // Useful for updates or tracking
+	Status     enums.OrderStatus      `json:"status"`             // e.g. PENDING, COMPLETED, CANCELLED
+}
+
+// OrderMetadata holds the fields of an order that may be changed after it was
+// placed. It is the document targeted by PATCH requests.
+type OrderMetadata struct {
+	CustomerID int               `json:"customerID"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// StockEffect returns how the order changes the stock of its product once it
+// is completed: purchases and returns add to it, sales take from it.
+func (o *Order) StockEffect() int {
+	switch o.Type {
+	case enums.OrderTypeBuy, enums.OrderTypeReturn:
+		return o.Quantity
+	case enums.OrderTypeSale:
+		return -o.Quantity
+	}
+	return 0
+}
+
+// Total returns the price of all units in the order.
+func (o *Order) Total() (money.Money, error) {
+	return o.Price.Mul(int64(o.Quantity))
 }
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	catalogModel "inventory.com/catalog/pkg/model"
+	"inventory.com/pkg/money"
+)
+
+var (
+	// ErrSagaNotFound is returned when no saga exists for the given ID or order.
+	ErrSagaNotFound = errors.New("saga not found")
+	// ErrInvalidSale is returned when a sale request is incomplete.
+	ErrInvalidSale = errors.New("invalid sale")
+	// ErrInsufficientStock is returned when a sale cannot reserve the requested quantity.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrReservationNotFound is returned when a stock reservation does not exist.
+	ErrReservationNotFound = errors.New("reservation not found")
+)
+
+// SagaID defines the unique identifier for a saga.
+type SagaID string
+
+// SagaStatus is the state of a saga.
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "running"      // Steps are being executed
+	SagaCompleted    SagaStatus = "completed"    // All steps succeeded
+	SagaCompensating SagaStatus = "compensating" // A step failed and completed steps are being undone
+	SagaCompensated  SagaStatus = "compensated"  // A step failed and all completed steps were undone
+)
+
+// SagaStepStatus is the state of an executed saga step.
+type SagaStepStatus string
+
+const (
+	SagaStepDone        SagaStepStatus = "done"
+	SagaStepCompensated SagaStepStatus = "compensated"
+)
+
+// SagaStep records the outcome of one saga step.
+type SagaStep struct {
+	Name   string         `json:"name"`
+	Status SagaStepStatus `json:"status"`
+	At     time.Time      `json:"at"`
+}
+
+// SaleRequest describes a sale placed through the sale saga.
+type SaleRequest struct {
+	ProductID    catalogModel.ProductID `json:"productID"`
+	Quantity     int                    `json:"quantity"`
+	CustomerID   int                    `json:"customerID"`
+	DiscountCode string                 `json:"discountCode,omitempty"`
+}
+
+// Saga is the persisted state of a sale saga: which steps ran and the
+// results later steps and compensations depend on.
+type Saga struct {
+	ID              SagaID        `json:"id"`
+	Status          SagaStatus    `json:"status"`
+	Request         SaleRequest   `json:"request"`
+	UnitPrice       *money.Money  `json:"unitPrice,omitempty"`       // Catalog list price per unit
+	ReservationID   ReservationID `json:"reservationID,omitempty"`   // Stock held until the order is created, released by the saga ID
+	RedemptionID    int           `json:"redemptionID,omitempty"`    // Discount use, voided by the saga ID on failure
+	DiscountedPrice *money.Money  `json:"discountedPrice,omitempty"` // Price per unit after the discount
+	OrderID         OrderID       `json:"orderID,omitempty"`
+	Steps           []SagaStep    `json:"steps"`
+	Error           string        `json:"error,omitempty"` // Why the saga is compensating
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
+}
+
+// Finished reports whether the saga reached a final state.
+func (s *Saga) Finished() bool {
+	return s.Status == SagaCompleted || s.Status == SagaCompensated
+}
+
+// ReservationID defines the unique identifier for a stock reservation.
+type ReservationID int
+
+// Reservation holds stock for a sale that is not an order yet.
+type Reservation struct {
+	ID        ReservationID          `json:"id"`
+	ProductID catalogModel.ProductID `json:"productID"`
+	Quantity  int                    `json:"quantity"`
+	Reference string                 `json:"reference"` // The saga holding the stock
+	CreatedAt time.Time              `json:"createdAt"`
+}
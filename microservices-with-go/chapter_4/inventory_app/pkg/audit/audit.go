@@ -0,0 +1,175 @@
+// Package audit records who changed which entity, when and how. Entries are
+// written to a pluggable Store and can be queried per entity.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"inventory.com/pkg/identity"
+	"inventory.com/pkg/requestid"
+)
+
+// Operation is the kind of mutation an entry records.
+type Operation string
+
+const (
+	OperationCreate  Operation = "create"
+	OperationUpdate  Operation = "update"
+	OperationDelete  Operation = "delete"
+	OperationRestore Operation = "restore"
+	// OperationPurge permanently removes a soft deleted entity.
+	OperationPurge Operation = "purge"
+)
+
+// Change is a single difference between the before and after state of an
+// entity, addressed by a JSON pointer. Before or After is omitted when the
+// field was added or removed.
+type Change struct {
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// Entry is a single audited mutation.
+type Entry struct {
+	ID         int             `json:"id"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"requestID,omitempty"`
+	EntityType string          `json:"entity"`
+	EntityID   string          `json:"entityID"`
+	Operation  Operation       `json:"operation"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Changes    []Change        `json:"changes"`
+}
+
+// Query selects audit entries. Empty fields match every entry.
+type Query struct {
+	EntityType string
+	EntityID   string
+}
+
+// Matches reports whether the entry is selected by the query.
+func (q Query) Matches(e *Entry) bool {
+	return (q.EntityType == "" || q.EntityType == e.EntityType) &&
+		(q.EntityID == "" || q.EntityID == e.EntityID)
+}
+
+// Store persists audit entries.
+type Store interface {
+	// Append stores the entry, assigning its ID.
+	Append(ctx context.Context, entry *Entry) error
+	// Find returns the entries matching the query, oldest first.
+	Find(ctx context.Context, query Query) ([]*Entry, error)
+}
+
+// Recorder builds audit entries from the request context and entity states.
+type Recorder struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewRecorder returns a Recorder writing to the given store.
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store, now: time.Now}
+}
+
+// Record stores an entry for a mutation of the entity. before is nil for
+// creations and after is nil for hard deletions. The actor and request ID
+// are taken from ctx.
+func (r *Recorder) Record(ctx context.Context, entityType string, entityID any, op Operation, before, after any) error {
+	entry := &Entry{
+		Timestamp:  r.now(),
+		Actor:      identity.Actor(ctx),
+		RequestID:  requestid.FromContext(ctx),
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		Operation:  op,
+	}
+
+	var err error
+	if entry.Before, err = marshalState(before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalState(after); err != nil {
+		return err
+	}
+	if entry.Changes, err = Diff(entry.Before, entry.After); err != nil {
+		return err
+	}
+	return r.store.Append(ctx, entry)
+}
+
+func marshalState(state any) (json.RawMessage, error) {
+	if state == nil || reflect.ValueOf(state).Kind() == reflect.Pointer && reflect.ValueOf(state).IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	return data, nil
+}
+
+// Diff compares two JSON documents and returns the changed fields. Objects
+// are compared field by field, any other value including arrays as a whole.
+// An empty document is treated as an empty object.
+func Diff(before, after json.RawMessage) ([]Change, error) {
+	var b, a any
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, err
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, err
+		}
+	}
+	// Creations and deletions list every field rather than one change of the whole document.
+	if _, ok := a.(map[string]any); ok && b == nil {
+		b = map[string]any{}
+	}
+	if _, ok := b.(map[string]any); ok && a == nil {
+		a = map[string]any{}
+	}
+	changes := []Change{}
+	diffValue("", b, a, &changes)
+	return changes, nil
+}
+
+func diffValue(path string, before, after any, changes *[]Change) {
+	beforeObj, beforeIsObj := before.(map[string]any)
+	afterObj, afterIsObj := after.(map[string]any)
+	if !beforeIsObj || !afterIsObj {
+		if !reflect.DeepEqual(before, after) {
+			*changes = append(*changes, Change{Path: path, Before: before, After: after})
+		}
+		return
+	}
+
+	keys := make(map[string]struct{}, len(beforeObj)+len(afterObj))
+	for k := range beforeObj {
+		keys[k] = struct{}{}
+	}
+	for k := range afterObj {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		escaped := strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+		diffValue(path+"/"+escaped, beforeObj[k], afterObj[k], changes)
+	}
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"inventory.com/pkg/identity"
+	"inventory.com/pkg/requestid"
+	"inventory.com/pkg/tenant"
+)
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff(
+		json.RawMessage(`{"name":"TV","tags":["a"],"meta":{"a/b":1,"keep":true},"gone":1}`),
+		json.RawMessage(`{"name":"Television","tags":["a","b"],"meta":{"a/b":2,"keep":true},"new":"x"}`),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "/gone", Before: 1.0},
+		{Path: "/meta/a~1b", Before: 1.0, After: 2.0},
+		{Path: "/name", Before: "TV", After: "Television"},
+		{Path: "/new", After: "x"},
+		{Path: "/tags", Before: []any{"a"}, After: []any{"a", "b"}},
+	}, changes)
+}
+
+func TestRecorder(t *testing.T) {
+	type product struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	store := NewMemoryStore()
+	recorder := NewRecorder(store)
+	ctx := requestid.With(identity.WithActor(tenant.WithID(context.Background(), tenant.Default), "alice"), "req-1")
+
+	assert.NoError(t, recorder.Record(ctx, "product", 5, OperationCreate, nil, &product{ID: 5, Name: "TV"}))
+	assert.NoError(t, recorder.Record(ctx, "product", 5, OperationUpdate, &product{ID: 5, Name: "TV"}, &product{ID: 5, Name: "OLED"}))
+	assert.NoError(t, recorder.Record(ctx, "product", 6, OperationCreate, (*product)(nil), &product{ID: 6}))
+
+	entries, err := store.Find(ctx, Query{EntityType: "product", EntityID: "5"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Nil(t, entries[0].Before)
+	assert.Equal(t, []Change{{Path: "/id", After: 5.0}, {Path: "/name", After: "TV"}}, entries[0].Changes)
+	assert.Equal(t, "alice", entries[1].Actor)
+	assert.Equal(t, "req-1", entries[1].RequestID)
+	assert.Equal(t, OperationUpdate, entries[1].Operation)
+	assert.Equal(t, []Change{{Path: "/name", Before: "TV", After: "OLED"}}, entries[1].Changes)
+}
+
+func TestMemoryStore_PartitionsTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	store := NewMemoryStore()
+
+	assert.NoError(t, store.Append(acme, &Entry{EntityType: "product", EntityID: "1", Operation: OperationCreate}))
+	assert.NoError(t, store.Append(acme, &Entry{EntityType: "product", EntityID: "1", Operation: OperationUpdate}))
+	other := &Entry{EntityType: "product", EntityID: "1", Operation: OperationDelete}
+	assert.NoError(t, store.Append(globex, other))
+	assert.Equal(t, 1, other.ID, "every tenant has its own ID sequence")
+
+	entries, err := store.Find(acme, Query{EntityType: "product", EntityID: "1"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	entries, err = store.Find(globex, Query{EntityType: "product", EntityID: "1"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "the trail of the same entity of another tenant is not included")
+	assert.Equal(t, OperationDelete, entries[0].Operation)
+
+	_, err = store.Find(context.Background(), Query{})
+	assert.ErrorIs(t, err, tenant.ErrNoTenant)
+	assert.ErrorIs(t, store.Append(context.Background(), &Entry{}), tenant.ErrNoTenant)
+}
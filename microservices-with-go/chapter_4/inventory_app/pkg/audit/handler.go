@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitHandler registers GET /audit?entity=product&id=5, which lists the audit
+// entries of the selected entities oldest first.
+func InitHandler(engine gin.IRouter, store Store) {
+	engine.GET("/audit", func(ctx *gin.Context) {
+		query := Query{EntityType: ctx.Query("entity"), EntityID: ctx.Query("id")}
+		if query.EntityID != "" && query.EntityType == "" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id requires entity"})
+			return
+		}
+		entries, err := store.Find(ctx.Request.Context(), query)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve audit entries"})
+			return
+		}
+		ctx.JSON(http.StatusOK, entries)
+	})
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"inventory.com/pkg/tenant"
+)
+
+// MemoryStore keeps audit entries in memory, separately for every tenant.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	tenants *tenant.Partitions[memoryLog]
+}
+
+// memoryLog holds the entries and ID sequence of one tenant.
+type memoryLog struct {
+	entries []*Entry
+	seqID   int
+}
+
+// NewMemoryStore returns an empty in-memory audit store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tenants: tenant.NewPartitions(func() *memoryLog { return &memoryLog{} })}
+}
+
+// Append stores the entry in the log of the tenant of ctx, assigning the next
+// sequential ID.
+func (s *MemoryStore) Append(ctx context.Context, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log, err := s.tenants.For(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.seqID++
+	entry.ID = log.seqID
+	log.entries = append(log.entries, entry)
+	return nil
+}
+
+// Find returns the entries of the tenant of ctx matching the query in the
+// order they were appended.
+func (s *MemoryStore) Find(ctx context.Context, query Query) ([]*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	log, err := s.tenants.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*Entry{}
+	for _, e := range log.entries {
+		if query.Matches(e) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/pkg/identity"
+	"inventory.com/pkg/tenant"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type testKeys struct {
+	cfg        Config
+	privateKey *rsa.PrivateKey
+	apiKey     string
+}
+
+func writeKeys(t *testing.T) testKeys {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	apiKey := "reporting-key"
+	apiKeys, err := json.Marshal([]APIKey{{SHA256: HashAPIKey(apiKey), Subject: "reporting", Roles: []string{RoleViewer}}})
+	require.NoError(t, err)
+
+	return testKeys{
+		cfg: Config{
+			HS256SecretFile:    write("secret", []byte(testSecret+"\n")),
+			RS256PublicKeyFile: write("public.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+			APIKeysFile:        write("api-keys.json", apiKeys),
+			Issuer:             "inventory",
+		},
+		privateKey: privateKey,
+		apiKey:     apiKey,
+	}
+}
+
+func claims(sub string, roles ...string) Claims {
+	return Claims{Subject: sub, Roles: roles, Issuer: "inventory", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+}
+
+func authenticate(t *testing.T, a *Authenticator, header, value string) (*Principal, error) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	return a.Authenticate(req)
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := writeKeys(t)
+	a, err := Load(keys.cfg)
+	require.NoError(t, err)
+
+	hs, err := SignHS256(claims("alice", RoleCatalogEditor), []byte(testSecret))
+	require.NoError(t, err)
+	p, err := authenticate(t, a, HeaderAuthorization, "Bearer "+hs)
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Subject: "alice", Roles: []string{RoleCatalogEditor}, Method: "jwt"}, p)
+
+	rs, err := SignRS256(claims("bob", RoleAdmin), keys.privateKey)
+	require.NoError(t, err)
+	p, err = authenticate(t, a, HeaderAuthorization, "Bearer "+rs)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", p.Subject)
+
+	p, err = authenticate(t, a, HeaderAPIKey, keys.apiKey)
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Subject: "reporting", Roles: []string{RoleViewer}, Method: "api-key"}, p)
+
+	p, err = authenticate(t, a, "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestAuthenticate_Rejects(t *testing.T) {
+	keys := writeKeys(t)
+	a, err := Load(keys.cfg)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	expired := claims("alice")
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	otherIssuer := claims("alice")
+	otherIssuer.Issuer = "elsewhere"
+
+	tokens := map[string]string{}
+	tokens["wrong secret"], _ = SignHS256(claims("alice"), []byte("another secret of at least 32 bytes"))
+	tokens["wrong RSA key"], _ = SignRS256(claims("alice"), otherKey)
+	tokens["expired"], _ = SignHS256(expired, []byte(testSecret))
+	tokens["other issuer"], _ = SignHS256(otherIssuer, []byte(testSecret))
+	tokens["no subject"], _ = SignHS256(claims(""), []byte(testSecret))
+	badTenant := claims("alice")
+	badTenant.Tenant = "../acme"
+	tokens["invalid tenant"], _ = SignHS256(badTenant, []byte(testSecret))
+	valid, _ := SignHS256(claims("alice", RoleAdmin), []byte(testSecret))
+	parts := strings.Split(valid, ".")
+	tokens["alg none"] = encode([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+	tokens["tampered claims"] = parts[0] + "." + encode([]byte(`{"sub":"mallory","roles":["admin"]}`)) + "." + parts[2]
+	tokens["malformed"] = "not-a-token"
+
+	for name, token := range tokens {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticate(t, a, HeaderAuthorization, "Bearer "+token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	_, err = authenticate(t, a, HeaderAuthorization, "Basic YWxpY2U6c2VjcmV0")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = authenticate(t, a, HeaderAPIKey, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestLoad_RejectsShortSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("short"), 0o600))
+
+	_, err := Load(Config{HS256SecretFile: path})
+	assert.Error(t, err)
+}
+
+func TestPrincipal_Can(t *testing.T) {
+	viewer := &Principal{Roles: []string{RoleViewer}}
+	editor := &Principal{Roles: []string{RoleCatalogEditor}}
+	clerk := &Principal{Roles: []string{RoleOrderClerk}}
+	admin := &Principal{Roles: []string{RoleAdmin}}
+	unknown := &Principal{Roles: []string{"superuser"}}
+
+	assert.True(t, viewer.Can(PermCatalogRead))
+	assert.False(t, viewer.Can(PermCatalogWrite))
+	assert.True(t, editor.Can(PermCatalogWrite))
+	assert.False(t, editor.Can(PermOrdersWrite))
+	assert.True(t, clerk.Can(PermOrdersWrite))
+	assert.False(t, clerk.Can(PermCatalogWrite))
+	assert.True(t, admin.Can(PermAdmin))
+	assert.False(t, unknown.Can(PermCatalogRead))
+}
+
+func TestMiddleware(t *testing.T) {
+	keys := writeKeys(t)
+	a, err := Load(keys.cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(a))
+	engine.GET("/categories", Require(PermCatalogRead), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, identity.Actor(ctx.Request.Context()))
+	})
+	engine.DELETE("/categories/1", Require(PermCatalogWrite), func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	do := func(method, path, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/categories", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+
+	w = do(http.MethodGet, "/categories", HeaderAPIKey, keys.apiKey)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "reporting", w.Body.String(), "the caller becomes the actor recorded by audits")
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/categories/1", HeaderAPIKey, keys.apiKey).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/categories", HeaderAPIKey, "unknown").Code)
+
+	token, err := SignHS256(claims("alice", RoleCatalogEditor), []byte(testSecret))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/categories/1", HeaderAuthorization, "Bearer "+token).Code)
+}
+
+func TestMiddleware_BindsTenant(t *testing.T) {
+	keys := writeKeys(t)
+	a, err := Load(keys.cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(a))
+	engine.GET("/", func(ctx *gin.Context) {
+		id, _ := tenant.Lookup(ctx.Request.Context())
+		ctx.String(http.StatusOK, string(id))
+	})
+
+	restricted := claims("alice", RoleViewer)
+	restricted.Tenant = "acme"
+	for _, tc := range []struct {
+		claims Claims
+		want   string
+	}{{restricted, "acme"}, {claims("bob", RoleViewer), ""}} {
+		token, err := SignHS256(tc.claims, []byte(testSecret))
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAuthorization, "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, tc.want, w.Body.String())
+	}
+}
+
+func TestMayChooseTenant(t *testing.T) {
+	keys := writeKeys(t)
+	a, err := Load(keys.cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(a), tenant.Middleware([]tenant.ID{"acme", "globex"}, MayChooseTenant))
+	engine.GET("/", Require(PermCatalogRead), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, string(tenant.FromContext(ctx.Request.Context())))
+	})
+
+	restricted := claims("alice", RoleViewer)
+	restricted.Tenant = "acme"
+	for _, tc := range []struct {
+		name   string
+		claims Claims
+		header string
+		status int
+	}{
+		{"claim", restricted, "", http.StatusOK},
+		{"no claim", claims("bob", RoleViewer), "globex", http.StatusForbidden},
+		{"admin without claim", claims("carol", RoleAdmin), "globex", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := SignHS256(tc.claims, []byte(testSecret))
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(HeaderAuthorization, "Bearer "+token)
+			if tc.header != "" {
+				req.Header.Set(tenant.HeaderTenantID, tc.header)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+
+	// Anonymous callers pass the tenant check and are turned away by Require.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(tenant.HeaderTenantID, "acme")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestForwardingClient(t *testing.T) {
+	keys := writeKeys(t)
+	a, err := Load(keys.cfg)
+	require.NoError(t, err)
+
+	var got []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(HeaderAuthorization)+"|"+r.Header.Get(HeaderAPIKey))
+	}))
+	defer upstream.Close()
+	client := NewForwardingClient(http.DefaultClient, "service-key")
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(a))
+	engine.GET("/", func(ctx *gin.Context) {
+		req, _ := http.NewRequestWithContext(ctx.Request.Context(), http.MethodGet, upstream.URL, nil)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})
+
+	token, _ := SignHS256(claims("alice", RoleViewer), []byte(testSecret))
+	for _, header := range [][2]string{{HeaderAuthorization, "Bearer " + token}, {HeaderAPIKey, keys.apiKey}} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header[0], header[1])
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	req, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []string{"Bearer " + token + "|", "|" + keys.apiKey, "|service-key"}, got)
+}
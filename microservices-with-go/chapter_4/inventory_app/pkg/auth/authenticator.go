@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"inventory.com/pkg/tenant"
+)
+
+// Request headers carrying credentials.
+const (
+	HeaderAuthorization = "Authorization"
+	HeaderAPIKey        = "X-API-Key"
+)
+
+// ErrInvalidAPIKey is returned for an API key that is not in the API keys file.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// Config names the local key files. Every field is optional; a service
+// without any key accepts no credentials.
+type Config struct {
+	HS256SecretFile    string // Shared secret of HS256 tokens
+	RS256PublicKeyFile string // PEM public key (PKIX or PKCS #1) of RS256 tokens
+	APIKeysFile        string // JSON list of APIKey entries
+	Issuer             string // Required "iss" claim, when set
+	Audience           string // Required "aud" claim, when set
+}
+
+// ConfigFromEnv reads the configuration shared by every service from
+// AUTH_HS256_SECRET_FILE, AUTH_RS256_PUBLIC_KEY_FILE, AUTH_API_KEYS_FILE,
+// AUTH_ISSUER and AUTH_AUDIENCE.
+func ConfigFromEnv() Config {
+	return Config{
+		HS256SecretFile:    os.Getenv("AUTH_HS256_SECRET_FILE"),
+		RS256PublicKeyFile: os.Getenv("AUTH_RS256_PUBLIC_KEY_FILE"),
+		APIKeysFile:        os.Getenv("AUTH_API_KEYS_FILE"),
+		Issuer:             os.Getenv("AUTH_ISSUER"),
+		Audience:           os.Getenv("AUTH_AUDIENCE"),
+	}
+}
+
+// APIKey is an entry of the API keys file. Only the SHA-256 of a key is
+// stored; see HashAPIKey.
+type APIKey struct {
+	SHA256  string   `json:"sha256"`
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+	Tenant  string   `json:"tenant,omitempty"`
+}
+
+// HashAPIKey returns the hex SHA-256 of key as stored in the API keys file.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator turns the credentials of a request into a Principal.
+type Authenticator struct {
+	verifier verifier
+	apiKeys  []APIKey
+}
+
+// Load reads the key files named by cfg.
+func Load(cfg Config) (*Authenticator, error) {
+	a := &Authenticator{verifier: verifier{issuer: cfg.Issuer, audience: cfg.Audience, now: time.Now}}
+	if cfg.HS256SecretFile != "" {
+		secret, err := os.ReadFile(cfg.HS256SecretFile)
+		if err != nil {
+			return nil, err
+		}
+		a.verifier.secret = []byte(strings.TrimSpace(string(secret)))
+		if len(a.verifier.secret) < 32 {
+			return nil, fmt.Errorf("HS256 secret in %s is shorter than 32 bytes", cfg.HS256SecretFile)
+		}
+	}
+	if cfg.RS256PublicKeyFile != "" {
+		key, err := readPublicKey(cfg.RS256PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		a.verifier.publicKey = key
+	}
+	if cfg.APIKeysFile != "" {
+		data, err := os.ReadFile(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &a.apiKeys); err != nil {
+			return nil, fmt.Errorf("invalid API keys file %s: %w", cfg.APIKeysFile, err)
+		}
+		for _, k := range a.apiKeys {
+			if err := checkTenant(k.Tenant); err != nil {
+				return nil, fmt.Errorf("invalid API keys file %s: %w", cfg.APIKeysFile, err)
+			}
+		}
+	}
+	return a, nil
+}
+
+// Configured reports whether any credential can be accepted.
+func (a *Authenticator) Configured() bool {
+	return a.verifier.secret != nil || a.verifier.publicKey != nil || len(a.apiKeys) > 0
+}
+
+// Authenticate returns the caller of r, nil when r carries no credentials, or
+// an error when its credentials are invalid.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if authz := r.Header.Get(HeaderAuthorization); authz != "" {
+		token, ok := strings.CutPrefix(authz, "Bearer ")
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported authorization scheme", ErrInvalidToken)
+		}
+		claims, err := a.verifier.verify(strings.TrimSpace(token))
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTenant(claims.Tenant); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+		}
+		return &Principal{Subject: claims.Subject, Roles: claims.Roles, Method: "jwt", Tenant: claims.Tenant}, nil
+	}
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		hash := HashAPIKey(key)
+		for _, k := range a.apiKeys {
+			if subtle.ConstantTimeCompare([]byte(hash), []byte(strings.ToLower(k.SHA256))) == 1 {
+				return &Principal{Subject: k.Subject, Roles: k.Roles, Method: "api-key", Tenant: k.Tenant}, nil
+			}
+		}
+		return nil, ErrInvalidAPIKey
+	}
+	return nil, nil
+}
+
+// checkTenant validates the optional tenant of a credential.
+func checkTenant(id string) error {
+	if id == "" {
+		return nil
+	}
+	_, err := tenant.Parse(id)
+	return err
+}
+
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", path)
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in %s: %w", path, err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key in %s is not an RSA key", path)
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/pkg/identity"
+	"inventory.com/pkg/tenant"
+)
+
+// Middleware authenticates every request. A caller with valid credentials
+// becomes the Principal and identity.Actor of the request context and, when
+// its credentials name a tenant, binds the request to that tenant; invalid
+// credentials are rejected with 401. Requests without credentials go on
+// anonymously and are turned away by Require.
+func Middleware(a *Authenticator) gin.HandlerFunc {
+	if !a.Configured() {
+		slog.Warn("no auth keys configured, every protected route rejects its requests")
+	}
+	return func(ctx *gin.Context) {
+		p, err := a.Authenticate(ctx.Request)
+		if err != nil {
+			Abort(ctx, err)
+			return
+		}
+		if p != nil {
+			c := WithPrincipal(ctx.Request.Context(), p)
+			c = identity.WithActor(c, p.Subject)
+			if p.Tenant != "" {
+				c = tenant.WithID(c, tenant.ID(p.Tenant))
+			}
+			c = context.WithValue(c, credentialsKey{}, credentials{
+				authorization: ctx.GetHeader(HeaderAuthorization),
+				apiKey:        ctx.GetHeader(HeaderAPIKey),
+			})
+			ctx.Request = ctx.Request.WithContext(c)
+		}
+		ctx.Next()
+	}
+}
+
+// Require lets through only callers granted perm.
+func Require(perm Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := Check(ctx.Request.Context(), perm); err != nil {
+			Abort(ctx, err)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Abort answers 403 for ErrForbidden and 401 for any other error.
+func Abort(ctx *gin.Context, err error) {
+	if errors.Is(err, ErrForbidden) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("WWW-Authenticate", `Bearer realm="inventory"`)
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+type credentialsKey struct{}
+
+type credentials struct {
+	authorization string
+	apiKey        string
+}
+
+// IHTTPClient sends the requests of a ForwardingClient.
+type IHTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ForwardingClient calls other services on behalf of the caller of the
+// request being served, by forwarding the caller's credentials. Requests
+// made outside of a request, e.g. by background jobs, use the service's own
+// API key when one is configured. The tenant the context is bound to is
+// forwarded in the X-Tenant-ID header.
+type ForwardingClient struct {
+	next   IHTTPClient
+	apiKey string
+}
+
+func NewForwardingClient(next IHTTPClient, serviceAPIKey string) *ForwardingClient {
+	return &ForwardingClient{next: next, apiKey: serviceAPIKey}
+}
+
+// Do adds the credentials and tenant to req unless it carries its own.
+func (c *ForwardingClient) Do(req *http.Request) (*http.Response, error) {
+	if id, ok := tenant.Lookup(req.Context()); ok && req.Header.Get(tenant.HeaderTenantID) == "" {
+		req.Header.Set(tenant.HeaderTenantID, string(id))
+	}
+	if req.Header.Get(HeaderAuthorization) == "" && req.Header.Get(HeaderAPIKey) == "" {
+		if creds, ok := req.Context().Value(credentialsKey{}).(credentials); ok {
+			if creds.authorization != "" {
+				req.Header.Set(HeaderAuthorization, creds.authorization)
+			} else {
+				req.Header.Set(HeaderAPIKey, creds.apiKey)
+			}
+		} else if c.apiKey != "" {
+			req.Header.Set(HeaderAPIKey, c.apiKey)
+		}
+	}
+	return c.next.Do(req)
+}
@@ -0,0 +1,175 @@
+// Package cache implements a size-bounded, expiring LRU cache with
+// read-through loading. Concurrent misses of the same key share one load.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LoadFunc loads the value of a key missing from the cache.
+type LoadFunc[V any] func(ctx context.Context) (V, error)
+
+// LRU keeps up to size entries for ttl each, evicting the least recently used
+// entry when full. Cached values are shared between callers and must not be
+// modified.
+type LRU[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	items   map[K]*list.Element
+	order   *list.List // Most recently used at the front
+	flights map[K]*flight[V]
+	epoch   uint64 // Bumped by every invalidation
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// flight is a load in progress shared by the callers missing the same key.
+type flight[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// New returns a cache of at most size entries that expire ttl after being stored.
+func New[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:    max(size, 1),
+		ttl:     ttl,
+		now:     time.Now,
+		items:   make(map[K]*list.Element),
+		order:   list.New(),
+		flights: make(map[K]*flight[V]),
+	}
+}
+
+// Get returns the cached value of key, if present and not expired.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+// GetOrLoad returns the cached value of key or loads and stores it. Callers
+// missing the same key concurrently wait for a single load, which is not
+// cancelled when only some of them give up. Errors are not cached.
+func (c *LRU[K, V]) GetOrLoad(ctx context.Context, key K, load LoadFunc[V]) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	f, ok := c.flights[key]
+	if !ok {
+		f = &flight[V]{done: make(chan struct{})}
+		c.flights[key] = f
+		go c.load(context.WithoutCancel(ctx), key, f, c.epoch, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load runs a flight. Its value is only stored when no invalidation happened
+// in the meantime, so that a load racing with a write cannot cache stale data.
+func (c *LRU[K, V]) load(ctx context.Context, key K, f *flight[V], epoch uint64, load LoadFunc[V]) {
+	f.value, f.err = load(ctx)
+
+	c.mu.Lock()
+	if c.flights[key] == f {
+		delete(c.flights, key)
+	}
+	if f.err == nil && c.epoch == epoch {
+		c.set(key, f.value)
+	}
+	c.mu.Unlock()
+	close(f.done)
+}
+
+// Set stores value under key.
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+// Delete removes key. Loads of key already in progress are not stored.
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	delete(c.flights, key)
+	c.epoch++
+}
+
+// Purge removes every entry. Loads already in progress are not stored.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+	clear(c.flights)
+	c.order.Init()
+	c.epoch++
+}
+
+// Len returns the number of entries, including expired ones not yet evicted.
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get must be called with c.mu held.
+func (c *LRU[K, V]) get(key K) (V, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	e := el.Value.(*entry[K, V])
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// set must be called with c.mu held.
+func (c *LRU[K, V]) set(key K, value V) {
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove must be called with c.mu held.
+func (c *LRU[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(size int, ttl time.Duration) (*LRU[int, string], *time.Time) {
+	c := New[int, string](size, ttl)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(2, time.Minute)
+	c.Set(1, "one")
+	c.Set(2, "two")
+	_, _ = c.Get(1)
+	c.Set(3, "three")
+
+	_, ok := c.Get(2)
+	assert.False(t, ok)
+	v, ok := c.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "one", v)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_Expires(t *testing.T) {
+	c, now := newTestCache(2, time.Minute)
+	c.Set(1, "one")
+
+	*now = now.Add(59 * time.Second)
+	_, ok := c.Get(1)
+	assert.True(t, ok)
+
+	*now = now.Add(time.Second)
+	_, ok = c.Get(1)
+	assert.False(t, ok)
+	assert.Zero(t, c.Len())
+}
+
+func TestLRU_GetOrLoadCoalescesMisses(t *testing.T) {
+	c, _ := newTestCache(10, time.Minute)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "one", nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]string, 5)
+	for i := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), 1, load)
+			assert.NoError(t, err)
+			values[i] = v
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, calls.Load())
+	assert.Equal(t, []string{"one", "one", "one", "one", "one"}, values)
+
+	v, err := c.GetOrLoad(context.Background(), 1, load)
+	require.NoError(t, err)
+	assert.Equal(t, "one", v)
+	assert.EqualValues(t, 1, calls.Load(), "a hit does not load")
+}
+
+func TestLRU_ErrorsAreNotCached(t *testing.T) {
+	c, _ := newTestCache(10, time.Minute)
+	boom := errors.New("boom")
+
+	_, err := c.GetOrLoad(context.Background(), 1, func(ctx context.Context) (string, error) { return "", boom })
+	assert.ErrorIs(t, err, boom)
+
+	v, err := c.GetOrLoad(context.Background(), 1, func(ctx context.Context) (string, error) { return "one", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "one", v)
+}
+
+func TestLRU_CallerCancellationDoesNotCancelLoad(t *testing.T) {
+	c, _ := newTestCache(10, time.Minute)
+	release := make(chan struct{})
+	loaded := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-release
+		cancel()
+	}()
+	_, err := c.GetOrLoad(ctx, 1, func(ctx context.Context) (string, error) {
+		close(release)
+		time.Sleep(10 * time.Millisecond)
+		loaded <- ctx.Err()
+		return "one", nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, <-loaded)
+	assert.Eventually(t, func() bool { _, ok := c.Get(1); return ok }, time.Second, time.Millisecond)
+}
+
+func TestLRU_InvalidationDiscardsLoadInProgress(t *testing.T) {
+	c, _ := newTestCache(10, time.Minute)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	done := make(chan string)
+	go func() {
+		v, _ := c.GetOrLoad(context.Background(), 1, func(ctx context.Context) (string, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+		done <- v
+	}()
+	<-started
+	c.Delete(1)
+
+	v, err := c.GetOrLoad(context.Background(), 1, func(ctx context.Context) (string, error) { return "fresh", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", v, "a load started after the invalidation does not join the stale one")
+
+	close(release)
+	assert.Equal(t, "stale", <-done)
+	v, _ = c.Get(1)
+	assert.Equal(t, "fresh", v)
+
+	c.Purge()
+	_, ok := c.Get(1)
+	assert.False(t, ok)
+}
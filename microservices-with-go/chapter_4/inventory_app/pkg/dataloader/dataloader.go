@@ -0,0 +1,166 @@
+// Package dataloader coalesces the lookups made while serving one request
+// into batched upstream calls and caches their results for the request.
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for keys missing from the result of a batch.
+	ErrNotFound = errors.New("dataloader: key not found")
+	// ErrBatchPanicked is returned for every key of a batch whose BatchFunc panicked.
+	ErrBatchPanicked = errors.New("dataloader: batch panicked")
+)
+
+// BatchFunc loads the values of many keys with as few upstream calls as
+// possible. Keys missing from the returned map resolve to ErrNotFound; an
+// error fails every key of the batch.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader collects the keys requested within a short wait window and loads
+// them with a single call to its BatchFunc. Results, including errors, are
+// cached for the lifetime of the loader, so a Loader should be created per
+// request.
+type Loader[K comparable, V any] struct {
+	batchFn  BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	cache   map[K]*result[V]
+	pending *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	ctx     context.Context
+	keys    []K
+	results []*result[V]
+}
+
+// New returns a loader that waits up to wait for more keys before calling
+// batchFn with at most maxBatch keys. A maxBatch of zero means no limit.
+func New[K comparable, V any](batchFn BatchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{
+		batchFn:  batchFn,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    make(map[K]*result[V]),
+	}
+}
+
+// Load returns the value of key, joining the pending batch or starting a new
+// one. The batch runs with the values of the context of the Load call that
+// started it but not its cancellation: the batch and its cached results are
+// shared with the other callers, which must not fail because the first one
+// gave up.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.cache[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.cache[key] = r
+		l.enqueue(ctx, key, r)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Prime stores a value loaded by other means, e.g. as part of a list, unless
+// the key was already requested.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	r := &result[V]{done: make(chan struct{}), value: value}
+	close(r.done)
+	l.cache[key] = r
+}
+
+// Clear forgets the cached value of key so the next Load fetches it again.
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// ClearAll forgets every cached value.
+func (l *Loader[K, V]) ClearAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache = make(map[K]*result[V])
+}
+
+// enqueue adds key to the pending batch. It must be called with l.mu held.
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, r *result[V]) {
+	if l.pending == nil {
+		b := &batch[K, V]{ctx: context.WithoutCancel(ctx)}
+		l.pending = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+	b := l.pending
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.run(b)
+	}
+}
+
+// dispatch runs b unless it was already started because it reached maxBatch.
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+	l.run(b)
+}
+
+func (l *Loader[K, V]) run(b *batch[K, V]) {
+	values, err := l.call(b)
+	for i, key := range b.keys {
+		r := b.results[i]
+		switch value, ok := values[key]; {
+		case err != nil:
+			r.err = err
+		case !ok:
+			r.err = ErrNotFound
+		default:
+			r.value = value
+		}
+		close(r.done)
+	}
+}
+
+// call runs the BatchFunc of b. It runs on its own goroutine, so a panic is
+// turned into an error for the callers waiting on the batch.
+func (l *Loader[K, V]) call(b *batch[K, V]) (values map[K]V, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			values, err = nil, fmt.Errorf("%w: %v", ErrBatchPanicked, p)
+		}
+	}()
+	return l.batchFn(b.ctx, b.keys)
+}
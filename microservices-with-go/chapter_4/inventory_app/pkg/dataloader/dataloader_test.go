@@ -0,0 +1,169 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doubling(calls *int32, batches *[][]int, mu *sync.Mutex) BatchFunc[int, int] {
+	return func(ctx context.Context, keys []int) (map[int]int, error) {
+		atomic.AddInt32(calls, 1)
+		mu.Lock()
+		sorted := append([]int(nil), keys...)
+		sort.Ints(sorted)
+		*batches = append(*batches, sorted)
+		mu.Unlock()
+
+		values := make(map[int]int, len(keys))
+		for _, k := range keys {
+			if k >= 0 {
+				values[k] = k * 2
+			}
+		}
+		return values, nil
+	}
+}
+
+func loadAll(t *testing.T, l *Loader[int, int], keys ...int) []int {
+	t.Helper()
+	values := make([]int, len(keys))
+	var wg sync.WaitGroup
+	for i, k := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := l.Load(context.Background(), k)
+			require.NoError(t, err)
+			values[i] = v
+		}()
+	}
+	wg.Wait()
+	return values
+}
+
+func TestLoader_BatchesConcurrentLoads(t *testing.T) {
+	var (
+		calls   int32
+		batches [][]int
+		mu      sync.Mutex
+	)
+	l := New(doubling(&calls, &batches, &mu), 10*time.Millisecond, 0)
+
+	values := loadAll(t, l, 1, 2, 3, 2, 1)
+
+	assert.Equal(t, []int{2, 4, 6, 4, 2}, values)
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, [][]int{{1, 2, 3}}, batches)
+}
+
+func TestLoader_CachesResults(t *testing.T) {
+	var (
+		calls   int32
+		batches [][]int
+		mu      sync.Mutex
+	)
+	l := New(doubling(&calls, &batches, &mu), time.Millisecond, 0)
+
+	loadAll(t, l, 1)
+	loadAll(t, l, 1)
+	assert.Equal(t, int32(1), calls)
+
+	l.Clear(1)
+	loadAll(t, l, 1)
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestLoader_Prime(t *testing.T) {
+	var (
+		calls   int32
+		batches [][]int
+		mu      sync.Mutex
+	)
+	l := New(doubling(&calls, &batches, &mu), time.Millisecond, 0)
+	l.Prime(7, 100)
+
+	assert.Equal(t, []int{100}, loadAll(t, l, 7))
+	assert.Equal(t, int32(0), calls)
+}
+
+func TestLoader_MaxBatch(t *testing.T) {
+	var (
+		calls   int32
+		batches [][]int
+		mu      sync.Mutex
+	)
+	l := New(doubling(&calls, &batches, &mu), 10*time.Millisecond, 2)
+
+	loadAll(t, l, 1, 2, 3, 4, 5)
+
+	assert.Equal(t, int32(3), calls)
+	for _, b := range batches {
+		assert.LessOrEqual(t, len(b), 2)
+	}
+}
+
+func TestLoader_MissingKeysAndErrors(t *testing.T) {
+	var (
+		calls   int32
+		batches [][]int
+		mu      sync.Mutex
+	)
+	l := New(doubling(&calls, &batches, &mu), time.Millisecond, 0)
+	_, err := l.Load(context.Background(), -1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	boom := errors.New("upstream down")
+	failing := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		return nil, boom
+	}, time.Millisecond, 0)
+	_, err = failing.Load(context.Background(), 1)
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestLoader_ContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	l := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return map[int]int{1: 2}, nil
+	}, time.Millisecond, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := l.Load(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The batch started by the caller that gave up still serves the others.
+	close(release)
+	v, err := l.Load(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+}
+
+func TestLoader_BatchPanics(t *testing.T) {
+	l := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		panic("nil map")
+	}, time.Millisecond, 0)
+
+	var wg sync.WaitGroup
+	for k := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := l.Load(context.Background(), k)
+			assert.ErrorIs(t, err, ErrBatchPanicked)
+			assert.ErrorContains(t, err, "nil map")
+		}()
+	}
+	wg.Wait()
+}
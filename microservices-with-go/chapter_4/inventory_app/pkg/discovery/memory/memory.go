@@ -3,7 +3,7 @@ package memory
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -73,7 +73,7 @@ func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string) ([]
 	var res []string
 	for instanceID, i := range r.serviceAddrs[serviceName] {
 		if i.lastActive.Before(time.Now().Add(-5 * time.Second)) {
-			log.Println("Instance " + instanceID + " of service " + serviceName + " is not active, skipping")
+			slog.DebugContext(ctx, "skipping inactive instance", "service_name", serviceName, "instance", instanceID)
 			continue
 		}
 		res = append(res, i.hostPort)
@@ -0,0 +1,20 @@
+//go:build consul
+
+package registry
+
+import (
+	"os"
+
+	"inventory.com/pkg/discovery"
+	"inventory.com/pkg/discovery/consul"
+)
+
+func init() {
+	constructors[KindConsul] = func() (discovery.Registry, error) {
+		addr := os.Getenv("CONSUL_ADDR")
+		if addr == "" {
+			addr = "localhost:8500"
+		}
+		return consul.NewRegistry(addr)
+	}
+}
@@ -0,0 +1,40 @@
+// Package registry selects the service registry of a service from the
+// environment. The Consul registry is only compiled in with the consul
+// build tag, which keeps the Consul client out of the default binaries:
+//
+//	go build -tags consul ./catalog/cmd
+package registry
+
+import (
+	"fmt"
+	"os"
+
+	"inventory.com/pkg/discovery"
+)
+
+// Registries selectable with DISCOVERY.
+const (
+	KindNone   = ""
+	KindConsul = "consul"
+)
+
+// constructors holds the registries compiled into the binary.
+var constructors = map[string]func() (discovery.Registry, error){}
+
+// FromEnv returns the registry selected by DISCOVERY, or nil when it is
+// unset and the service runs without discovery. The Consul agent is reached
+// at CONSUL_ADDR (default localhost:8500).
+func FromEnv() (discovery.Registry, error) {
+	kind := os.Getenv("DISCOVERY")
+	if kind == KindNone {
+		return nil, nil
+	}
+	newRegistry, ok := constructors[kind]
+	if !ok {
+		if kind == KindConsul {
+			return nil, fmt.Errorf("service registry %q is not compiled in, build with -tags consul", kind)
+		}
+		return nil, fmt.Errorf("unknown service registry %q", kind)
+	}
+	return newRegistry()
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Handler consumes an event. Handlers may be called more than once for the
+// same event and must be idempotent.
+type Handler func(ctx context.Context, event *Event) error
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+// InProcessBus delivers events synchronously to handlers in the same process.
+type InProcessBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewInProcessBus returns a bus without subscribers.
+func NewInProcessBus() *InProcessBus {
+	return &InProcessBus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for an event type, or for all events with AllEvents.
+func (b *InProcessBus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish calls every handler subscribed to the event and returns their
+// joined errors. When one handler fails the event is redelivered to all of them.
+func (b *InProcessBus) Publish(ctx context.Context, event *Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[event.Type]...), b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Headers set on every webhook delivery.
+const (
+	HeaderEventID   = "X-Event-ID"
+	HeaderEventType = "X-Event-Type"
+)
+
+// Signer authenticates a request carrying payload, e.g. by setting a
+// signature header.
+type Signer func(req *http.Request, payload []byte)
+
+// WebhookSink delivers events as JSON POST requests to an HTTP endpoint. Any
+// response other than 2xx counts as a failed delivery.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+	sign   Signer
+}
+
+// NewWebhookSink returns a sink posting to url, giving up on a request after
+// timeout. Requests are signed with sign unless it is nil.
+func NewWebhookSink(url string, timeout time.Duration, sign Signer) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Timeout: timeout}, sign: sign}
+}
+
+// Publish posts the event to the webhook endpoint.
+func (s *WebhookSink) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, event.ID)
+	req.Header.Set(HeaderEventType, event.Type)
+	if s.sign != nil {
+		s.sign(req, body)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s responded with status %d", s.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// MultiBus publishes every event to several buses. When one of them fails
+// the event is retried on all of them.
+type MultiBus []Bus
+
+// Publish delivers the event to every bus and returns their joined errors.
+func (m MultiBus) Publish(ctx context.Context, event *Event) error {
+	var errs []error
+	for _, b := range m {
+		if err := b.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
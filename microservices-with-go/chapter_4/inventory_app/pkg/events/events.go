@@ -0,0 +1,89 @@
+// Package events implements domain event publishing through a transactional
+// outbox: events are written together with the state change that caused
+// them and a relay delivers them to a bus at least once.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"inventory.com/pkg/requestid"
+	"inventory.com/pkg/tenant"
+)
+
+// Event is a domain event describing a change of an aggregate, e.g. a product,
+// of the tenant named by TenantID.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateID"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	RequestID     string          `json:"requestID,omitempty"`
+	TenantID      tenant.ID       `json:"tenantID,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Tx collects the events emitted while a state change is applied. They are
+// committed to the outbox only if the change succeeds.
+type Tx struct {
+	ctx    context.Context
+	events []*Event
+}
+
+// Emit adds an event of the given type to the transaction. The payload is
+// encoded immediately, so it captures the state at the time of the call.
+func (tx *Tx) Emit(eventType, aggregateType string, aggregateID any, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	tx.events = append(tx.events, &Event{
+		ID:            newID(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   fmt.Sprint(aggregateID),
+		OccurredAt:    time.Now(),
+		RequestID:     requestid.FromContext(tx.ctx),
+		TenantID:      tenant.FromContext(tx.ctx),
+		Payload:       data,
+	})
+	return nil
+}
+
+// Record is an event stored in the outbox together with its delivery state.
+type Record struct {
+	Event         *Event
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+}
+
+// Outbox stores events alongside the data they describe until they are delivered.
+type Outbox interface {
+	// Transact runs fn and commits the events it emitted only if fn succeeds.
+	// Implementations backed by a database run fn and the outbox writes in
+	// the same database transaction.
+	Transact(ctx context.Context, fn func(tx *Tx) error) error
+	// Pending returns up to limit undelivered records due for delivery at now, oldest first.
+	Pending(ctx context.Context, now time.Time, limit int) ([]*Record, error)
+	// MarkDelivered removes a delivered event from the outbox.
+	MarkDelivered(ctx context.Context, id string) error
+	// MarkFailed records a failed delivery attempt and when to retry.
+	MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error
+}
+
+// Bus delivers events to their consumers.
+type Bus interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b[:])
+}
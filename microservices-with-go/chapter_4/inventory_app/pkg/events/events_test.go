@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"io"
+)
+
+func TestOutboxTransact(t *testing.T) {
+	ctx := context.Background()
+	outbox := NewMemoryOutbox()
+
+	err := outbox.Transact(ctx, func(tx *Tx) error {
+		assert.NoError(t, tx.Emit("ProductCreated", "product", 1, map[string]string{"name": "TV"}))
+		return errors.New("write failed")
+	})
+	assert.Error(t, err)
+	pending, _ := outbox.Pending(ctx, time.Now(), 10)
+	assert.Empty(t, pending, "events of a failed change must not be committed")
+
+	err = outbox.Transact(ctx, func(tx *Tx) error {
+		return tx.Emit("ProductCreated", "product", 1, map[string]string{"name": "TV"})
+	})
+	assert.NoError(t, err)
+	pending, _ = outbox.Pending(ctx, time.Now(), 10)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "1", pending[0].Event.AggregateID)
+	assert.JSONEq(t, `{"name":"TV"}`, string(pending[0].Event.Payload))
+}
+
+func TestRelayRetriesUntilDelivered(t *testing.T) {
+	ctx := context.Background()
+	outbox := NewMemoryOutbox()
+	assert.NoError(t, outbox.Transact(ctx, func(tx *Tx) error {
+		return tx.Emit("OrderCompleted", "order", 7, nil)
+	}))
+
+	bus := NewInProcessBus()
+	calls := 0
+	bus.Subscribe(AllEvents, func(ctx context.Context, e *Event) error {
+		calls++
+		if calls == 1 {
+			return errors.New("consumer unavailable")
+		}
+		return nil
+	})
+	relay := NewRelay(outbox, bus, 10, time.Second, time.Minute)
+
+	now := time.Now()
+	delivered, err := relay.Flush(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+
+	// Not due again before the backoff has passed.
+	delivered, _ = relay.Flush(ctx, now.Add(500*time.Millisecond))
+	assert.Equal(t, 0, delivered)
+	assert.Equal(t, 1, calls)
+
+	delivered, err = relay.Flush(ctx, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	pending, _ := outbox.Pending(ctx, now.Add(time.Hour), 10)
+	assert.Empty(t, pending)
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := NewRelay(nil, nil, 1, time.Second, 5*time.Second)
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 4*time.Second, relay.backoff(3))
+	assert.Equal(t, 5*time.Second, relay.backoff(10))
+}
+
+func TestWebhookSink(t *testing.T) {
+	var gotType, gotSignature, gotBody string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get(HeaderEventType)
+		gotSignature = r.Header.Get("X-Signature")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sign := func(req *http.Request, payload []byte) { req.Header.Set("X-Signature", string(payload)) }
+	sink := NewWebhookSink(server.URL, time.Second, sign)
+	event := &Event{ID: "1", Type: "ProductUpdated"}
+	assert.NoError(t, sink.Publish(context.Background(), event))
+	assert.Equal(t, "ProductUpdated", gotType)
+	assert.Equal(t, gotBody, gotSignature, "the signer sees the body sent")
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, sink.Publish(context.Background(), event))
+}
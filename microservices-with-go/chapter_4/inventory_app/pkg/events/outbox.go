@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrEventNotFound is returned when an outbox record does not exist.
+var ErrEventNotFound = errors.New("event not found in outbox")
+
+// MemoryOutbox is an in-memory Outbox for services using in-memory repositories.
+type MemoryOutbox struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	order   []string
+}
+
+// NewMemoryOutbox returns an empty in-memory outbox.
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{records: make(map[string]*Record)}
+}
+
+// Transact runs fn and appends the emitted events if it returns nil. The
+// outbox is only locked for the append: callers run Transact inside the
+// critical section of the repository they change, which orders the events
+// of an aggregate, so changes of unrelated aggregates do not wait for each
+// other here.
+func (o *MemoryOutbox) Transact(ctx context.Context, fn func(tx *Tx) error) error {
+	tx := &Tx{ctx: ctx}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, e := range tx.events {
+		o.records[e.ID] = &Record{Event: e, NextAttemptAt: e.OccurredAt}
+		o.order = append(o.order, e.ID)
+	}
+	return nil
+}
+
+// Pending returns up to limit records due at now in the order they were emitted.
+func (o *MemoryOutbox) Pending(ctx context.Context, now time.Time, limit int) ([]*Record, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var result []*Record
+	for _, id := range o.order {
+		if len(result) == limit {
+			break
+		}
+		if r := o.records[id]; !r.NextAttemptAt.After(now) {
+			copied := *r
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+// MarkDelivered removes the record of a delivered event.
+func (o *MemoryOutbox) MarkDelivered(ctx context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.records[id]; !ok {
+		return fmt.Errorf("%w: id=%s", ErrEventNotFound, id)
+	}
+	delete(o.records, id)
+	for i, existing := range o.order {
+		if existing == id {
+			o.order = append(o.order[:i], o.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// MarkFailed increments the attempts of a record and schedules its retry.
+func (o *MemoryOutbox) MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	r, ok := o.records[id]
+	if !ok {
+		return fmt.Errorf("%w: id=%s", ErrEventNotFound, id)
+	}
+	r.Attempts++
+	r.LastError = cause.Error()
+	r.NextAttemptAt = retryAt
+	return nil
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"inventory.com/pkg/tenant"
+)
+
+// Relay moves events from an outbox to a bus. An event is removed from the
+// outbox only after the bus accepted it, so delivery is at least once and
+// consumers must tolerate duplicates. Consumers are called with a context
+// bound to the tenant of the event.
+type Relay struct {
+	outbox     Outbox
+	bus        Bus
+	batchSize  int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewRelay returns a relay delivering batches of up to batchSize events. A
+// failed delivery is retried after minBackoff, doubling per attempt up to maxBackoff.
+func NewRelay(outbox Outbox, bus Bus, batchSize int, minBackoff, maxBackoff time.Duration) *Relay {
+	return &Relay{
+		outbox:     outbox,
+		bus:        bus,
+		batchSize:  batchSize,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// Flush delivers the events due at now and returns how many were delivered.
+func (r *Relay) Flush(ctx context.Context, now time.Time) (int, error) {
+	records, err := r.outbox.Pending(ctx, now, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, rec := range records {
+		if err := r.bus.Publish(tenant.WithID(ctx, rec.Event.TenantID), rec.Event); err != nil {
+			retryAt := now.Add(r.backoff(rec.Attempts + 1))
+			slog.WarnContext(ctx, "event delivery failed, retrying",
+				"event_type", rec.Event.Type, "event_id", rec.Event.ID, "tenant", rec.Event.TenantID,
+				"attempt", rec.Attempts+1, "retry_at", retryAt, "error", err)
+			if err := r.outbox.MarkFailed(ctx, rec.Event.ID, err, retryAt); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+		if err := r.outbox.MarkDelivered(ctx, rec.Event.ID); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// backoff returns the delay before the given delivery attempt.
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.minBackoff
+	for i := 1; i < attempt && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.maxBackoff)
+}
+
+// Run flushes the outbox every interval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := r.Flush(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "failed to relay events", "error", err)
+			}
+		}
+	}
+}
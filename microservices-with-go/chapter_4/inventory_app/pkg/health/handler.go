@@ -0,0 +1,25 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitHandler exposes liveness under GET /healthz, which answers 200 as long
+// as the service serves requests, and readiness under GET /readyz, which
+// answers 200 when every check passes and 503 otherwise, with the outcome of
+// each check.
+func InitHandler(engine gin.IRouter, h *Health) {
+	engine.GET("/healthz", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": StatusUp})
+	})
+	engine.GET("/readyz", func(ctx *gin.Context) {
+		report := h.Check(ctx.Request.Context())
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.JSON(status, report)
+	})
+}
@@ -0,0 +1,154 @@
+// Package health reports whether a service is alive and ready to serve.
+// Liveness only says the process is serving HTTP; readiness runs pluggable
+// checks, e.g. that a repository answers or that an upstream service can be
+// resolved through the registry. While a service is ready it heartbeats into
+// the service registry, so instances that are not ready drop out of
+// Registry.ServiceAddresses.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"inventory.com/pkg/discovery"
+)
+
+// Checker checks one dependency of a service.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Statuses of a Report and of its checks.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckResult is the outcome of one check.
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Report is the outcome of the readiness checks; it is up when every check is.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether every check passed.
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+// Health holds the readiness checks of a service.
+type Health struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Checker
+}
+
+// New returns a Health whose checks each get timeout to pass.
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout, checks: make(map[string]Checker)}
+}
+
+// Add registers a readiness check under name, replacing any check of that name.
+func (h *Health) Add(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = c
+}
+
+// Check runs every readiness check concurrently.
+func (h *Health) Check(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := make(map[string]Checker, len(h.checks))
+	for name, c := range h.checks {
+		checks[name] = c
+	}
+	h.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(ctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// run runs a check with the check timeout. A check that does not return in
+// time fails even if it ignores its context.
+func (h *Health) run(ctx context.Context, c Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := CheckResult{Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Resolvable checks that the registry knows at least one active instance of
+// service, e.g. of an upstream the service calls.
+func Resolvable(registry discovery.Registry, service string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		addrs, err := registry.ServiceAddresses(ctx, service)
+		if err == nil && len(addrs) == 0 {
+			err = discovery.ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", service, err)
+		}
+		return nil
+	})
+}
+
+// Ignore makes a check pass when it fails with one of errs, e.g. a
+// repository that reports an empty store as not found.
+func Ignore(c Checker, errs ...error) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		err := c.Check(ctx)
+		for _, target := range errs {
+			if errors.Is(err, target) {
+				return nil
+			}
+		}
+		return err
+	})
+}
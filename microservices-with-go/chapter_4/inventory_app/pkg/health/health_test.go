@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/pkg/discovery"
+	"inventory.com/pkg/discovery/memory"
+)
+
+var errEmpty = errors.New("empty")
+
+func TestHealth_Check(t *testing.T) {
+	h := New(20 * time.Millisecond)
+	h.Add("ok", CheckerFunc(func(ctx context.Context) error { return nil }))
+	h.Add("empty", Ignore(CheckerFunc(func(ctx context.Context) error { return errEmpty }), errEmpty))
+	assert.True(t, h.Check(context.Background()).Ready())
+
+	h.Add("stuck", CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second) // ignores ctx, like a check waiting on a deadlocked mutex
+		return nil
+	}))
+	report := h.Check(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusUp, report.Checks["ok"].Status)
+	assert.Equal(t, StatusDown, report.Checks["stuck"].Status)
+	assert.Contains(t, report.Checks["stuck"].Error, context.DeadlineExceeded.Error())
+}
+
+func TestResolvable(t *testing.T) {
+	ctx := context.Background()
+	registry := memory.NewRegistry()
+	check := Resolvable(registry, "catalog")
+	assert.ErrorIs(t, check.Check(ctx), discovery.ErrNotFound)
+
+	require.NoError(t, registry.Register(ctx, "catalog-1", "catalog", "localhost:8081"))
+	assert.NoError(t, check.Check(ctx))
+}
+
+func TestInitHandler(t *testing.T) {
+	var healthy atomic.Bool
+	h := New(time.Second)
+	h.Add("repository", CheckerFunc(func(ctx context.Context) error {
+		if !healthy.Load() {
+			return errors.New("unreachable")
+		}
+		return nil
+	}))
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	InitHandler(engine, h)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+	rec := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "unreachable", report.Checks["repository"].Error)
+
+	healthy.Store(true)
+	assert.Equal(t, http.StatusOK, get("/readyz").Code)
+}
+
+// recordingRegistry counts the heartbeats of an instance.
+type recordingRegistry struct {
+	*memory.Registry
+	mu           sync.Mutex
+	heartbeats   int
+	deregistered bool
+}
+
+func (r *recordingRegistry) ReportHealthyState(instanceID, serviceName string) error {
+	r.mu.Lock()
+	r.heartbeats++
+	r.mu.Unlock()
+	return r.Registry.ReportHealthyState(instanceID, serviceName)
+}
+
+func (r *recordingRegistry) Deregister(ctx context.Context, instanceID, serviceName string) error {
+	r.mu.Lock()
+	r.deregistered = true
+	r.mu.Unlock()
+	return r.Registry.Deregister(ctx, instanceID, serviceName)
+}
+
+func (r *recordingRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.heartbeats
+}
+
+func TestHeartbeat_PausedWhileNotReady(t *testing.T) {
+	var ready atomic.Bool
+	ready.Store(true)
+	h := New(time.Second)
+	h.Add("repository", CheckerFunc(func(ctx context.Context) error {
+		if !ready.Load() {
+			return errors.New("unreachable")
+		}
+		return nil
+	}))
+	registry := &recordingRegistry{Registry: memory.NewRegistry()}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Heartbeat(ctx, registry, Instance{ID: "order-1", Service: "order", HostPort: "localhost:8082"}, 5*time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return registry.count() >= 2 }, time.Second, time.Millisecond)
+	addrs, err := registry.ServiceAddresses(ctx, "order")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost:8082"}, addrs)
+
+	ready.Store(false)
+	time.Sleep(20 * time.Millisecond)
+	paused := registry.count()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, paused, registry.count(), "no heartbeats while not ready")
+
+	ready.Store(true)
+	assert.Eventually(t, func() bool { return registry.count() > paused }, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	assert.True(t, registry.deregistered)
+	_, err = registry.ServiceAddresses(context.Background(), "order")
+	assert.ErrorIs(t, err, discovery.ErrNotFound)
+}
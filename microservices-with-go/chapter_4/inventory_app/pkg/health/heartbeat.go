@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"inventory.com/pkg/discovery"
+)
+
+// Instance identifies a service instance in the registry.
+type Instance struct {
+	ID       string // e.g. from discovery.GenerateInstanceID
+	Service  string
+	HostPort string // Address other services reach the instance at
+}
+
+// Heartbeat registers the instance and, every interval, reports it healthy
+// to the registry while the readiness checks pass. An instance that stops
+// being ready stops heartbeating, so the registry drops it from
+// ServiceAddresses until it is ready again. The instance is deregistered
+// when ctx is done.
+func (h *Health) Heartbeat(ctx context.Context, registry discovery.Registry, inst Instance, interval time.Duration) {
+	if err := registry.Register(ctx, inst.ID, inst.Service, inst.HostPort); err != nil {
+		slog.ErrorContext(ctx, "failed to register in the service registry", "instance", inst.ID, "error", err)
+	}
+	defer func() {
+		if err := registry.Deregister(context.Background(), inst.ID, inst.Service); err != nil {
+			slog.Error("failed to deregister from the service registry", "instance", inst.ID, "error", err)
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	ready := true
+	for {
+		report := h.Check(ctx)
+		if report.Ready() != ready {
+			ready = report.Ready()
+			if ready {
+				slog.InfoContext(ctx, "instance ready, heartbeating into the service registry", "instance", inst.ID)
+			} else {
+				slog.WarnContext(ctx, "instance not ready, heartbeats paused", "instance", inst.ID, "checks", report.Checks)
+			}
+		}
+		if ready {
+			h.report(ctx, registry, inst)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// report sends a heartbeat, registering the instance again when the
+// registry lost it, e.g. after a restart of an in-memory registry.
+func (h *Health) report(ctx context.Context, registry discovery.Registry, inst Instance) {
+	err := registry.ReportHealthyState(inst.ID, inst.Service)
+	if err == nil {
+		return
+	}
+	if err := registry.Register(ctx, inst.ID, inst.Service, inst.HostPort); err != nil {
+		slog.WarnContext(ctx, "failed to heartbeat into the service registry", "instance", inst.ID, "error", err)
+	}
+}
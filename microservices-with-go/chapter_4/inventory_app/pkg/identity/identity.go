@@ -0,0 +1,39 @@
+// Package identity carries the caller identity through a request context.
+package identity
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Anonymous is the actor recorded when the caller did not identify itself.
+const Anonymous = "anonymous"
+
+// HeaderUserID is the request header a caller uses to identify itself.
+const HeaderUserID = "X-User-ID"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the given actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor stored in ctx, or Anonymous if there is none.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return Anonymous
+}
+
+// Middleware stores the caller identity from the X-User-ID header in the request context.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if actor := ctx.GetHeader(HeaderUserID); actor != "" {
+			ctx.Request = ctx.Request.WithContext(WithActor(ctx.Request.Context(), actor))
+		}
+		ctx.Next()
+	}
+}
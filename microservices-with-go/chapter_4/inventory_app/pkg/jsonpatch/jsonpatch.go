@@ -0,0 +1,356 @@
+// Package jsonpatch implements JSON Merge Patch (RFC 7386) and JSON Patch
+// (RFC 6902) for partial updates of JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of the supported patch formats.
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedMediaType is returned for patch formats other than merge patch and JSON patch.
+	ErrUnsupportedMediaType = errors.New("unsupported patch media type")
+	// ErrInvalidPatch is returned when the patch document itself is malformed.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrCannotApply is returned when a well-formed patch cannot be applied to
+	// the target document, including failed "test" operations and results
+	// that do not decode into the target type.
+	ErrCannotApply = errors.New("patch cannot be applied")
+)
+
+// Func transforms a JSON document by applying a parsed patch.
+type Func func(doc []byte) ([]byte, error)
+
+// Parse validates a patch of the given media type and returns a function applying it.
+func Parse(mediaType string, patch []byte) (Func, error) {
+	parsed, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mediaType)
+	}
+
+	switch parsed {
+	case MediaTypeMergePatch:
+		var value any
+		if err := json.Unmarshal(patch, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return func(doc []byte) ([]byte, error) { return MergePatch(doc, patch) }, nil
+	case MediaTypeJSONPatch:
+		ops, err := parseOperations(patch)
+		if err != nil {
+			return nil, err
+		}
+		return func(doc []byte) ([]byte, error) { return applyOperations(doc, ops) }, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mediaType)
+}
+
+// ApplyTo applies patch to the JSON encoding of current and decodes the result
+// into a new value of the same type. Fields unknown to the type are rejected.
+func ApplyTo[T any](current *T, patch Func) (*T, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := patch(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(T)
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCannotApply, err)
+	}
+	return result, nil
+}
+
+// MergePatch applies an RFC 7386 merge patch to doc.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCannotApply, err)
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// operation is a single RFC 6902 operation.
+type operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func parseOperations(patch []byte) ([]operation, error) {
+	var ops []operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i, op := range ops {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d (%s) requires a value", ErrInvalidPatch, i, op.Op)
+			}
+		case "move", "copy":
+			if _, err := splitPointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+		if _, err := splitPointer(op.Path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+	}
+	return ops, nil
+}
+
+// applyOperations applies the operations in order. The patch is atomic: if
+// any operation fails the original document is left untouched.
+func applyOperations(doc []byte, ops []operation) ([]byte, error) {
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCannotApply, err)
+	}
+
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case "add":
+			root, err = add(root, op.Path, decodeValue(op.Value))
+		case "remove":
+			root, _, err = remove(root, op.Path)
+		case "replace":
+			if root, _, err = remove(root, op.Path); err == nil {
+				root, err = add(root, op.Path, decodeValue(op.Value))
+			}
+		case "move":
+			var value any
+			if root, value, err = remove(root, op.From); err == nil {
+				root, err = add(root, op.Path, value)
+			}
+		case "copy":
+			var value any
+			if value, err = get(root, op.From); err == nil {
+				root, err = add(root, op.Path, deepCopy(value))
+			}
+		case "test":
+			var value any
+			if value, err = get(root, op.Path); err == nil && !reflect.DeepEqual(value, decodeValue(op.Value)) {
+				err = fmt.Errorf("test failed at %q", op.Path)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d (%s): %v", ErrCannotApply, i, op.Op, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+func decodeValue(raw json.RawMessage) any {
+	var value any
+	_ = json.Unmarshal(raw, &value) // validated by json.Unmarshal of the whole patch
+	return value
+}
+
+func deepCopy(value any) any {
+	data, _ := json.Marshal(value)
+	var copied any
+	_ = json.Unmarshal(data, &copied)
+	return copied
+}
+
+// splitPointer decodes an RFC 6901 JSON pointer into its reference tokens.
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// parent walks to the container holding the last token of the pointer.
+func parent(root any, pointer string) (any, string, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tokens) == 0 {
+		return nil, "", nil
+	}
+	node := root
+	for _, t := range tokens[:len(tokens)-1] {
+		if node, err = child(node, t); err != nil {
+			return nil, "", err
+		}
+	}
+	return node, tokens[len(tokens)-1], nil
+}
+
+func child(node any, token string) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		value, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("path segment %q not found", token)
+		}
+		return value, nil
+	case []any:
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		return n[i], nil
+	}
+	return nil, fmt.Errorf("path segment %q does not refer to a container", token)
+}
+
+func get(root any, pointer string) (any, error) {
+	container, last, err := parent(root, pointer)
+	if err != nil {
+		return nil, err
+	}
+	if container == nil && last == "" {
+		return root, nil
+	}
+	return child(container, last)
+}
+
+// add inserts value at pointer and returns the new root. Arrays are replaced
+// by new slices, so the updated slice is written back into its own parent.
+func add(root any, pointer string, value any) (any, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return setIn(root, tokens, func(container any, last string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[last] = value
+			return c, nil
+		case []any:
+			i := len(c)
+			if last != "-" {
+				if i, err = arrayIndex(last, len(c)); err != nil {
+					return nil, err
+				}
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("cannot add to %q", pointer)
+	})
+}
+
+func remove(root any, pointer string) (any, any, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	var removed any
+	newRoot, err := setIn(root, tokens, func(container any, last string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			value, ok := c[last]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", pointer)
+			}
+			removed = value
+			delete(c, last)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(last, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = c[i]
+			return append(c[:i:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove %q", pointer)
+	})
+	return newRoot, removed, err
+}
+
+// setIn descends through tokens and lets update modify the innermost container,
+// writing every rebuilt container back into its parent.
+func setIn(node any, tokens []string, update func(container any, last string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return update(node, tokens[0])
+	}
+	next, err := child(node, tokens[0])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := setIn(next, tokens[1:], update)
+	if err != nil {
+		return nil, err
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		n[tokens[0]] = updated
+	case []any:
+		i, _ := arrayIndex(tokens[0], len(n)-1)
+		n[i] = updated
+	}
+	return node, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
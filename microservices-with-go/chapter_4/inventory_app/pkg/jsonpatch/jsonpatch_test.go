@@ -0,0 +1,72 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	doc := `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`
+	patch := `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`
+
+	result, err := MergePatch([]byte(doc), []byte(patch))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Hello!","author":{"givenName":"John"},"tags":["example"],"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`, string(result))
+}
+
+func TestJSONPatch(t *testing.T) {
+	patch, err := Parse(MediaTypeJSONPatch, []byte(`[
+		{"op":"test","path":"/a/b","value":"c"},
+		{"op":"replace","path":"/a/b","value":"d"},
+		{"op":"add","path":"/list/1","value":2},
+		{"op":"add","path":"/list/-","value":4},
+		{"op":"remove","path":"/gone"},
+		{"op":"copy","from":"/a","path":"/copied"},
+		{"op":"move","from":"/a~1b","path":"/moved"}
+	]`))
+	assert.NoError(t, err)
+
+	result, err := patch([]byte(`{"a":{"b":"c"},"list":[1,3],"gone":true,"a/b":"slash"}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":{"b":"d"},"list":[1,2,3,4],"copied":{"b":"d"},"moved":"slash"}`, string(result))
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	_, err := Parse(MediaTypeJSONPatch, []byte(`[{"op":"frobnicate","path":"/a"}]`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = Parse(MediaTypeJSONPatch, []byte(`[{"op":"add","path":"/a"}]`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = Parse("application/json", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+
+	patch, err := Parse(MediaTypeJSONPatch, []byte(`[{"op":"test","path":"/a","value":1}]`))
+	assert.NoError(t, err)
+	_, err = patch([]byte(`{"a":2}`))
+	assert.ErrorIs(t, err, ErrCannotApply)
+
+	patch, err = Parse(MediaTypeJSONPatch, []byte(`[{"op":"remove","path":"/missing"}]`))
+	assert.NoError(t, err)
+	_, err = patch([]byte(`{"a":2}`))
+	assert.ErrorIs(t, err, ErrCannotApply)
+}
+
+func TestApplyTo(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	patch, err := Parse(MediaTypeMergePatch+"; charset=utf-8", []byte(`{"count":3}`))
+	assert.NoError(t, err)
+	result, err := ApplyTo(&item{Name: "bolt", Count: 1}, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, &item{Name: "bolt", Count: 3}, result)
+
+	patch, err = Parse(MediaTypeMergePatch, []byte(`{"colour":"red"}`))
+	assert.NoError(t, err)
+	_, err = ApplyTo(&item{Name: "bolt"}, patch)
+	assert.ErrorIs(t, err, ErrCannotApply)
+}
@@ -0,0 +1,194 @@
+// Package lifecycle runs a service binary: it serves HTTP with timeouts,
+// runs the background jobs of the service, and on SIGINT or SIGTERM shuts
+// down in order:
+//
+//  1. readiness starts failing, so load balancers stop sending requests;
+//  2. background jobs are stopped and waited for, which deregisters the
+//     instance from discovery (health.Heartbeat is one of the jobs) and stops
+//     the relays and schedulers;
+//  3. requests are still served for the drain delay, until the load balancers
+//     and the other services have noticed;
+//  4. the listener is closed and in-flight requests are drained;
+//  5. the shutdown hooks run in the order they were added, e.g. a last flush
+//     of the event outbox and of the pending spans.
+//
+// A second signal during shutdown kills the process.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrShuttingDown fails the readiness check of a service shutting down.
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// Config tunes the HTTP server and the shutdown of a Service.
+type Config struct {
+	Addr              string        // Address to listen on, e.g. ":8081"
+	ReadHeaderTimeout time.Duration // Time to read the request headers
+	ReadTimeout       time.Duration // Time to read the whole request
+	WriteTimeout      time.Duration // Time from the end of the request headers to the end of the response
+	IdleTimeout       time.Duration // Time a keep-alive connection waits for the next request
+	ShutdownTimeout   time.Duration // Bounds each shutdown step
+	DrainDelay        time.Duration // Time requests are still served once readiness fails
+}
+
+// DefaultConfig suits the JSON APIs of the services.
+func DefaultConfig(addr string) Config {
+	return Config{
+		Addr:              addr,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		ShutdownTimeout:   30 * time.Second,
+		DrainDelay:        5 * time.Second,
+	}
+}
+
+// Service is a service binary being run.
+type Service struct {
+	cfg    Config
+	server *http.Server
+
+	jobs     []func(ctx context.Context)
+	hooks    []hook
+	draining atomic.Bool
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// New returns a service serving handler.
+func New(cfg Config, handler http.Handler) *Service {
+	return &Service{
+		cfg: cfg,
+		server: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		},
+	}
+}
+
+// Go adds a background job, started by Run. The job must return once ctx
+// is done.
+func (s *Service) Go(job func(ctx context.Context)) {
+	s.jobs = append(s.jobs, job)
+}
+
+// OnShutdown adds a hook run once the requests are drained. A failing hook
+// is logged and does not keep the others from running.
+func (s *Service) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.hooks = append(s.hooks, hook{name: name, fn: fn})
+}
+
+// Check fails once the service is shutting down, so that it can be added
+// to the readiness checks of the service.
+func (s *Service) Check(ctx context.Context) error {
+	if s.draining.Load() {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// Run serves until ctx is done, SIGINT or SIGTERM is received or the server
+// fails, then shuts the service down. It returns the error of the server,
+// if any.
+func (s *Service) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.serve(ctx, stop, ln)
+}
+
+// serve runs the service on ln; stop restores the default signal handling.
+func (s *Service) serve(ctx context.Context, stop func(), ln net.Listener) error {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	var jobs sync.WaitGroup
+	for _, job := range s.jobs {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job(jobsCtx)
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.server.Serve(ln) }()
+	slog.Info("service started", "addr", ln.Addr().String())
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down", "cause", context.Cause(ctx))
+	case err = <-serveErr:
+		slog.Error("server failed, shutting down", "error", err)
+	}
+	stop()
+	s.draining.Store(true)
+
+	cancelJobs()
+	if !s.wait(&jobs) {
+		slog.Warn("background jobs did not stop in time")
+	}
+	if err == nil && s.cfg.DrainDelay > 0 {
+		slog.Info("serving until readiness failure is noticed", "delay", s.cfg.DrainDelay)
+		time.Sleep(s.cfg.DrainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	if shutdownErr := s.server.Shutdown(shutdownCtx); shutdownErr != nil {
+		slog.Warn("in-flight requests were not drained in time", "error", shutdownErr)
+	}
+	cancel()
+
+	for _, h := range s.hooks {
+		hookCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+		if hookErr := h.fn(hookCtx); hookErr != nil {
+			slog.Error("shutdown hook failed", "hook", h.name, "error", hookErr)
+		}
+		cancel()
+	}
+	slog.Info("service stopped")
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// wait waits for the jobs for up to the shutdown timeout.
+func (s *Service) wait(jobs *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(s.cfg.ShutdownTimeout):
+		return false
+	}
+}
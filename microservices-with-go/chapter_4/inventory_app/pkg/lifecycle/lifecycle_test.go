@@ -0,0 +1,128 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_GracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	cfg := DefaultConfig("127.0.0.1:0")
+	cfg.ShutdownTimeout = 2 * time.Second
+	cfg.DrainDelay = 0
+	svc := New(cfg, mux)
+
+	var mu sync.Mutex
+	var steps []string
+	record := func(step string) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, step)
+	}
+	svc.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		record("job stopped")
+	})
+	svc.OnShutdown("outbox", func(ctx context.Context) error {
+		record("outbox flushed")
+		return nil
+	})
+	svc.OnShutdown("tracing", func(ctx context.Context) error {
+		record("tracing flushed")
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- svc.serve(ctx, func() {}, ln) }()
+	assert.NoError(t, svc.Check(ctx))
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if !assert.NoError(t, err) {
+			body <- ""
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+	<-started
+
+	cancel() // like SIGTERM
+	assert.Eventually(t, func() bool { return svc.Check(ctx) == ErrShuttingDown }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(steps) == 1
+	}, time.Second, time.Millisecond, "jobs stop while requests drain")
+	select {
+	case <-served:
+		t.Fatal("returned before the in-flight request was served")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, "done", <-body, "in-flight request drained")
+	require.NoError(t, <-served)
+	assert.Equal(t, []string{"job stopped", "outbox flushed", "tracing flushed"}, steps)
+
+	_, err = net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond)
+	assert.Error(t, err, "listener closed")
+}
+
+func TestService_DrainDelay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "pong")
+	})
+	cfg := DefaultConfig("127.0.0.1:0")
+	cfg.DrainDelay = 200 * time.Millisecond
+	svc := New(cfg, mux)
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- svc.serve(ctx, func() {}, ln) }()
+
+	start := time.Now()
+	cancel()
+	assert.Eventually(t, func() bool { return svc.Check(ctx) == ErrShuttingDown }, time.Second, time.Millisecond)
+	resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+	require.NoError(t, err, "new requests are served while readiness fails")
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "pong", string(data))
+
+	require.NoError(t, <-served)
+	assert.GreaterOrEqual(t, time.Since(start), cfg.DrainDelay, "the listener is closed after the delay")
+}
+
+func TestService_RunFailsOnBusyAddress(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	svc := New(DefaultConfig(ln.Addr().String()), http.NotFoundHandler())
+	assert.Error(t, svc.Run(context.Background()))
+}
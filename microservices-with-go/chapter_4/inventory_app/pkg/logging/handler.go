@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type levelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// InitHandler exposes the level of the default logger under GET /log-level
+// and lets it be changed without a restart with PUT /log-level
+// {"level": "debug"}.
+func InitHandler(engine gin.IRouter) {
+	engine.GET("/log-level", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"level": level.Level().String()})
+	})
+	engine.PUT("/log-level", func(ctx *gin.Context) {
+		var req levelRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		var lvl slog.Level
+		if err := lvl.UnmarshalText([]byte(req.Level)); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		level.Set(lvl)
+		slog.InfoContext(ctx.Request.Context(), "log level changed", "level", lvl.String())
+		ctx.JSON(http.StatusOK, gin.H{"level": lvl.String()})
+	})
+}
@@ -0,0 +1,133 @@
+// Package logging sets up structured logging with log/slog for the services.
+// Records are written as JSON to stderr, and records logged with a request
+// context carry the request ID, trace ID, route, tenant and user of the
+// request. The std log package goes through the same handler, at info level.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+	"inventory.com/pkg/identity"
+	"inventory.com/pkg/requestid"
+	"inventory.com/pkg/tenant"
+)
+
+// Formats selectable with LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config selects how a service logs.
+type Config struct {
+	Service string
+	Level   string // "debug", "info", "warn" or "error"; info when empty
+	Format  string // One of the Format constants; FormatJSON when empty
+}
+
+// ConfigFromEnv reads the level from <SERVICE>_LOG_LEVEL, e.g.
+// CATALOG_LOG_LEVEL, falling back to LOG_LEVEL, and the format from
+// LOG_FORMAT ("json", the default, or "text").
+func ConfigFromEnv(service string) Config {
+	cfg := Config{
+		Service: service,
+		Level:   os.Getenv(strings.ToUpper(service) + "_LOG_LEVEL"),
+		Format:  os.Getenv("LOG_FORMAT"),
+	}
+	if cfg.Level == "" {
+		cfg.Level = os.Getenv("LOG_LEVEL")
+	}
+	return cfg
+}
+
+// level is the minimum level of the default logger, changed at runtime
+// through InitHandler.
+var level = new(slog.LevelVar)
+
+// Init installs the default logger of the service, which also receives the
+// output of the std log package.
+func Init(cfg Config) error {
+	var lvl slog.Level
+	if cfg.Level != "" {
+		if err := lvl.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+		}
+	}
+	logger, err := New(os.Stderr, cfg, level)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	slog.SetDefault(logger)
+	return nil
+}
+
+// New returns a logger writing to w with the request attributes of the
+// context of every record and a service attribute. Records below leveler
+// are dropped.
+func New(w io.Writer, cfg Config, leveler slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: leveler}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	return slog.New(contextHandler{handler}).With("service", cfg.Service), nil
+}
+
+// Fatal logs msg with args at error level and exits, like log.Fatal.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type routeKey struct{}
+
+// withRoute returns a copy of ctx carrying the route of the request.
+func withRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// contextHandler adds the request attributes found in the context of a
+// record. Tenant and user are only known once the auth and tenant
+// middlewares ran, and are left out until then.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	if route, ok := ctx.Value(routeKey{}).(string); ok {
+		r.AddAttrs(slog.String("route", route))
+	}
+	if id, ok := tenant.Lookup(ctx); ok {
+		r.AddAttrs(slog.String("tenant", string(id)))
+	}
+	if actor := identity.Actor(ctx); actor != identity.Anonymous {
+		r.AddAttrs(slog.String("user", actor))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
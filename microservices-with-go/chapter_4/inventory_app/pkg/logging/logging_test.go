@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/pkg/identity"
+	"inventory.com/pkg/requestid"
+	"inventory.com/pkg/tenant"
+)
+
+// capture makes the default logger write JSON records to a buffer for the
+// duration of the test.
+func capture(t *testing.T, lvl slog.Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	leveler := new(slog.LevelVar)
+	leveler.Set(lvl)
+	logger, err := New(&buf, Config{Service: "catalog"}, leveler)
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestMiddleware_EnrichesRecords(t *testing.T) {
+	buf := capture(t, slog.LevelInfo)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(requestid.Middleware(), Middleware(), func(ctx *gin.Context) {
+		c := identity.WithActor(ctx.Request.Context(), "alice")
+		ctx.Request = ctx.Request.WithContext(tenant.WithID(c, "acme"))
+	})
+	engine.GET("/products/:id", func(ctx *gin.Context) {
+		slog.InfoContext(ctx.Request.Context(), "loading product")
+		ctx.Status(http.StatusNotFound)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/products/7", nil)
+	req.Header.Set(requestid.Header, "req-1")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	recs := records(t, buf)
+	require.Len(t, recs, 2)
+	for _, rec := range recs {
+		assert.Equal(t, "catalog", rec["service"])
+		assert.Equal(t, "req-1", rec["request_id"])
+		assert.Equal(t, "/products/:id", rec["route"])
+		assert.Equal(t, "acme", rec["tenant"])
+		assert.Equal(t, "alice", rec["user"])
+	}
+	assert.Equal(t, "loading product", recs[0]["msg"])
+	access := recs[1]
+	assert.Equal(t, "request", access["msg"])
+	assert.Equal(t, "WARN", access["level"])
+	assert.Equal(t, "/products/7", access["path"])
+	assert.Equal(t, float64(http.StatusNotFound), access["status"])
+}
+
+func TestNew_FiltersByLevel(t *testing.T) {
+	buf := capture(t, slog.LevelWarn)
+	slog.Info("dropped")
+	slog.Warn("kept")
+
+	recs := records(t, buf)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "kept", recs[0]["msg"])
+	_, hasTenant := recs[0]["tenant"]
+	assert.False(t, hasTenant, "records without a request context carry no request attributes")
+}
+
+func TestConfigFromEnv_ServiceLevelOverridesGlobal(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("ORDER_LOG_LEVEL", "debug")
+	assert.Equal(t, "debug", ConfigFromEnv("order").Level)
+	assert.Equal(t, "warn", ConfigFromEnv("catalog").Level)
+
+	assert.Error(t, Init(Config{Service: "order", Level: "verbose"}))
+	assert.Error(t, Init(Config{Service: "order", Format: "xml"}))
+}
+
+func TestInitHandler_ChangesLevel(t *testing.T) {
+	t.Cleanup(func() { level.Set(slog.LevelInfo) })
+	engine := gin.New()
+	InitHandler(engine)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(`{"level":"debug"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(`{"level":"loud"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware binds the route of every request to its context, so that the
+// records logged while serving it carry the route, and writes an access log
+// record once the request was served: at error level for 5xx responses, at
+// warn level for 4xx ones and at info level otherwise. It runs after
+// requestid.Middleware and tracing.Middleware; the tenant and user bound by
+// the middlewares after it are included in the access log.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		route := ctx.FullPath()
+		if route != "" {
+			ctx.Request = ctx.Request.WithContext(withRoute(ctx.Request.Context(), route))
+		}
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		lvl := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			lvl = slog.LevelError
+		case status >= http.StatusBadRequest:
+			lvl = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ctx.Writer.Size()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", ctx.Errors.String()))
+		}
+		slog.LogAttrs(ctx.Request.Context(), lvl, "request", attrs...)
+	}
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"inventory.com/pkg/resilient"
+)
+
+// IHTTPClient sends the requests of a Client.
+type IHTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client times the calls a gateway makes to another service and counts the
+// failed ones: transport errors, open circuits, timeouts and 5xx responses.
+type Client struct {
+	gateway string
+	next    IHTTPClient
+}
+
+// NewClient instruments the calls of the gateway named gateway, e.g. "catalog".
+func NewClient(gateway string, next IHTTPClient) *Client {
+	return &Client{gateway: gateway, next: next}
+}
+
+// Do sends req with the next client and records its outcome.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.next.Do(req)
+
+	outcome := "error"
+	switch {
+	case err != nil:
+		upstreamErrors.WithLabelValues(c.gateway, errorReason(err)).Inc()
+	case failed(resp.StatusCode):
+		outcome = statusClass(resp.StatusCode)
+		upstreamErrors.WithLabelValues(c.gateway, outcome).Inc()
+	default:
+		outcome = statusClass(resp.StatusCode)
+	}
+	upstreamDuration.WithLabelValues(c.gateway, req.Method, outcome).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// errorReason classifies the error of a call that got no response.
+func errorReason(err error) string {
+	switch {
+	case errors.Is(err, resilient.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "transport"
+}
@@ -0,0 +1,117 @@
+// Package metrics exposes Prometheus metrics of the services under
+// GET /metrics: rate, errors and duration of the requests served per route,
+// latency and errors of the calls made by each gateway, latency of the
+// repository operations, and business gauges computed per tenant when
+// Prometheus scrapes them.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of the metrics of the services.
+const namespace = "inventory"
+
+// routeUnmatched labels the requests that match no route, so that unknown
+// paths do not create new series.
+const routeUnmatched = "unmatched"
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Requests served, by method, route and status code.",
+	}, []string{"method", "route", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve a request, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Requests being served.",
+	})
+
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time taken by a gateway call to another service, retries included, by gateway, method and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"gateway", "method", "outcome"})
+
+	upstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Failed gateway calls to another service, by gateway and reason.",
+	}, []string{"gateway", "reason"})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Time taken by a repository operation, by repository and operation.",
+		Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5},
+	}, []string{"repository", "operation"})
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestDuration, requestsInFlight, upstreamDuration, upstreamErrors, repositoryDuration)
+}
+
+// Middleware counts and times every request by its route, e.g.
+// "/products/:id", rather than by its path.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = routeUnmatched
+		}
+		method := ctx.Request.Method
+		requestsTotal.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		requestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveRepository records the duration of a repository operation started
+// at start. Deferred at the top of the operation:
+//
+//	defer metrics.ObserveRepository("memory.Category", "GetAll", time.Now())
+func ObserveRepository(repository, operation string, start time.Time) {
+	repositoryDuration.WithLabelValues(repository, operation).Observe(time.Since(start).Seconds())
+}
+
+// InitHandler exposes the metrics of the service under GET /metrics.
+func InitHandler(engine *gin.Engine) {
+	handler := promhttp.Handler()
+	engine.GET("/metrics", func(ctx *gin.Context) {
+		handler.ServeHTTP(ctx.Writer, ctx.Request)
+	})
+}
+
+// statusClass returns the outcome label of a response status, e.g. "2xx".
+func statusClass(code int) string {
+	if code < 100 || code >= 600 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// failed reports whether a response status counts as an upstream error.
+func failed(code int) bool {
+	return code >= http.StatusInternalServerError
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"inventory.com/pkg/resilient"
+	"inventory.com/pkg/tenant"
+)
+
+func TestMiddleware_LabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware())
+	engine.GET("/products/:id", func(ctx *gin.Context) { ctx.Status(http.StatusNotFound) })
+	InitHandler(engine)
+
+	for _, path := range []string{"/products/1", "/products/2", "/nope"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(requestsTotal.WithLabelValues(http.MethodGet, "/products/:id", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requestsTotal.WithLabelValues(http.MethodGet, routeUnmatched, "404")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(requestsInFlight))
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `inventory_http_request_duration_seconds_count{method="GET",route="/products/:id"} 2`)
+}
+
+type doFunc func(req *http.Request) (*http.Response, error)
+
+func (f doFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestClient_CountsErrors(t *testing.T) {
+	status := http.StatusOK
+	var err error
+	client := NewClient("test-catalog", doFunc(func(req *http.Request) (*http.Response, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: status}, nil
+	}))
+	call := func() {
+		req := httptest.NewRequest(http.MethodGet, "http://catalog/products/1", nil)
+		client.Do(req)
+	}
+
+	call()
+	status = http.StatusServiceUnavailable
+	call()
+	err = resilient.ErrCircuitOpen
+	call()
+	err = context.DeadlineExceeded
+	call()
+	err = errors.New("connection refused")
+	call()
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(upstreamErrors.WithLabelValues("test-catalog", "5xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(upstreamErrors.WithLabelValues("test-catalog", "circuit_open")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(upstreamErrors.WithLabelValues("test-catalog", "timeout")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(upstreamErrors.WithLabelValues("test-catalog", "transport")))
+	assert.Equal(t, 3, testutil.CollectAndCount(upstreamDuration.MustCurryWith(map[string]string{"gateway": "test-catalog", "method": http.MethodGet})), "2xx, 5xx and error series")
+}
+
+func TestTenantGauge(t *testing.T) {
+	stock := map[tenant.ID]map[string]int{
+		"acme":   {"1": 5, "2": 0},
+		"globex": {"1": 7},
+	}
+	gauge := NewTenantGauge("test_product_stock", "Stock.", []string{"product_id"}, []tenant.ID{"acme", "globex", "broken"},
+		func(ctx context.Context) ([]Sample, error) {
+			levels, ok := stock[tenant.FromContext(ctx)]
+			if !ok {
+				return nil, errors.New("unavailable")
+			}
+			var samples []Sample
+			for id, n := range levels {
+				samples = append(samples, Sample{Labels: []string{id}, Value: float64(n)})
+			}
+			return samples, nil
+		})
+
+	expected := `
+# HELP inventory_test_product_stock Stock.
+# TYPE inventory_test_product_stock gauge
+inventory_test_product_stock{product_id="1",tenant="acme"} 5
+inventory_test_product_stock{product_id="2",tenant="acme"} 0
+inventory_test_product_stock{product_id="1",tenant="globex"} 7
+`
+	assert.NoError(t, testutil.CollectAndCompare(gauge, strings.NewReader(expected)))
+}
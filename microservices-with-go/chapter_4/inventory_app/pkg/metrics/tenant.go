@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"inventory.com/pkg/tenant"
+)
+
+// Sample is a value of a gauge together with the values of its labels.
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Count is the single sample of a gauge without labels.
+func Count(n int) []Sample {
+	return []Sample{{Value: float64(n)}}
+}
+
+// TenantGauge is a gauge read from the repositories of every tenant when
+// Prometheus scrapes it, e.g. the number of products. Its series carry a
+// tenant label followed by the labels of the samples.
+type TenantGauge struct {
+	name    string
+	desc    *prometheus.Desc
+	tenants []tenant.ID
+	read    func(ctx context.Context) ([]Sample, error)
+}
+
+// RegisterTenantGauge registers a gauge named inventory_<name>. read is
+// called once per tenant with a context bound to that tenant; a tenant whose
+// read fails is left out of the scrape.
+func RegisterTenantGauge(name, help string, labels []string, tenants []tenant.ID, read func(ctx context.Context) ([]Sample, error)) {
+	prometheus.MustRegister(NewTenantGauge(name, help, labels, tenants, read))
+}
+
+func NewTenantGauge(name, help string, labels []string, tenants []tenant.ID, read func(ctx context.Context) ([]Sample, error)) *TenantGauge {
+	fqName := prometheus.BuildFQName(namespace, "", name)
+	return &TenantGauge{
+		name:    fqName,
+		desc:    prometheus.NewDesc(fqName, help, append([]string{"tenant"}, labels...), nil),
+		tenants: tenants,
+		read:    read,
+	}
+}
+
+func (g *TenantGauge) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g *TenantGauge) Collect(ch chan<- prometheus.Metric) {
+	for _, id := range g.tenants {
+		samples, err := g.read(tenant.WithID(context.Background(), id))
+		if err != nil {
+			slog.Warn("failed to read gauge", "gauge", g.name, "tenant", id, "error", err)
+			continue
+		}
+		for _, s := range samples {
+			ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, s.Value, append([]string{string(id)}, s.Labels...)...)
+		}
+	}
+}
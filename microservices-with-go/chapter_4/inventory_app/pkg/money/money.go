@@ -0,0 +1,280 @@
+// Package money provides a currency-aware monetary amount stored in minor units
+// (e.g. cents), shared by the catalog, order and discount services.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"inventory.com/gen"
+)
+
+var (
+	// ErrInvalid is the base error for every invalid monetary value.
+	ErrInvalid = errors.New("invalid money")
+	// ErrUnknownCurrency is returned for currency codes that are not ISO 4217 codes known to this package.
+	ErrUnknownCurrency = fmt.Errorf("%w: unknown currency", ErrInvalid)
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+	ErrCurrencyMismatch = fmt.Errorf("%w: currency mismatch", ErrInvalid)
+	// ErrInvalidAmount is returned when an amount cannot be parsed or exceeds the currency precision.
+	ErrInvalidAmount = fmt.Errorf("%w: invalid amount", ErrInvalid)
+)
+
+// Currency is an ISO 4217 alphabetic currency code such as "USD".
+type Currency string
+
+// minorUnits maps supported ISO 4217 currencies to their number of decimal places.
+var minorUnits = map[Currency]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "IDR": 2, "INR": 2, "JOD": 3,
+	"JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PLN": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2,
+	"USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// Exponent returns the number of decimal places of the currency.
+func (c Currency) Exponent() (int, error) {
+	exp, ok := minorUnits[c]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, string(c))
+	}
+	return exp, nil
+}
+
+// Money is an amount expressed in the minor units of its currency, e.g. 1999 USD is $19.99.
+type Money struct {
+	Amount   int64    // Minor units
+	Currency Currency // ISO 4217 code
+}
+
+// New returns a Money value of the given minor units, validating the currency.
+func New(minor int64, currency Currency) (Money, error) {
+	m := Money{Amount: minor, Currency: currency}
+	if err := m.Validate(); err != nil {
+		return Money{}, err
+	}
+	return m, nil
+}
+
+// Parse converts a decimal string such as "19.99" into Money. The number of
+// fractional digits may not exceed the precision of the currency.
+func Parse(amount string, currency Currency) (Money, error) {
+	exp, err := currency.Exponent()
+	if err != nil {
+		return Money{}, err
+	}
+
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %q for %s", ErrInvalidAmount, amount, currency)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+		}
+	}
+	if negative {
+		digits = "-" + digits // parsed with the sign, down to math.MinInt64
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// Validate reports whether the currency is known.
+func (m Money) Validate() error {
+	_, err := m.Currency.Exponent()
+	return err
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// IsPositive reports whether the amount is greater than zero.
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// IsNegative reports whether the amount is less than zero.
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Add returns m + other. Both values must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidAmount)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other. Both values must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul returns m multiplied by an integer quantity.
+func (m Money) Mul(quantity int64) (Money, error) {
+	product, err := multiply(m.Amount, quantity)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Percent returns the given share of m expressed in basis points (1/100 of a
+// percent, so 1500 is 15%), rounded half away from zero to the nearest minor unit.
+func (m Money) Percent(basisPoints int64) (Money, error) {
+	product, err := multiply(m.Amount, basisPoints)
+	if err != nil {
+		return Money{}, err
+	}
+	quotient, remainder := product/10000, product%10000
+	if remainder >= 5000 {
+		quotient++
+	} else if remainder <= -5000 {
+		quotient--
+	}
+	return Money{Amount: quotient, Currency: m.Currency}, nil
+}
+
+// multiply returns a*b, failing instead of wrapping around on overflow.
+func multiply(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	// MinInt64 * -1 wraps to MinInt64, which the division check cannot detect.
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, fmt.Errorf("%w: overflow", ErrInvalidAmount)
+	}
+	return product, nil
+}
+
+// Cmp compares m and other, returning -1, 0 or +1. Both values must be in the same currency.
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Sum adds up the given values, which must all share one currency.
+func Sum(values ...Money) (Money, error) {
+	if len(values) == 0 {
+		return Money{}, nil
+	}
+	total := Money{Currency: values[0].Currency}
+	for _, v := range values {
+		var err error
+		if total, err = total.Add(v); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Decimal formats the amount using the precision of its currency, e.g. "19.99".
+func (m Money) Decimal() string {
+	exp, err := m.Currency.Exponent()
+	if err != nil || exp == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+	// The magnitude is unsigned so that math.MinInt64 can be negated.
+	sign, abs := "", uint64(m.Amount)
+	if m.Amount < 0 {
+		sign, abs = "-", -abs
+	}
+	digits := fmt.Sprintf("%0*d", exp+1, abs)
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the value as "19.99 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// jsonMoney is the wire format: the amount is a decimal string so that no
+// precision is lost in clients that decode numbers as floating point.
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency Currency        `json:"currency"`
+}
+
+// MarshalJSON encodes the value as {"amount":"19.99","currency":"USD"}, and
+// the zero value, which has no currency, as null.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m == (Money{}) {
+		return []byte("null"), nil
+	}
+	amount, err := json.Marshal(m.Decimal())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonMoney{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts the amount either as a decimal string or a JSON number,
+// and null as the zero value.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*m = Money{}
+		return nil
+	}
+	var raw jsonMoney
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	amount := string(bytes.TrimSpace(raw.Amount))
+	if unquoted, err := strconv.Unquote(amount); err == nil {
+		amount = unquoted
+	}
+	parsed, err := Parse(amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// ToProto converts the value to its protobuf representation.
+func (m Money) ToProto() *gen.Money {
+	return &gen.Money{Amount: m.Amount, Currency: string(m.Currency)}
+}
+
+// FromProto converts a protobuf message into a validated Money value.
+func FromProto(p *gen.Money) (Money, error) {
+	if p == nil {
+		return Money{}, fmt.Errorf("%w: missing value", ErrInvalid)
+	}
+	return New(p.GetAmount(), Currency(p.GetCurrency()))
+}
@@ -0,0 +1,113 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse("19.99", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, Money{Amount: 1999, Currency: "USD"}, m)
+
+	m, err = Parse("500", "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), m.Amount)
+
+	_, err = Parse("1.999", "USD")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = Parse("1.00", "XXX")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+
+	m, err = Parse("-0.05", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-5), m.Amount)
+
+	for _, amount := range []string{"-+5", "+-5", "--5", "++5", "-", "5-"} {
+		_, err = Parse(amount, "USD")
+		assert.ErrorIs(t, err, ErrInvalidAmount, amount)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := Money{Amount: 10, Currency: "USD"}
+	b := Money{Amount: 20, Currency: "USD"}
+
+	// 0.1 + 0.2 must be exactly 0.3, unlike float64.
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.30", sum.Decimal())
+
+	_, err = a.Add(Money{Amount: 10, Currency: "EUR"})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	total, err := Money{Amount: 1999, Currency: "USD"}.Mul(3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5997), total.Amount)
+
+	share, err := Money{Amount: 999, Currency: "USD"}.Percent(1500)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), share.Amount)
+}
+
+func TestOverflow(t *testing.T) {
+	_, err := Money{Amount: math.MaxInt64 / 2, Currency: "USD"}.Mul(3)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = Money{Amount: math.MinInt64, Currency: "USD"}.Mul(-1)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = Money{Amount: -1, Currency: "USD"}.Mul(math.MinInt64)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	m, err := Money{Amount: math.MinInt64, Currency: "USD"}.Mul(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(math.MinInt64), m.Amount)
+
+	_, err = Money{Amount: math.MaxInt64 / 1000, Currency: "USD"}.Percent(1500)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(Money{Amount: -1205, Currency: "USD"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"-12.05","currency":"USD"}`, string(data))
+
+	var m Money
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":12.5,"currency":"EUR"}`), &m))
+	assert.Equal(t, Money{Amount: 1250, Currency: "EUR"}, m)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"1.234","currency":"EUR"}`), &m))
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	type priced struct {
+		Price Money  `json:"price"`
+		Flat  *Money `json:"flat"`
+	}
+	for _, want := range []priced{
+		{},
+		{Price: Money{Amount: 0, Currency: "USD"}},
+		{Price: Money{Amount: math.MinInt64, Currency: "USD"}, Flat: &Money{Amount: math.MaxInt64, Currency: "JPY"}},
+	} {
+		data, err := json.Marshal(want)
+		require.NoError(t, err)
+		var got priced
+		require.NoError(t, json.Unmarshal(data, &got), string(data))
+		assert.Equal(t, want, got, string(data))
+	}
+
+	data, err := json.Marshal(priced{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price":null,"flat":null}`, string(data))
+}
+
+func TestDecimal_MinInt64(t *testing.T) {
+	assert.Equal(t, "-92233720368547758.08", Money{Amount: math.MinInt64, Currency: "USD"}.Decimal())
+	assert.Equal(t, "92233720368547758.07", Money{Amount: math.MaxInt64, Currency: "USD"}.Decimal())
+}
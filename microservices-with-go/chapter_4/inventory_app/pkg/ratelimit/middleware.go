@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"inventory.com/pkg/auth"
+)
+
+// HeaderAPIKey is the request header carrying the caller's API key.
+const HeaderAPIKey = "X-API-Key"
+
+// Prefixes of the bucket keys of a rule, after the rule name.
+const (
+	ipBucket  = "ip:"
+	keyBucket = "key:"
+)
+
+// Rule limits the requests of a route group. A request is limited by its
+// client IP and, when it carries an API key, by that key as well; each rule
+// has its own buckets. Keys are only known to the buckets by their
+// auth.HashAPIKey, and only requests the IP bucket allows take from them,
+// so that made-up keys add no allowance and few buckets. Rules of the same name share their buckets and must
+// have the same limits.
+type Rule struct {
+	Name   string // Names the buckets of the rule
+	Method string // Matches any method when empty
+	Prefix string // Matches request paths starting with it
+	PerKey Limit
+	PerIP  Limit
+}
+
+func (r Rule) matches(req *http.Request) bool {
+	return (r.Method == "" || r.Method == req.Method) && strings.HasPrefix(req.URL.Path, r.Prefix)
+}
+
+// Middleware limits every request by the first of rules matching it;
+// requests no rule matches are not limited. Responses carry the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers of the
+// most restrictive bucket, and rejected requests a Retry-After header.
+// When the store fails, requests are let through.
+//
+// The client IP is gin's Context.ClientIP, which only believes the
+// X-Forwarded-For header of the proxies set with Engine.SetTrustedProxies;
+// gin trusts every proxy until it is called.
+func Middleware(store Store, rules []Rule) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		rule, ok := match(rules, ctx.Request)
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		var results []Result
+		take := func(key string, limit Limit) bool {
+			if limit.Unlimited() {
+				return true
+			}
+			res, err := store.Take(ctx.Request.Context(), rule.Name+":"+key, limit)
+			if err != nil {
+				slog.WarnContext(ctx.Request.Context(), "failed to take a rate limit token, letting the request through", "rule", rule.Name, "error", err)
+				return true
+			}
+			results = append(results, res)
+			return res.Allowed
+		}
+		if take(ipBucket+ctx.ClientIP(), rule.PerIP) {
+			if apiKey := ctx.GetHeader(HeaderAPIKey); apiKey != "" {
+				take(keyBucket+auth.HashAPIKey(apiKey), rule.PerKey)
+			}
+		}
+		if len(results) == 0 {
+			ctx.Next()
+			return
+		}
+
+		res := binding(results)
+		header := ctx.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		if !res.Allowed {
+			header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(res.RetryAfter), 1)))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func match(rules []Rule, req *http.Request) (Rule, bool) {
+	for _, r := range rules {
+		if r.matches(req) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// limitOf returns the limit rules set for the bucket key built by Middleware.
+func limitOf(rules []Rule, key string) (Limit, bool) {
+	name, bucket, _ := strings.Cut(key, ":")
+	for _, r := range rules {
+		if r.Name != name {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(bucket, ipBucket):
+			return r.PerIP, true
+		case strings.HasPrefix(bucket, keyBucket):
+			return r.PerKey, true
+		}
+	}
+	return Limit{}, false
+}
+
+// binding returns the rejection that lasts longest or, when every bucket
+// allowed the request, the one with the fewest tokens left.
+func binding(results []Result) Result {
+	best := results[0]
+	for _, res := range results[1:] {
+		switch {
+		case !res.Allowed && (best.Allowed || res.RetryAfter > best.RetryAfter):
+			best = res
+		case res.Allowed && best.Allowed && res.Remaining < best.Remaining:
+			best = res
+		}
+	}
+	return best
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,114 @@
+// Package ratelimit limits requests with token buckets. Buckets live in a
+// Store: a MemoryStore limits a single process, a RemoteStore shares the
+// buckets of another process so that several instances enforce one quota.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst
+// tokens. The zero Limit does not limit.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// PerSecond, PerMinute and PerHour return a Limit of n requests per period
+// with bursts of up to burst requests.
+func PerSecond(n, burst int) Limit { return Limit{Rate: float64(n), Burst: burst} }
+func PerMinute(n, burst int) Limit { return Limit{Rate: float64(n) / 60, Burst: burst} }
+func PerHour(n, burst int) Limit   { return Limit{Rate: float64(n) / 3600, Burst: burst} }
+
+// Unlimited reports whether l lets every request through.
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed    bool          `json:"allowed"`
+	Limit      int           `json:"limit"`      // Size of the bucket
+	Remaining  int           `json:"remaining"`  // Whole tokens left after the request
+	Reset      time.Duration `json:"reset"`      // Until the bucket is full again
+	RetryAfter time.Duration `json:"retryAfter"` // Until the next token, when not allowed
+}
+
+// Store takes tokens from the bucket of a key.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// MemoryStore keeps the buckets in memory. Buckets that filled up again are
+// dropped from time to time.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // When the bucket is full again, after which it may be dropped
+}
+
+// sweepInterval is how often full buckets are dropped.
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Take removes a token from the bucket of key if there is one.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep must be called with s.mu held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
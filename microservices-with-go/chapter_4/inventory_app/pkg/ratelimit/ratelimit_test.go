@@ -0,0 +1,235 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inventory.com/pkg/auth"
+)
+
+func newTestStore() (*MemoryStore, *time.Time) {
+	s := NewMemoryStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	s, now := newTestStore()
+	limit := PerSecond(2, 3)
+	ctx := context.Background()
+
+	for i := range 3 {
+		res, err := s.Take(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+	res, _ := s.Take(ctx, "a", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, res.Reset)
+
+	res, _ = s.Take(ctx, "b", limit)
+	assert.True(t, res.Allowed, "keys have their own buckets")
+
+	*now = now.Add(500 * time.Millisecond)
+	res, _ = s.Take(ctx, "a", limit)
+	assert.True(t, res.Allowed)
+	assert.Zero(t, res.Remaining)
+
+	*now = now.Add(time.Hour)
+	res, _ = s.Take(ctx, "a", limit)
+	assert.Equal(t, 2, res.Remaining, "a bucket never holds more than its burst")
+}
+
+func TestMemoryStore_SweepsFullBuckets(t *testing.T) {
+	s, now := newTestStore()
+	_, _ = s.Take(context.Background(), "a", PerSecond(1, 1))
+	*now = now.Add(2 * sweepInterval)
+	_, _ = s.Take(context.Background(), "b", PerSecond(1, 1))
+
+	assert.Len(t, s.buckets, 1)
+}
+
+func newTestEngine(store Store, rules []Rule) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(store, rules))
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	engine.GET("/products/:id", ok)
+	engine.POST("/orders", ok)
+	engine.GET("/health", ok)
+	return engine
+}
+
+func do(engine *gin.Engine, method, path, apiKey, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	if apiKey != "" {
+		req.Header.Set(HeaderAPIKey, apiKey)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_RulesAndHeaders(t *testing.T) {
+	store, _ := newTestStore()
+	engine := newTestEngine(store, []Rule{
+		{Name: "orders", Method: http.MethodPost, Prefix: "/orders", PerIP: PerMinute(1, 1)},
+		{Name: "health", Prefix: "/health"},
+		{Name: "default", Prefix: "/", PerIP: PerSecond(10, 5)},
+	})
+
+	w := do(engine, http.MethodPost, "/orders", "", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+
+	w = do(engine, http.MethodPost, "/orders", "", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	w = do(engine, http.MethodGet, "/products/1", "", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code, "route groups have their own buckets")
+	assert.Equal(t, "4", w.Header().Get("RateLimit-Remaining"))
+
+	w = do(engine, http.MethodPost, "/orders", "", "10.0.0.2")
+	assert.Equal(t, http.StatusOK, w.Code, "clients have their own buckets")
+
+	for range 10 {
+		w = do(engine, http.MethodGet, "/health", "", "10.0.0.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestMiddleware_APIKeyAndIP(t *testing.T) {
+	store, _ := newTestStore()
+	engine := newTestEngine(store, []Rule{
+		{Name: "default", Prefix: "/", PerKey: PerSecond(1, 2), PerIP: PerSecond(1, 3)},
+	})
+
+	assert.Equal(t, http.StatusOK, do(engine, http.MethodGet, "/products/1", "k1", "10.0.0.1").Code)
+	w := do(engine, http.MethodGet, "/products/1", "k1", "10.0.0.2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"), "the key bucket is the most restrictive")
+	assert.Equal(t, http.StatusTooManyRequests, do(engine, http.MethodGet, "/products/1", "k1", "10.0.0.3").Code,
+		"a key is limited across IPs")
+
+	assert.Equal(t, http.StatusOK, do(engine, http.MethodGet, "/products/1", "k2", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, do(engine, http.MethodGet, "/products/1", "k3", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do(engine, http.MethodGet, "/products/1", "k4", "10.0.0.1").Code,
+		"an IP is limited across keys")
+}
+
+// keyRecorder records the bucket keys taken from its store.
+type keyRecorder struct {
+	Store
+	keys []string
+}
+
+func (r *keyRecorder) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	r.keys = append(r.keys, key)
+	return r.Store.Take(ctx, key, limit)
+}
+
+func TestMiddleware_HashesAPIKeys(t *testing.T) {
+	store, _ := newTestStore()
+	recorder := &keyRecorder{Store: store}
+	engine := newTestEngine(recorder, []Rule{
+		{Name: "default", Prefix: "/", PerKey: PerSecond(1, 5), PerIP: PerMinute(1, 1)},
+	})
+
+	assert.Equal(t, http.StatusOK, do(engine, http.MethodGet, "/products/1", "secret-key", "10.0.0.1").Code)
+	assert.Equal(t, []string{"default:ip:10.0.0.1", "default:key:" + auth.HashAPIKey("secret-key")}, recorder.keys)
+
+	recorder.keys = nil
+	assert.Equal(t, http.StatusTooManyRequests, do(engine, http.MethodGet, "/products/1", "junk-key", "10.0.0.1").Code)
+	assert.Equal(t, []string{"default:ip:10.0.0.1"}, recorder.keys, "a rejected request takes no key bucket")
+}
+
+func TestMiddleware_IgnoresSpoofedForwardedFor(t *testing.T) {
+	store, _ := newTestStore()
+	engine := newTestEngine(store, []Rule{{Name: "default", Prefix: "/", PerIP: PerMinute(1, 1)}})
+	require.NoError(t, engine.SetTrustedProxies([]string{"10.0.0.9"}))
+
+	spoofed := func(remoteIP, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+		req.RemoteAddr = remoteIP + ":1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, spoofed("10.0.0.1", "192.0.2.1"))
+	assert.Equal(t, http.StatusTooManyRequests, spoofed("10.0.0.1", "192.0.2.2"),
+		"a client that is not a trusted proxy cannot pick its IP")
+
+	assert.Equal(t, http.StatusOK, spoofed("10.0.0.9", "192.0.2.3"), "a trusted proxy forwards the client IP")
+	assert.Equal(t, http.StatusTooManyRequests, spoofed("10.0.0.9", "192.0.2.3"))
+}
+
+func TestRemoteStore_SharesQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules := []Rule{{Name: "default", Prefix: "/", PerIP: PerMinute(1, 2)}}
+	shared := gin.New()
+	InitHandler(shared, NewMemoryStore(), rules)
+	srv := httptest.NewServer(shared)
+	defer srv.Close()
+
+	first := newTestEngine(NewRemoteStore(srv.URL, http.DefaultClient), rules)
+	second := newTestEngine(NewRemoteStore(srv.URL, http.DefaultClient), rules)
+
+	assert.Equal(t, http.StatusOK, do(first, http.MethodGet, "/products/1", "", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, do(second, http.MethodGet, "/products/1", "", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do(first, http.MethodGet, "/products/1", "", "10.0.0.1").Code)
+}
+
+func TestMiddleware_FailsOpen(t *testing.T) {
+	engine := newTestEngine(NewRemoteStore("http://127.0.0.1:1", http.DefaultClient), []Rule{
+		{Name: "default", Prefix: "/", PerIP: PerSecond(1, 1)},
+	})
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, do(engine, http.MethodGet, "/products/1", "", "10.0.0.1").Code)
+	}
+}
+
+func TestInitHandler_AppliesItsOwnLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	shared := gin.New()
+	InitHandler(shared, NewMemoryStore(), []Rule{{Name: "default", Prefix: "/", PerIP: PerMinute(1, 1)}})
+
+	take := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ratelimit/take", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		shared.ServeHTTP(w, req)
+		return w
+	}
+	greedy := `{"key":"default:ip:10.0.0.1","limit":{"rate":1000,"burst":1000}}`
+	var res Result
+	w := take(greedy)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Limit, "the limit sent by the caller is ignored")
+
+	w = take(greedy)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.False(t, res.Allowed)
+
+	assert.Equal(t, http.StatusBadRequest, take(`{"key":"other:ip:10.0.0.1"}`).Code)
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IHTTPClient sends the requests of a RemoteStore.
+type IHTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RemoteStore takes tokens from the buckets of another process exposing its
+// store with InitHandler, so that every instance using it shares one quota.
+type RemoteStore struct {
+	addr   string
+	client IHTTPClient
+}
+
+func NewRemoteStore(addr string, client IHTTPClient) *RemoteStore {
+	return &RemoteStore{addr: addr, client: client}
+}
+
+type takeRequest struct {
+	Key string `json:"key"`
+}
+
+// Take asks the remote store for a token. The remote instance applies the
+// limit its own rules set for key; limit is not sent.
+func (s *RemoteStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	body, err := json.Marshal(takeRequest{Key: key})
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.addr+"/ratelimit/take", bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("non-2xx response: %v", resp.Status)
+	}
+	var res Result
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+// InitHandler shares store with the RemoteStores of other instances under
+// POST /ratelimit/take. Tokens are taken with the limits of rules, whatever
+// the caller asks for, so engine should only let the other instances in.
+// The route itself must not be rate limited.
+func InitHandler(engine gin.IRouter, store Store, rules []Rule) {
+	engine.POST("/ratelimit/take", func(ctx *gin.Context) {
+		var req takeRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil || req.Key == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+			return
+		}
+		limit, ok := limitOf(rules, req.Key)
+		if !ok || limit.Unlimited() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "no rule limits this key"})
+			return
+		}
+		res, err := store.Take(ctx.Request.Context(), req.Key, limit)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, res)
+	})
+}
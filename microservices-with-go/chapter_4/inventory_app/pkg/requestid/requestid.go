@@ -0,0 +1,48 @@
+// Package requestid assigns every request an identifier that is carried
+// through the request context and echoed to the caller.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the request and response header carrying the request ID.
+const Header = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// With returns a copy of ctx carrying the given request ID.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// New returns a random 128-bit request ID in hex.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b[:])
+}
+
+// Middleware stores the X-Request-ID header in the request context, generating
+// an ID when the caller did not send one, and echoes it in the response.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(Header)
+		if id == "" {
+			id = New()
+		}
+		ctx.Request = ctx.Request.WithContext(With(ctx.Request.Context(), id))
+		ctx.Header(Header, id)
+		ctx.Next()
+	}
+}
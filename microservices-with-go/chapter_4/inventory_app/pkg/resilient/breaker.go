@@ -0,0 +1,132 @@
+package resilient
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker.
+type State string
+
+const (
+	// StateClosed lets every call through and counts consecutive failures.
+	StateClosed State = "closed"
+	// StateOpen rejects calls until the open timeout has passed.
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probe through; its outcome closes or reopens the circuit.
+	StateHalfOpen State = "half-open"
+)
+
+// BreakerStats is a snapshot of a circuit breaker, exposed as metrics.
+type BreakerStats struct {
+	Upstream            string    `json:"upstream"`
+	State               State     `json:"state"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	Successes           int64     `json:"successes"`
+	Failures            int64     `json:"failures"`
+	Rejected            int64     `json:"rejected"` // Calls refused while open
+	Opened              int64     `json:"opened"`   // Transitions to open
+	ChangedAt           time.Time `json:"changedAt"`
+}
+
+// breaker guards one upstream instance.
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	stats   BreakerStats
+	probing bool // The half-open probe is in flight
+}
+
+func newBreaker(upstream string, threshold int, openTimeout time.Duration, now func() time.Time) *breaker {
+	return &breaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         now,
+		stats:       BreakerStats{Upstream: upstream, State: StateClosed, ChangedAt: now()},
+	}
+}
+
+// allow reports whether a call may proceed and whether it is the half-open
+// probe. A call that was allowed must be followed by exactly one call to record.
+func (b *breaker) allow() (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stats.State == StateOpen && b.now().Sub(b.stats.ChangedAt) >= b.openTimeout {
+		b.transition(StateHalfOpen)
+	}
+	switch b.stats.State {
+	case StateOpen:
+		b.stats.Rejected++
+		return false, false
+	case StateHalfOpen:
+		if b.probing {
+			b.stats.Rejected++
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+// record counts the outcome of an allowed call. Only the probe decides the
+// state of a half-open circuit; calls started before the circuit opened
+// are counted but change nothing.
+func (b *breaker) record(success, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if success {
+		b.stats.Successes++
+		b.stats.ConsecutiveFailures = 0
+		if probe {
+			b.transition(StateClosed)
+		}
+		return
+	}
+
+	b.stats.Failures++
+	b.stats.ConsecutiveFailures++
+	if probe || b.stats.State == StateClosed && b.stats.ConsecutiveFailures >= b.threshold {
+		b.transition(StateOpen)
+	}
+}
+
+// release ends an allowed call without counting it, e.g. when the caller gave
+// up on it, so that a half-open circuit can send another probe.
+func (b *breaker) release(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+}
+
+// transition must be called with b.mu held.
+func (b *breaker) transition(to State) {
+	lvl := slog.LevelInfo
+	if to == StateOpen {
+		lvl = slog.LevelWarn
+	}
+	slog.Log(context.Background(), lvl, "circuit breaker state changed", "upstream", b.stats.Upstream, "from", b.stats.State, "to", to)
+	b.stats.State = to
+	b.stats.ChangedAt = b.now()
+	if to == StateOpen {
+		b.stats.Opened++
+	}
+}
+
+func (b *breaker) snapshot() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
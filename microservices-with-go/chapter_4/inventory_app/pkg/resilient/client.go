@@ -0,0 +1,211 @@
+// Package resilient provides the HTTP client shared by the service gateways.
+// Every attempt has its own deadline, idempotent requests are retried with
+// jittered exponential backoff, and each upstream instance (host:port) sits
+// behind its own circuit breaker so that a failing instance is not called
+// until it had time to recover.
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the upstream while its circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Config tunes a Client.
+type Config struct {
+	Timeout          time.Duration     // Deadline of a single attempt, including reading the body
+	MaxAttempts      int               // Attempts of an idempotent request, including the first
+	MinBackoff       time.Duration     // Upper bound of the wait before the first retry
+	MaxBackoff       time.Duration     // Upper bound of any wait between attempts
+	FailureThreshold int               // Consecutive failures that open a circuit
+	OpenTimeout      time.Duration     // Time an open circuit waits before letting a probe through
+	Transport        http.RoundTripper // Sends every attempt; http.DefaultTransport when nil
+}
+
+// DefaultConfig suits calls between services on the same network.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          2 * time.Second,
+		MaxAttempts:      3,
+		MinBackoff:       50 * time.Millisecond,
+		MaxBackoff:       time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      10 * time.Second,
+	}
+}
+
+// Client sends requests with per-attempt deadlines, retries and circuit breaking.
+type Client struct {
+	cfg   Config
+	http  *http.Client
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func New(cfg Config) *Client {
+	return &Client{
+		cfg:      cfg,
+		http:     &http.Client{Transport: cfg.Transport},
+		now:      time.Now,
+		sleep:    sleep,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// Do sends req like http.Client.Do. Only GET, HEAD, OPTIONS, PUT and DELETE
+// requests are retried, after transport errors, timed out attempts and 429,
+// 502, 503 and 504 responses; a retried request must have a replayable body
+// (http.NewRequest sets one up for in-memory bodies). Transport errors and
+// 5xx responses count as failures of the upstream instance.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	b := c.breaker(req.URL.Host)
+
+	attempts := 1
+	if idempotent(req.Method) && (req.Body == nil || req.GetBody != nil) {
+		attempts = max(c.cfg.MaxAttempts, 1)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+		}
+
+		ok, probe := b.allow()
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, req.URL.Host)
+		}
+		resp, err := c.attempt(req)
+		if err != nil && ctx.Err() != nil {
+			b.release(probe)
+			return nil, err
+		}
+		b.record(err == nil && resp.StatusCode < http.StatusInternalServerError, probe)
+
+		if err == nil && !retryableStatus(resp.StatusCode) || attempt == attempts-1 {
+			return resp, err
+		}
+		if err == nil {
+			lastErr = fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+// attempt sends req with the attempt deadline. The deadline stays in force
+// until the response body is closed.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.cfg.Timeout)
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// backoff returns a random wait of up to MinBackoff doubled for every
+// previous retry, capped at MaxBackoff ("full jitter").
+func (c *Client) backoff(attempt int) time.Duration {
+	limit := c.cfg.MinBackoff << (attempt - 1)
+	if limit > c.cfg.MaxBackoff || limit <= 0 {
+		limit = c.cfg.MaxBackoff
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit) + 1
+}
+
+func (c *Client) breaker(upstream string) *breaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[upstream]
+	if !ok {
+		b = newBreaker(upstream, max(c.cfg.FailureThreshold, 1), c.cfg.OpenTimeout, c.now)
+		c.breakers[upstream] = b
+	}
+	return b
+}
+
+// Stats returns the state of the circuit of every upstream called so far.
+func (c *Client) Stats() []BreakerStats {
+	c.mu.Lock()
+	breakers := make([]*breaker, 0, len(c.breakers))
+	for _, b := range c.breakers {
+		breakers = append(breakers, b)
+	}
+	c.mu.Unlock()
+
+	stats := make([]BreakerStats, len(breakers))
+	for i, b := range breakers {
+		stats[i] = b.snapshot()
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Upstream < stats[j].Upstream })
+	return stats
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,15 @@
+package resilient
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitHandler exposes the circuit breaker metrics of client under
+// GET /circuit-breakers.
+func InitHandler(engine *gin.Engine, client *Client) {
+	engine.GET("/circuit-breakers", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, client.Stats())
+	})
+}
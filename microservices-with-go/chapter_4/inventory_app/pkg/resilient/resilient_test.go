@@ -0,0 +1,274 @@
+package resilient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer answers with the queued status codes in order, then with 200.
+// A status of 0 makes the attempt hang until the client gives up.
+type flakyServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	script []int
+	calls  atomic.Int32
+	bodies []string
+}
+
+func newFlakyServer(t *testing.T, script ...int) *flakyServer {
+	t.Helper()
+	s := &flakyServer{script: script}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		status := http.StatusOK
+		if len(s.script) > 0 {
+			status, s.script = s.script[0], s.script[1:]
+		}
+		s.mu.Unlock()
+
+		if status == 0 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *flakyServer) set(script ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = script
+}
+
+func newTestClient() (*Client, *time.Time) {
+	c := New(Config{
+		Timeout:          200 * time.Millisecond,
+		MaxAttempts:      3,
+		MinBackoff:       time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		FailureThreshold: 3,
+		OpenTimeout:      time.Minute,
+	})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func send(t *testing.T, c *Client, method, url, body string) (*http.Response, error) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	require.NoError(t, err)
+	resp, err := c.Do(req)
+	if resp != nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	srv := newFlakyServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	c, _ := newTestClient()
+
+	resp, err := send(t, c, http.MethodGet, srv.URL, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 3, srv.calls.Load())
+}
+
+func TestClient_ReplaysBodyOnRetry(t *testing.T) {
+	srv := newFlakyServer(t, http.StatusServiceUnavailable)
+	c, _ := newTestClient()
+
+	resp, err := send(t, c, http.MethodPut, srv.URL, `{"name":"TV"}`)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{`{"name":"TV"}`, `{"name":"TV"}`}, srv.bodies)
+}
+
+func TestClient_DoesNotRetryPost(t *testing.T) {
+	srv := newFlakyServer(t, http.StatusServiceUnavailable)
+	c, _ := newTestClient()
+
+	resp, err := send(t, c, http.MethodPost, srv.URL, `{}`)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, srv.calls.Load())
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	srv := newFlakyServer(t, http.StatusNotFound)
+	c, _ := newTestClient()
+
+	resp, err := send(t, c, http.MethodGet, srv.URL, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.EqualValues(t, 1, srv.calls.Load())
+	assert.Equal(t, StateClosed, c.Stats()[0].State)
+	assert.Zero(t, c.Stats()[0].Failures)
+}
+
+func TestClient_ClientErrorsKeepCircuitClosed(t *testing.T) {
+	srv := newFlakyServer(t, 404, 404, 404, 404, 404)
+	c, _ := newTestClient()
+
+	for range 5 {
+		resp, err := send(t, c, http.MethodGet, srv.URL, "")
+		require.NoError(t, err, "lookups of unknown IDs never open the circuit")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+	assert.EqualValues(t, 5, srv.calls.Load())
+	assert.Equal(t, StateClosed, c.Stats()[0].State)
+}
+
+func TestClient_AttemptDeadline(t *testing.T) {
+	srv := newFlakyServer(t, 0)
+	c, _ := newTestClient()
+
+	start := time.Now()
+	resp, err := send(t, c, http.MethodGet, srv.URL, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, srv.calls.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_GivesUpWhenCallerCancels(t *testing.T) {
+	srv := newFlakyServer(t, 0, 0, 0)
+	c, _ := newTestClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	_, err = c.Do(req)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 1, srv.calls.Load())
+	assert.Zero(t, c.Stats()[0].Failures, "a call the caller gave up on says nothing about the upstream")
+}
+
+func TestClient_CircuitOpensAndRecovers(t *testing.T) {
+	srv := newFlakyServer(t, 500, 500, 500)
+	c, now := newTestClient()
+
+	for range 3 {
+		resp, err := send(t, c, http.MethodGet, srv.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+	_, err := send(t, c, http.MethodGet, srv.URL, "")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 3, srv.calls.Load(), "an open circuit does not call the upstream")
+
+	stats := c.Stats()[0]
+	assert.Equal(t, StateOpen, stats.State)
+	assert.EqualValues(t, 1, stats.Opened)
+	assert.EqualValues(t, 1, stats.Rejected)
+
+	// The failing probe reopens the circuit.
+	*now = now.Add(time.Minute)
+	srv.set(500)
+	resp, err := send(t, c, http.MethodGet, srv.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, StateOpen, c.Stats()[0].State)
+	_, err = send(t, c, http.MethodGet, srv.URL, "")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// The successful probe closes it.
+	*now = now.Add(time.Minute)
+	resp, err = send(t, c, http.MethodGet, srv.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	stats = c.Stats()[0]
+	assert.Equal(t, StateClosed, stats.State)
+	assert.EqualValues(t, 2, stats.Opened)
+	assert.Zero(t, stats.ConsecutiveFailures)
+}
+
+func TestClient_RetriesStopAtOpenCircuit(t *testing.T) {
+	srv := newFlakyServer(t, 503, 503, 503, 503)
+	c, _ := newTestClient()
+
+	resp, err := send(t, c, http.MethodGet, srv.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	_, err = send(t, c, http.MethodGet, srv.URL, "")
+
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 3, srv.calls.Load())
+}
+
+func TestClient_BreakerPerInstance(t *testing.T) {
+	down := newFlakyServer(t, 500, 500, 500)
+	up := newFlakyServer(t)
+	c, _ := newTestClient()
+
+	for range 3 {
+		_, err := send(t, c, http.MethodGet, down.URL, "")
+		require.NoError(t, err)
+	}
+	_, err := send(t, c, http.MethodGet, down.URL, "")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	resp, err := send(t, c, http.MethodGet, up.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, c.Stats(), 2)
+}
+
+func TestBreaker_SingleProbe(t *testing.T) {
+	now := time.Now()
+	b := newBreaker("catalog:8081", 1, time.Second, func() time.Time { return now })
+	ok, probe := b.allow()
+	require.True(t, ok)
+	b.record(false, probe)
+
+	now = now.Add(time.Second)
+	ok, probe = b.allow()
+	assert.True(t, ok)
+	assert.True(t, probe)
+	ok, _ = b.allow()
+	assert.False(t, ok, "only one probe at a time")
+
+	b.release(probe)
+	ok, probe = b.allow()
+	assert.True(t, ok)
+	assert.True(t, probe)
+	assert.Equal(t, StateHalfOpen, b.snapshot().State)
+}
+
+func TestBackoff(t *testing.T) {
+	c := New(Config{MinBackoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond})
+	for range 100 {
+		assert.LessOrEqual(t, c.backoff(1), 10*time.Millisecond)
+		assert.LessOrEqual(t, c.backoff(2), 20*time.Millisecond)
+		assert.LessOrEqual(t, c.backoff(5), 30*time.Millisecond)
+		assert.Positive(t, c.backoff(1))
+	}
+}
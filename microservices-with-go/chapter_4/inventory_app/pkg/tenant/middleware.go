@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware binds every request to a tenant. A caller whose credentials
+// name a tenant, bound by auth.Middleware, is held to it and may only repeat
+// it in the X-Tenant-ID header; other callers pick their tenant with the
+// header and default to Default. Tenants outside of allowed are rejected.
+//
+// With more than one tenant allowed, only callers mayChoose lets through may
+// pick their tenant; any other caller whose credentials name no tenant is
+// rejected with ErrTenantRequired.
+func Middleware(allowed []ID, mayChoose func(ctx context.Context) bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, bound := Lookup(ctx.Request.Context())
+		if !bound && len(allowed) > 1 && !mayChoose(ctx.Request.Context()) {
+			abort(ctx, ErrTenantRequired)
+			return
+		}
+		if header := ctx.GetHeader(HeaderTenantID); header != "" {
+			requested, err := Parse(header)
+			if err != nil {
+				abort(ctx, err)
+				return
+			}
+			if bound && requested != id {
+				abort(ctx, ErrTenantMismatch)
+				return
+			}
+			id, bound = requested, true
+		}
+		if !bound {
+			id = Default
+		}
+		if !slices.Contains(allowed, id) {
+			abort(ctx, ErrUnknownTenant)
+			return
+		}
+		ctx.Request = ctx.Request.WithContext(WithID(ctx.Request.Context(), id))
+		ctx.Next()
+	}
+}
+
+func abort(ctx *gin.Context, err error) {
+	status := http.StatusForbidden
+	if errors.Is(err, ErrInvalidTenant) {
+		status = http.StatusBadRequest
+	}
+	ctx.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,122 @@
+// Package tenant carries the tenant of a request through its context and
+// partitions repository data per tenant. Repositories look up their
+// partition with Partitions.For, so a request can only ever reach the data
+// of the tenant it was bound to.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Default is the tenant of requests that name none, and the only tenant of a
+// deployment that does not configure TENANTS.
+const Default ID = "default"
+
+// HeaderTenantID is the request header naming the tenant of a request.
+const HeaderTenantID = "X-Tenant-ID"
+
+var (
+	ErrInvalidTenant  = errors.New("invalid tenant ID")
+	ErrUnknownTenant  = errors.New("unknown tenant")
+	ErrTenantMismatch = errors.New("tenant does not match the authenticated caller")
+	ErrTenantRequired = errors.New("credentials must name a tenant")
+	// ErrNoTenant is returned by Partitions.For for a context bound to no tenant.
+	ErrNoTenant = errors.New("context is not bound to a tenant")
+)
+
+// ID identifies a tenant: 1 to 64 lowercase letters, digits or dashes.
+type ID string
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Parse validates a tenant ID.
+func Parse(s string) (ID, error) {
+	if !idPattern.MatchString(s) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTenant, s)
+	}
+	return ID(s), nil
+}
+
+// ParseList parses a comma separated list of tenant IDs. An empty list
+// yields only Default.
+func ParseList(s string) ([]ID, error) {
+	if strings.TrimSpace(s) == "" {
+		return []ID{Default}, nil
+	}
+	var ids []ID
+	for _, part := range strings.Split(s, ",") {
+		id, err := Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// FromEnv returns the tenants served by the deployment, read from TENANTS.
+func FromEnv() ([]ID, error) {
+	return ParseList(os.Getenv("TENANTS"))
+}
+
+type tenantKey struct{}
+
+// WithID returns a copy of ctx bound to the given tenant.
+func WithID(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// Lookup returns the tenant ctx is bound to, if any.
+func Lookup(ctx context.Context) (ID, bool) {
+	id, ok := ctx.Value(tenantKey{}).(ID)
+	return id, ok && id != ""
+}
+
+// FromContext returns the tenant ctx is bound to, or Default if there is none.
+func FromContext(ctx context.Context) ID {
+	if id, ok := Lookup(ctx); ok {
+		return id
+	}
+	return Default
+}
+
+// Partitions holds one P per tenant, created on first use. It is safe for
+// concurrent use; the partitions themselves are guarded by their owner.
+type Partitions[P any] struct {
+	mu    sync.Mutex
+	parts map[ID]*P
+	newP  func() *P
+}
+
+// NewPartitions returns partitions initialized with newP.
+func NewPartitions[P any](newP func() *P) *Partitions[P] {
+	return &Partitions[P]{parts: make(map[ID]*P), newP: newP}
+}
+
+// For returns the partition of the tenant ctx is bound to. A context bound
+// to no tenant fails with ErrNoTenant instead of falling back to Default, so
+// a code path that lost its tenant cannot reach the data of another one.
+func (p *Partitions[P]) For(ctx context.Context) (*P, error) {
+	id, ok := Lookup(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	part, ok := p.parts[id]
+	if !ok {
+		part = p.newP()
+		p.parts[id] = part
+	}
+	return part, nil
+}
@@ -0,0 +1,129 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseList(t *testing.T) {
+	ids, err := ParseList("")
+	require.NoError(t, err)
+	assert.Equal(t, []ID{Default}, ids)
+
+	ids, err = ParseList("acme, globex,acme")
+	require.NoError(t, err)
+	assert.Equal(t, []ID{"acme", "globex"}, ids)
+
+	for _, invalid := range []string{"Acme", "../etc", "-acme", "acme,"} {
+		_, err := ParseList(invalid)
+		assert.ErrorIs(t, err, ErrInvalidTenant, invalid)
+	}
+}
+
+func TestPartitions(t *testing.T) {
+	type counter struct{ n int }
+	parts := NewPartitions(func() *counter { return &counter{} })
+	acme := WithID(context.Background(), "acme")
+
+	for range 2 {
+		part, err := parts.For(acme)
+		require.NoError(t, err)
+		part.n++
+	}
+
+	part, err := parts.For(acme)
+	require.NoError(t, err)
+	assert.Equal(t, 2, part.n)
+	part, err = parts.For(WithID(context.Background(), "globex"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, part.n)
+
+	_, err = parts.For(context.Background())
+	assert.ErrorIs(t, err, ErrNoTenant, "an unbound context has no partition")
+	part, err = parts.For(WithID(context.Background(), Default))
+	require.NoError(t, err)
+	assert.Equal(t, 0, part.n, "an unbound context does not fall back to Default")
+}
+
+type chooserKey struct{}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) {
+		// Stands in for auth.Middleware binding a caller restricted to a tenant.
+		if bound := ctx.GetHeader("X-Test-Bound"); bound != "" {
+			ctx.Request = ctx.Request.WithContext(WithID(ctx.Request.Context(), ID(bound)))
+		}
+		if ctx.GetHeader("X-Test-Chooser") != "" {
+			ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), chooserKey{}, true))
+		}
+	}, Middleware([]ID{Default, "acme", "globex"}, func(ctx context.Context) bool {
+		// Stands in for auth.MayChooseTenant.
+		return ctx.Value(chooserKey{}) != nil
+	}))
+	engine.GET("/", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, string(FromContext(ctx.Request.Context())))
+	})
+
+	do := func(header, bound string, chooser bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if chooser {
+			req.Header.Set("X-Test-Chooser", "1")
+		}
+		if header != "" {
+			req.Header.Set(HeaderTenantID, header)
+		}
+		if bound != "" {
+			req.Header.Set("X-Test-Bound", bound)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct {
+		name, header, bound string
+		chooser             bool
+		status              int
+		tenant              string
+	}{
+		{name: "no tenant", chooser: true, status: http.StatusOK, tenant: "default"},
+		{name: "header", header: "acme", chooser: true, status: http.StatusOK, tenant: "acme"},
+		{name: "credentials", bound: "globex", status: http.StatusOK, tenant: "globex"},
+		{name: "header repeating credentials", header: "globex", bound: "globex", status: http.StatusOK, tenant: "globex"},
+		{name: "header overriding credentials", header: "acme", bound: "globex", status: http.StatusForbidden},
+		{name: "unknown tenant", header: "initech", chooser: true, status: http.StatusForbidden},
+		{name: "invalid tenant", header: "../acme", chooser: true, status: http.StatusBadRequest},
+		{name: "credentials without tenant", status: http.StatusForbidden},
+		{name: "header without tenant in credentials", header: "acme", status: http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(tc.header, tc.bound, tc.chooser)
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusOK {
+				assert.Equal(t, tc.tenant, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_SingleTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware([]ID{Default}, func(context.Context) bool { return false }))
+	engine.GET("/", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, string(FromContext(ctx.Request.Context())))
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "a single tenant needs no claim")
+	assert.Equal(t, "default", w.Body.String())
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"inventory.com/pkg/requestid"
+)
+
+// AttributeRequestID carries the X-Request-ID of a request on its spans.
+const AttributeRequestID = attribute.Key("inventory.request_id")
+
+// Middleware starts a server span for every request, continuing the trace
+// of the caller's traceparent header. It runs after requestid.Middleware so
+// that the span carries the request ID. Responses of 5xx mark the span as failed.
+func Middleware() gin.HandlerFunc {
+	tracer := otel.Tracer(tracerName)
+	return func(ctx *gin.Context) {
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		name := ctx.Request.Method
+		if route := ctx.FullPath(); route != "" {
+			name += " " + route
+		}
+		c, span := tracer.Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRoute(ctx.FullPath()),
+				semconv.URLPath(ctx.Request.URL.Path),
+				AttributeRequestID.String(requestid.FromContext(ctx.Request.Context())),
+			),
+		)
+		defer span.End()
+		ctx.Request = ctx.Request.WithContext(c)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(ctx.Errors) > 0 {
+			span.SetStatus(codes.Error, strings.Join(ctx.Errors.Errors(), "; "))
+		}
+	}
+}
+
+// Transport instruments outgoing requests: every request gets a client span
+// and carries the traceparent and X-Request-ID of the request being served.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(requestIDTransport{next})
+}
+
+type requestIDTransport struct {
+	next http.RoundTripper
+}
+
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := requestid.FromContext(req.Context()); id != "" && req.Header.Get(requestid.Header) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(requestid.Header, id)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,97 @@
+// Package tracing sets up OpenTelemetry tracing for the services: server
+// spans for incoming gin requests, client spans for outgoing requests that
+// propagate the W3C traceparent header, and spans around controller and
+// repository calls. Spans go to stdout or to an OTLP collector.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters selectable with OTEL_TRACES_EXPORTER.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// tracerName names the tracer of the spans created by the services.
+const tracerName = "inventory.com/pkg/tracing"
+
+// Config selects where the spans of a service go.
+type Config struct {
+	ServiceName  string
+	Exporter     string // One of the Exporter constants; ExporterNone when empty
+	OTLPEndpoint string // Base URL of the collector's OTLP/HTTP receiver
+}
+
+// ConfigFromEnv reads OTEL_TRACES_EXPORTER ("stdout", "otlp" or "none", the
+// default), OTEL_EXPORTER_OTLP_ENDPOINT (default http://localhost:4318) and
+// OTEL_SERVICE_NAME, which overrides service.
+func ConfigFromEnv(service string) Config {
+	cfg := Config{
+		ServiceName:  service,
+		Exporter:     os.Getenv("OTEL_TRACES_EXPORTER"),
+		OTLPEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		cfg.ServiceName = name
+	}
+	if cfg.OTLPEndpoint == "" {
+		cfg.OTLPEndpoint = "http://localhost:4318"
+	}
+	return cfg
+}
+
+// Init installs the global tracer provider and the W3C trace context and
+// baggage propagators. The returned function flushes the pending spans and
+// must be called before the service exits. With ExporterNone spans are still
+// created and propagated, so trace IDs reach the services downstream, but
+// nothing is exported.
+func Init(cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName))),
+	}
+	switch cfg.Exporter {
+	case "", ExporterNone:
+	case ExporterStdout, "console":
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.OTLPEndpoint, "/")+"/v1/traces"))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts an internal span, e.g. around a controller or repository
+// call, as a child of the span in ctx.
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"inventory.com/pkg/requestid"
+)
+
+func useProvider(t *testing.T, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	t.Helper()
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return provider
+}
+
+func TestMiddlewareAndTransport_PropagateTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	useProvider(t, sdktrace.WithSpanProcessor(recorder))
+
+	var upstreamHeaders http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHeaders = r.Header.Clone()
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(requestid.Middleware(), Middleware())
+	engine.GET("/products/:id", func(ctx *gin.Context) {
+		c, span := Start(ctx.Request.Context(), "controller.ProductController.Get")
+		defer span.End()
+		req, _ := http.NewRequestWithContext(c, http.MethodGet, upstream.URL, nil)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	const callerTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/products/7", nil)
+	req.Header.Set("traceparent", "00-"+callerTrace+"-00f067aa0ba902b7-01")
+	req.Header.Set(requestid.Header, "req-1")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	outgoing, internal, server := spans[0], spans[1], spans[2]
+	assert.Equal(t, "GET /products/:id", server.Name())
+	assert.Equal(t, callerTrace, server.SpanContext().TraceID().String(), "the caller's trace is continued")
+	assert.Equal(t, codes.Error, server.Status().Code)
+	assert.Contains(t, server.Attributes(), AttributeRequestID.String("req-1"))
+	assert.Equal(t, server.SpanContext().SpanID(), internal.Parent().SpanID())
+	assert.Equal(t, internal.SpanContext().SpanID(), outgoing.Parent().SpanID())
+
+	assert.Equal(t, "req-1", upstreamHeaders.Get(requestid.Header))
+	traceparent := upstreamHeaders.Get("traceparent")
+	assert.True(t, strings.HasPrefix(traceparent, "00-"+callerTrace+"-"+outgoing.SpanContext().SpanID().String()), traceparent)
+}
+
+func TestInit_OTLPExporter(t *testing.T) {
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer collector.Close()
+
+	shutdown, err := Init(Config{ServiceName: "catalog", Exporter: ExporterOTLP, OTLPEndpoint: collector.URL + "/"})
+	require.NoError(t, err)
+	_, span := Start(context.Background(), "controller.CategoryController.Delete")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	assert.Equal(t, []string{"/v1/traces"}, paths)
+}
+
+func TestInit_RejectsUnknownExporter(t *testing.T) {
+	_, err := Init(Config{ServiceName: "catalog", Exporter: "zipkin"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,334 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/tenant"
+)
+
+// Dispatcher keeps webhook subscriptions and their deliveries in memory. It
+// is an events.Bus: publishing an event queues one delivery per matching
+// subscription of the event's tenant, and Flush sends the queued deliveries
+// of every tenant that are due. All other methods only see the subscriptions
+// of the tenant of their context.
+type Dispatcher struct {
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+	deliveries    []*Delivery
+	client        *http.Client
+	maxAttempts   int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	logSize       int
+}
+
+// NewDispatcher returns a dispatcher without subscriptions. A delivery is
+// attempted up to maxAttempts times, waiting minBackoff after the first
+// failure and doubling per attempt up to maxBackoff, before it is dead-lettered.
+// The delivery log keeps the logSize most recent finished deliveries of every
+// subscription, delivered or dead-lettered; pending deliveries are always kept.
+func NewDispatcher(timeout time.Duration, maxAttempts int, minBackoff, maxBackoff time.Duration, logSize int) *Dispatcher {
+	return &Dispatcher{
+		subscriptions: make(map[string]*Subscription),
+		client:        &http.Client{Timeout: timeout},
+		maxAttempts:   maxAttempts,
+		minBackoff:    minBackoff,
+		maxBackoff:    maxBackoff,
+		logSize:       logSize,
+	}
+}
+
+// Subscribe validates and stores a subscription, generating a secret if none
+// was given. The returned copy is the only one that includes the secret.
+func (d *Dispatcher) Subscribe(ctx context.Context, sub *Subscription) (*Subscription, error) {
+	if err := sub.Validate(); err != nil {
+		return nil, err
+	}
+
+	created := *sub
+	created.ID = newID()
+	created.Tenant = tenant.FromContext(ctx)
+	created.CreatedAt = time.Now()
+	if created.Secret == "" {
+		created.Secret = newID()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stored := created
+	d.subscriptions[created.ID] = &stored
+	return &created, nil
+}
+
+// Unsubscribe removes a subscription together with its deliveries.
+func (d *Dispatcher) Unsubscribe(ctx context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.owned(ctx, id); !ok {
+		return fmt.Errorf("%w: id=%s", ErrSubscriptionNotFound, id)
+	}
+	delete(d.subscriptions, id)
+	d.deliveries = slices.DeleteFunc(d.deliveries, func(del *Delivery) bool { return del.SubscriptionID == id })
+	return nil
+}
+
+// Subscription returns a subscription without its secret.
+func (d *Dispatcher) Subscription(ctx context.Context, id string) (*Subscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sub, ok := d.owned(ctx, id)
+	if !ok {
+		return nil, fmt.Errorf("%w: id=%s", ErrSubscriptionNotFound, id)
+	}
+	return redacted(sub), nil
+}
+
+// Subscriptions returns all subscriptions without their secrets, oldest first.
+func (d *Dispatcher) Subscriptions(ctx context.Context) []*Subscription {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	subs := make([]*Subscription, 0, len(d.subscriptions))
+	for _, sub := range d.subscriptions {
+		if sub.Tenant == tenant.FromContext(ctx) {
+			subs = append(subs, redacted(sub))
+		}
+	}
+	slices.SortFunc(subs, func(a, b *Subscription) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return subs
+}
+
+// Publish queues a delivery of the event to every matching subscription of
+// the tenant of ctx, which events.Relay binds to the tenant of the event. It
+// never fails: delivery errors are handled by the dispatcher's own retries.
+func (d *Dispatcher) Publish(ctx context.Context, event *events.Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for _, sub := range d.subscriptions {
+		if sub.Tenant != tenant.FromContext(ctx) || !sub.Matches(event.Type) {
+			continue
+		}
+		d.deliveries = append(d.deliveries, &Delivery{
+			ID:             newID(),
+			SubscriptionID: sub.ID,
+			Event:          event,
+			Status:         StatusPending,
+			NextAttemptAt:  now,
+		})
+	}
+	return nil
+}
+
+// Deliveries returns the delivery log of a subscription, oldest first.
+func (d *Dispatcher) Deliveries(ctx context.Context, subscriptionID string) ([]*Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.owned(ctx, subscriptionID); !ok {
+		return nil, fmt.Errorf("%w: id=%s", ErrSubscriptionNotFound, subscriptionID)
+	}
+	return d.find(func(del *Delivery) bool { return del.SubscriptionID == subscriptionID }), nil
+}
+
+// DeadLetters returns the deliveries that exhausted their attempts, oldest first.
+func (d *Dispatcher) DeadLetters(ctx context.Context) []*Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.find(func(del *Delivery) bool {
+		_, ok := d.owned(ctx, del.SubscriptionID)
+		return ok && del.Status == StatusDeadLettered
+	})
+}
+
+// Redeliver moves a dead-lettered delivery back to the queue with a fresh
+// set of attempts. The attempt log is kept.
+func (d *Dispatcher) Redeliver(ctx context.Context, deliveryID string) (*Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, del := range d.deliveries {
+		if del.ID != deliveryID {
+			continue
+		}
+		if _, ok := d.owned(ctx, del.SubscriptionID); !ok {
+			break
+		}
+		if del.Status != StatusDeadLettered {
+			return nil, fmt.Errorf("%w: id=%s", ErrNotDeadLettered, deliveryID)
+		}
+		del.Status = StatusPending
+		del.NextAttemptAt = time.Now()
+		del.failures = 0
+		return copyDelivery(del), nil
+	}
+	return nil, fmt.Errorf("%w: id=%s", ErrDeliveryNotFound, deliveryID)
+}
+
+// Flush sends the deliveries due at now and returns how many succeeded.
+func (d *Dispatcher) Flush(ctx context.Context, now time.Time) int {
+	type job struct {
+		delivery *Delivery
+		sub      Subscription
+	}
+	d.mu.Lock()
+	var due []job
+	for _, del := range d.deliveries {
+		sub, ok := d.subscriptions[del.SubscriptionID]
+		if ok && del.Status == StatusPending && !del.NextAttemptAt.After(now) {
+			due = append(due, job{delivery: del, sub: *sub})
+		}
+	}
+	d.mu.Unlock()
+
+	delivered := 0
+	for _, j := range due {
+		start := time.Now()
+		statusCode, err := d.send(ctx, &j.sub, j.delivery, now)
+		attempt := Attempt{At: now, StatusCode: statusCode, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+
+		d.mu.Lock()
+		del := j.delivery
+		del.Attempts = append(del.Attempts, attempt)
+		switch {
+		case err == nil:
+			del.Status = StatusDelivered
+			del.NextAttemptAt = time.Time{}
+			delivered++
+		case del.failures+1 >= d.maxAttempts:
+			del.Status = StatusDeadLettered
+			del.NextAttemptAt = time.Time{}
+			slog.ErrorContext(ctx, "webhook delivery dead-lettered", "delivery_id", del.ID, "event_type", del.Event.Type, "url", j.sub.URL, "error", err)
+		default:
+			del.failures++
+			del.NextAttemptAt = now.Add(d.backoff(del.failures))
+		}
+		d.mu.Unlock()
+	}
+
+	d.mu.Lock()
+	d.prune()
+	d.mu.Unlock()
+	return delivered
+}
+
+// prune drops the oldest finished deliveries of every subscription beyond the
+// log size. Callers hold the lock.
+func (d *Dispatcher) prune() {
+	finished := make(map[string]int)
+	kept := make([]*Delivery, 0, len(d.deliveries))
+	for i := len(d.deliveries) - 1; i >= 0; i-- {
+		del := d.deliveries[i]
+		if del.Status != StatusPending {
+			finished[del.SubscriptionID]++
+			if finished[del.SubscriptionID] > d.logSize {
+				continue
+			}
+		}
+		kept = append(kept, del)
+	}
+	slices.Reverse(kept)
+	d.deliveries = kept
+}
+
+// Run flushes due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.Flush(ctx, now)
+		}
+	}
+}
+
+// send posts one signed delivery and returns the response status code.
+// Any response other than 2xx counts as a failure.
+func (d *Dispatcher) send(ctx context.Context, sub *Subscription, del *Delivery, now time.Time) (int, error) {
+	body, err := json.Marshal(del.Event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(events.HeaderEventID, del.Event.ID)
+	req.Header.Set(events.HeaderEventType, del.Event.Type)
+	req.Header.Set(HeaderDelivery, del.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay after the given number of consecutive failures.
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	delay := d.minBackoff
+	for i := 1; i < failures && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
+
+// owned returns a subscription of the tenant of ctx. Callers hold the lock.
+func (d *Dispatcher) owned(ctx context.Context, id string) (*Subscription, bool) {
+	sub, ok := d.subscriptions[id]
+	if !ok || sub.Tenant != tenant.FromContext(ctx) {
+		return nil, false
+	}
+	return sub, true
+}
+
+// find returns copies of the deliveries matching keep. Callers hold the lock.
+func (d *Dispatcher) find(keep func(*Delivery) bool) []*Delivery {
+	found := []*Delivery{}
+	for _, del := range d.deliveries {
+		if keep(del) {
+			found = append(found, copyDelivery(del))
+		}
+	}
+	return found
+}
+
+func copyDelivery(del *Delivery) *Delivery {
+	copied := *del
+	copied.Attempts = slices.Clone(del.Attempts)
+	return &copied
+}
+
+func redacted(sub *Subscription) *Subscription {
+	copied := *sub
+	copied.Secret = ""
+	copied.EventTypes = slices.Clone(sub.EventTypes)
+	return &copied
+}
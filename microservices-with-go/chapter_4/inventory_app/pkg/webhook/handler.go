@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireSignature lets through only requests signed with secret, as by
+// Signer, less than tolerance ago; older signatures could be replays.
+func RequireSignature(secret string, tolerance time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		unix, err := strconv.ParseInt(ctx.GetHeader(HeaderTimestamp), 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing signature timestamp"})
+			return
+		}
+		timestamp := time.Unix(unix, 0)
+		if age := time.Since(timestamp); age > tolerance || age < -tolerance {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "signature expired"})
+			return
+		}
+		payload, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read the request body"})
+			return
+		}
+		if !Verify(secret, timestamp, payload, ctx.GetHeader(HeaderSignature)) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(payload))
+		ctx.Next()
+	}
+}
+
+// InitHandler registers the /webhooks routes managing subscriptions of the dispatcher:
+//
+//	POST   /webhooks                                 subscribe; the response carries the secret
+//	GET    /webhooks                                 list subscriptions
+//	GET    /webhooks/:id                             get a subscription
+//	DELETE /webhooks/:id                             unsubscribe
+//	GET    /webhooks/:id/deliveries                  delivery log of a subscription
+//	GET    /webhooks/dead-letters                    deliveries that exhausted their attempts
+//	POST   /webhooks/dead-letters/:id/redeliver      queue a dead-lettered delivery again
+func InitHandler(engine gin.IRouter, dispatcher *Dispatcher) {
+	router := engine.Group("/webhooks")
+
+	router.POST("", func(ctx *gin.Context) {
+		var sub Subscription
+		if err := ctx.ShouldBindJSON(&sub); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+			return
+		}
+		created, err := dispatcher.Subscribe(ctx.Request.Context(), &sub)
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, created)
+	})
+
+	router.GET("", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, dispatcher.Subscriptions(ctx.Request.Context()))
+	})
+
+	router.GET("dead-letters", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, dispatcher.DeadLetters(ctx.Request.Context()))
+	})
+
+	router.POST("dead-letters/:id/redeliver", func(ctx *gin.Context) {
+		delivery, err := dispatcher.Redeliver(ctx.Request.Context(), ctx.Param("id"))
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusAccepted, delivery)
+	})
+
+	router.GET(":id", func(ctx *gin.Context) {
+		sub, err := dispatcher.Subscription(ctx.Request.Context(), ctx.Param("id"))
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, sub)
+	})
+
+	router.DELETE(":id", func(ctx *gin.Context) {
+		if err := dispatcher.Unsubscribe(ctx.Request.Context(), ctx.Param("id")); err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	})
+
+	router.GET(":id/deliveries", func(ctx *gin.Context) {
+		deliveries, err := dispatcher.Deliveries(ctx.Request.Context(), ctx.Param("id"))
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, deliveries)
+	})
+}
+
+func handleError(ctx *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrInvalidSubscription):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrSubscriptionNotFound), errors.Is(err, ErrDeliveryNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNotDeadLettered):
+		status = http.StatusConflict
+	}
+	ctx.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,128 @@
+// Package webhook delivers domain events to subscribed HTTP endpoints. Every
+// delivery is signed with the subscription secret, failed deliveries are
+// retried with exponential backoff and end up on a dead-letter list from
+// which they can be redelivered manually.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/tenant"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+	ErrNotDeadLettered      = errors.New("webhook delivery is not dead-lettered")
+)
+
+// Headers set on every delivery in addition to events.HeaderEventID and
+// events.HeaderEventType.
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Subscription is an endpoint receiving the events of the listed types. An
+// empty EventTypes list, or one containing events.AllEvents, selects every
+// event. A subscription belongs to the tenant it was created for and only
+// receives the events of that tenant.
+type Subscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Secret     string    `json:"secret,omitempty"` // Only returned when the subscription is created
+	Tenant     tenant.ID `json:"tenant"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Validate checks that the subscription targets an absolute http(s) URL.
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// Matches reports whether the subscription wants events of the given type.
+func (s *Subscription) Matches(eventType string) bool {
+	return len(s.EventTypes) == 0 ||
+		slices.Contains(s.EventTypes, events.AllEvents) ||
+		slices.Contains(s.EventTypes, eventType)
+}
+
+// Status is the state of a delivery.
+type Status string
+
+const (
+	StatusPending      Status = "pending"
+	StatusDelivered    Status = "delivered"
+	StatusDeadLettered Status = "dead"
+)
+
+// Attempt is one entry of the delivery log.
+type Attempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// Delivery is an event on its way to one subscription.
+type Delivery struct {
+	ID             string        `json:"id"`
+	SubscriptionID string        `json:"subscriptionID"`
+	Event          *events.Event `json:"event"`
+	Status         Status        `json:"status"`
+	NextAttemptAt  time.Time     `json:"nextAttemptAt,omitzero"`
+	Attempts       []Attempt     `json:"attempts"`
+
+	failures int // Consecutive failed attempts since the delivery was queued or redelivered
+}
+
+// Sign returns the signature of a payload sent at timestamp, as carried by
+// HeaderSignature: "sha256=" followed by the hex HMAC-SHA256 of
+// "<unix timestamp>.<payload>" keyed with the subscription secret. Including
+// the timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the payload sent at timestamp.
+func Verify(secret string, timestamp time.Time, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
+
+// Signer signs the requests of an events.WebhookSink like the deliveries to
+// subscriptions, so that the receiver can check them with RequireSignature.
+func Signer(secret string) events.Signer {
+	return func(req *http.Request, payload []byte) {
+		now := time.Now()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(HeaderSignature, Sign(secret, now, payload))
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"inventory.com/pkg/events"
+	"inventory.com/pkg/tenant"
+)
+
+func TestDispatcherSignsDeliveries(t *testing.T) {
+	ctx := context.Background()
+	var verified bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		unix, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		verified = Verify("s3cret", time.Unix(unix, 0), body, r.Header.Get(HeaderSignature))
+		assert.Equal(t, "OrderCompleted", r.Header.Get(events.HeaderEventType))
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(time.Second, 3, time.Second, time.Minute, 10)
+	sub, err := d.Subscribe(ctx, &Subscription{URL: server.URL, EventTypes: []string{"OrderCompleted"}, Secret: "s3cret"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, d.Publish(ctx, &events.Event{ID: "1", Type: "OrderCompleted"}))
+	assert.NoError(t, d.Publish(ctx, &events.Event{ID: "2", Type: "OrderCreated"}))
+	assert.Equal(t, 1, d.Flush(ctx, time.Now()))
+	assert.True(t, verified)
+
+	log, err := d.Deliveries(ctx, sub.ID)
+	assert.NoError(t, err)
+	assert.Len(t, log, 1, "unsubscribed event types are not delivered")
+	assert.Equal(t, StatusDelivered, log[0].Status)
+}
+
+func TestDispatcherDeadLettersAndRedelivers(t *testing.T) {
+	ctx := context.Background()
+	healthy := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(time.Second, 2, time.Second, time.Minute, 10)
+	sub, _ := d.Subscribe(ctx, &Subscription{URL: server.URL})
+	assert.NoError(t, d.Publish(ctx, &events.Event{ID: "1", Type: "StockChanged"}))
+
+	now := time.Now()
+	assert.Equal(t, 0, d.Flush(ctx, now))
+	assert.Equal(t, 0, d.Flush(ctx, now.Add(500*time.Millisecond)), "not due before the backoff has passed")
+	assert.Empty(t, d.DeadLetters(ctx))
+	assert.Equal(t, 0, d.Flush(ctx, now.Add(time.Second)))
+
+	dead := d.DeadLetters(ctx)
+	assert.Len(t, dead, 1)
+	assert.Len(t, dead[0].Attempts, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, dead[0].Attempts[1].StatusCode)
+
+	healthy = true
+	_, err := d.Redeliver(ctx, dead[0].ID)
+	assert.NoError(t, err)
+	_, err = d.Redeliver(ctx, dead[0].ID)
+	assert.ErrorIs(t, err, ErrNotDeadLettered)
+	assert.Equal(t, 1, d.Flush(ctx, time.Now()))
+
+	log, _ := d.Deliveries(ctx, sub.ID)
+	assert.Equal(t, StatusDelivered, log[0].Status)
+	assert.Len(t, log[0].Attempts, 3)
+}
+
+func TestDispatcherScopesTenants(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { received++ }))
+	defer server.Close()
+
+	d := NewDispatcher(time.Second, 3, time.Second, time.Minute, 10)
+	sub, err := d.Subscribe(acme, &Subscription{URL: server.URL, Tenant: "globex"})
+	assert.NoError(t, err)
+	assert.Equal(t, tenant.ID("acme"), sub.Tenant, "the tenant comes from the context, not the request")
+
+	assert.Empty(t, d.Subscriptions(globex))
+	_, err = d.Subscription(globex, sub.ID)
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	assert.ErrorIs(t, d.Unsubscribe(globex, sub.ID), ErrSubscriptionNotFound)
+
+	assert.NoError(t, d.Publish(globex, &events.Event{ID: "1", Type: "OrderCreated", TenantID: "globex"}))
+	assert.NoError(t, d.Publish(acme, &events.Event{ID: "2", Type: "OrderCreated", TenantID: "acme"}))
+	assert.Equal(t, 1, d.Flush(context.Background(), time.Now()))
+	assert.Equal(t, 1, received)
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	assert.ErrorIs(t, (&Subscription{URL: "ftp://example.com"}).Validate(), ErrInvalidSubscription)
+	assert.ErrorIs(t, (&Subscription{URL: "/relative"}).Validate(), ErrInvalidSubscription)
+	assert.NoError(t, (&Subscription{URL: "https://erp.example.com/hooks"}).Validate())
+}
+
+func TestDispatcherCapsDeliveryLog(t *testing.T) {
+	ctx := context.Background()
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(time.Second, 3, time.Second, time.Minute, 2)
+	sub, _ := d.Subscribe(ctx, &Subscription{URL: server.URL})
+	for i := range 3 {
+		assert.NoError(t, d.Publish(ctx, &events.Event{ID: strconv.Itoa(i), Type: "StockChanged"}))
+	}
+	now := time.Now()
+	assert.Equal(t, 3, d.Flush(ctx, now))
+
+	healthy = false
+	assert.NoError(t, d.Publish(ctx, &events.Event{ID: "3", Type: "StockChanged"}))
+	assert.Equal(t, 0, d.Flush(ctx, now))
+
+	log, err := d.Deliveries(ctx, sub.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, log, 3, "two finished deliveries and the pending one") {
+		assert.Equal(t, "1", log[0].Event.ID, "the oldest finished delivery is dropped")
+		assert.Equal(t, "2", log[1].Event.ID)
+		assert.Equal(t, StatusPending, log[2].Status)
+	}
+}
+
+func TestRequireSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var received events.Event
+	engine.POST("/events", RequireSignature("s3cret", time.Minute), func(ctx *gin.Context) {
+		assert.NoError(t, ctx.ShouldBindJSON(&received))
+		ctx.Status(http.StatusNoContent)
+	})
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	event := &events.Event{ID: "1", Type: "ProductUpdated"}
+	assert.NoError(t, events.NewWebhookSink(server.URL+"/events", time.Second, Signer("s3cret")).Publish(context.Background(), event))
+	assert.Equal(t, "ProductUpdated", received.Type, "the handler reads the verified body")
+	assert.Error(t, events.NewWebhookSink(server.URL+"/events", time.Second, Signer("guess")).Publish(context.Background(), event))
+	assert.Error(t, events.NewWebhookSink(server.URL+"/events", time.Second, nil).Publish(context.Background(), event))
+
+	// A delivery signed long ago is refused even with a valid signature.
+	body := []byte(`{"id":"1","type":"ProductUpdated"}`)
+	sent := time.Now().Add(-time.Hour)
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(sent.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign("s3cret", sent, body))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}